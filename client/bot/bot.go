@@ -351,7 +351,8 @@ func (b *bot) watch() {
 	}
 }
 
-func run(ctx *cli.Context) error {
+// Run the bot
+func Run(ctx *cli.Context, srvOpts ...micro.Option) error {
 	log.Init(log.WithFields(map[string]interface{}{"service": "bot"}))
 
 	// Init plugins
@@ -409,7 +410,7 @@ func run(ctx *cli.Context) error {
 	}
 
 	// setup service
-	service := micro.NewService(
+	service := micro.NewService(append(srvOpts,
 		micro.Name(Name),
 		micro.RegisterTTL(
 			time.Duration(ctx.Int("register_ttl"))*time.Second,
@@ -417,7 +418,7 @@ func run(ctx *cli.Context) error {
 		micro.RegisterInterval(
 			time.Duration(ctx.Int("register_interval"))*time.Second,
 		),
-	)
+	)...)
 
 	// Start bot
 	b := newBot(ctx, ios, cmds, service)
@@ -460,10 +461,12 @@ func Commands() []*cli.Command {
 	}
 
 	command := &cli.Command{
-		Name:   "bot",
-		Usage:  "Run the chatops bot",
-		Flags:  flags,
-		Action: run,
+		Name:  "bot",
+		Usage: "Run the chatops bot",
+		Flags: flags,
+		Action: func(ctx *cli.Context) error {
+			return Run(ctx)
+		},
 	}
 
 	for _, p := range Plugins() {
@@ -413,6 +413,14 @@ func StoreCommands() []*cli.Command {
 					Usage:   "table to write to",
 					Value:   "micro",
 				},
+				&cli.StringFlag{
+					Name:  "if-version",
+					Usage: "only write the record if it has the version, as shown by read -v",
+				},
+				&cli.BoolFlag{
+					Name:  "if-absent",
+					Usage: "only write the record if there isn't one",
+				},
 			},
 		},
 		{
@@ -431,6 +439,45 @@ func StoreCommands() []*cli.Command {
 					Usage: "table to delete from",
 					Value: "micro",
 				},
+				&cli.StringFlag{
+					Name:  "if-version",
+					Usage: "only delete the record if it has the version, as shown by read -v",
+				},
+			},
+		},
+		{
+			Name:      "watch",
+			Usage:     "watch the changes to the keys with a prefix",
+			UsageText: `micro store watch [options] prefix`,
+			Action:    storecli.Watch,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "database",
+					Aliases: []string{"d"},
+					Usage:   "database to watch",
+				},
+				&cli.StringFlag{
+					Name:    "table",
+					Aliases: []string{"t"},
+					Usage:   "table to watch",
+				},
+				&cli.Uint64Flag{
+					Name:  "sequence",
+					Usage: "sequence of the last change seen, to resume watching after it. Only the instance of the store which sent it can resume the watch",
+				},
+				&cli.StringFlag{
+					Name:  "epoch",
+					Usage: "epoch of the last change seen, required with --sequence. It identifies the instance of the store",
+				},
+				&cli.StringFlag{
+					Name:  "output",
+					Usage: "output format (json)",
+				},
+				&cli.StringFlag{
+					Name:  "store",
+					Usage: "store service to call",
+					Value: "go.micro.store",
+				},
 			},
 		},
 		{
@@ -470,15 +517,19 @@ func StoreCommands() []*cli.Command {
 			Flags: append(storecli.CommonFlags,
 				&cli.StringFlag{
 					Name:    "destination",
-					Usage:   "Backup destination",
+					Usage:   "Backup destination: file://, dir://, s3://bucket/key or stdout://, optionally with compress=gzip|zstd and passphrase or key_file to encrypt",
 					Value:   "file:///tmp/store-snapshot",
 					EnvVars: []string{"MICRO_SNAPSHOT_DESTINATION"},
 				},
+				&cli.BoolFlag{
+					Name:  "incremental",
+					Usage: "Only back up the records changed since the last snapshot, chained by a manifest next to the destination",
+				},
 			),
 		},
 		{
 			Name:   "sync",
-			Usage:  "Copy all records of one store into another store",
+			Usage:  "Copy all records of one store into another store, or keep them in sync with --continuous. Sync is one-way, records written to the other store since they were last synced are reported as conflicts and left as they are.",
 			Action: storecli.Sync,
 			Flags:  storecli.SyncFlags,
 		},
@@ -489,9 +540,13 @@ func StoreCommands() []*cli.Command {
 			Flags: append(storecli.CommonFlags,
 				&cli.StringFlag{
 					Name:  "source",
-					Usage: "Backup source",
+					Usage: "Backup source: file://, dir://, s3://bucket/key or stdin://, with the passphrase or key_file the backup was encrypted with",
 					Value: "file:///tmp/store-snapshot",
 				},
+				&cli.StringFlag{
+					Name:  "at",
+					Usage: "Restore the state at a time from incremental snapshots, in RFC3339 format e.g. 2020-07-01T12:00:00Z. Keys which didn't exist then are deleted",
+				},
 			),
 		},
 	}
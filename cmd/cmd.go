@@ -9,6 +9,7 @@ import (
 
 	ccli "github.com/micro/cli/v2"
 	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/client"
 	"github.com/micro/go-micro/v2/config/cmd"
 	gostore "github.com/micro/go-micro/v2/store"
 	"github.com/micro/micro/v2/plugin"
@@ -43,6 +44,7 @@ import (
 	"github.com/micro/micro/v2/internal/helper"
 	"github.com/micro/micro/v2/internal/platform"
 	_ "github.com/micro/micro/v2/internal/plugins"
+	"github.com/micro/micro/v2/internal/rollout"
 	"github.com/micro/micro/v2/internal/update"
 	_ "github.com/micro/micro/v2/internal/usage"
 )
@@ -283,10 +285,17 @@ func setup(app *ccli.App) {
 			(*cmd.DefaultCmd.Options().Store).Init(opts...)
 		}
 
+		// route the share of traffic set by canary rollouts to the canary instances, the selector
+		// configured picks between the instances chosen
+		*cmd.DefaultCmd.Options().Selector = rollout.NewSelector(*cmd.DefaultCmd.Options().Selector, *cmd.DefaultCmd.Options().Registry)
+		if err := (*cmd.DefaultCmd.Options().Client).Init(client.Selector(*cmd.DefaultCmd.Options().Selector)); err != nil {
+			return err
+		}
+
 		// add the system rules if we're using the JWT implementation
 		// which doesn't have access to the rules in the auth service
 		if (*cmd.DefaultCmd.Options().Auth).String() == "jwt" {
-			for _, rule := range inauth.SystemRules {
+			for _, rule := range append(inauth.SystemRules, inauth.RuntimeRules...) {
 				if err := (*cmd.DefaultCmd.Options().Auth).Grant(rule); err != nil {
 					return err
 				}
@@ -16,6 +16,7 @@ require (
 	github.com/google/uuid v1.1.1
 	github.com/gorilla/handlers v1.4.2
 	github.com/gorilla/mux v1.7.3
+	github.com/klauspost/compress v1.11.13
 	github.com/micro/cli/v2 v2.1.2
 	github.com/micro/go-micro/v2 v2.9.1-0.20200702172911-4ff114e7986d
 	github.com/micro/services/signup v0.0.0-20200629142252-9f80a09a8594
@@ -33,6 +34,7 @@ require (
 	golang.org/x/tools v0.0.0-20191216173652-a0e659d51361
 	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.27.0
+	gopkg.in/yaml.v2 v2.2.4
 )
 
 replace google.golang.org/grpc => google.golang.org/grpc v1.26.0
@@ -28,3 +28,53 @@ var SystemRules = []*auth.Rule{
 		Resource: &auth.Resource{Type: "service", Name: "go.micro.registry", Endpoint: "Registry.ListServices"},
 	},
 }
+
+// RuntimeRules restrict running commands in services and collecting the runtime's garbage to
+// admins. They're added to the rules of each namespace by the auth service, and take priority
+// over the default rules. Both the rule for any account and the public one are needed to deny
+// access, since rules scoped to accounts are skipped for requests without one.
+var RuntimeRules = []*auth.Rule{
+	&auth.Rule{
+		ID:       "runtime-exec-admin",
+		Scope:    "admin",
+		Resource: &auth.Resource{Type: "service", Name: "go.micro.runtime", Endpoint: "Manager.Exec"},
+		Access:   auth.AccessGranted,
+		Priority: 2,
+	},
+	&auth.Rule{
+		ID:       "runtime-exec",
+		Scope:    auth.ScopeAccount,
+		Resource: &auth.Resource{Type: "service", Name: "go.micro.runtime", Endpoint: "Manager.Exec"},
+		Access:   auth.AccessDenied,
+		Priority: 1,
+	},
+	&auth.Rule{
+		ID:       "runtime-exec-public",
+		Scope:    auth.ScopePublic,
+		Resource: &auth.Resource{Type: "service", Name: "go.micro.runtime", Endpoint: "Manager.Exec"},
+		Access:   auth.AccessDenied,
+		Priority: 1,
+	},
+	// the orphaned state of every namespace can only be collected by admins
+	&auth.Rule{
+		ID:       "runtime-gc-admin",
+		Scope:    "admin",
+		Resource: &auth.Resource{Type: "service", Name: "go.micro.runtime", Endpoint: "Manager.GC"},
+		Access:   auth.AccessGranted,
+		Priority: 2,
+	},
+	&auth.Rule{
+		ID:       "runtime-gc",
+		Scope:    auth.ScopeAccount,
+		Resource: &auth.Resource{Type: "service", Name: "go.micro.runtime", Endpoint: "Manager.GC"},
+		Access:   auth.AccessDenied,
+		Priority: 1,
+	},
+	&auth.Rule{
+		ID:       "runtime-gc-public",
+		Scope:    auth.ScopePublic,
+		Resource: &auth.Resource{Type: "service", Name: "go.micro.runtime", Endpoint: "Manager.GC"},
+		Access:   auth.AccessDenied,
+		Priority: 1,
+	},
+}
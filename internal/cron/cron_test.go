@@ -0,0 +1,46 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2020, time.July, 1, 12, 3, 30, 0, time.UTC) // a wednesday
+
+	tt := []struct {
+		Spec string
+		Next time.Time
+	}{
+		{"* * * * *", time.Date(2020, time.July, 1, 12, 4, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2020, time.July, 1, 12, 5, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2020, time.July, 1, 13, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2020, time.July, 2, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2020, time.July, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2020, time.July, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, time.July, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)},
+		{"15,45 12 * * *", time.Date(2020, time.July, 1, 12, 15, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, time.July, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tc := range tt {
+		s, err := Parse(tc.Spec)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %v", tc.Spec, err)
+			continue
+		}
+		if next := s.Next(from); !next.Equal(tc.Next) {
+			t.Errorf("Expected the next time of %q to be %v, got %v", tc.Spec, tc.Next, next)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected an error parsing %q", spec)
+		}
+	}
+}
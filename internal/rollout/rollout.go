@@ -0,0 +1,108 @@
+// Package rollout contains the conventions shared by the runtime manager, which runs canary and
+// blue/green rollouts, and the clients which route traffic to them
+package rollout
+
+import (
+	"math/rand"
+	"strconv"
+
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/registry/cache"
+	"github.com/micro/go-micro/v2/router"
+	"github.com/micro/go-micro/v2/selector"
+)
+
+const (
+	// MetadataKey is the node metadata key set on the instances started by a rollout
+	MetadataKey = "rollout"
+	// TrafficKey is the node metadata key containing the percentage of traffic a canary should
+	// receive
+	TrafficKey = "traffic"
+	// Canary is the value of MetadataKey for canary instances
+	Canary = "canary"
+	// Green is the value of MetadataKey for the new instances in a blue/green rollout
+	Green = "green"
+)
+
+// NewSelector wraps the selector so the percentage of requests set in the traffic metadata of
+// canary nodes is sent to them, and the rest to the other nodes. The new nodes of a blue/green
+// rollout are only sent requests once the nodes of the current version have gone, which is when
+// the rollout switches the service over to the new version. Routes don't have the metadata of
+// their node, so the nodes are looked up in a cache of the registry. The selector wrapped picks
+// between the routes chosen. A rollout selector is unwrapped rather than wrapped again, e.g. when
+// the registry it uses is replaced.
+func NewSelector(s selector.Selector, r registry.Registry) selector.Selector {
+	if rs, ok := s.(*rolloutSelector); ok {
+		rs.cache.Stop()
+		s = rs.Selector
+	}
+	return &rolloutSelector{Selector: s, cache: cache.New(r)}
+}
+
+type rolloutSelector struct {
+	selector.Selector
+	cache cache.Cache
+}
+
+func (s *rolloutSelector) Select(routes []router.Route, opts ...selector.SelectOption) (*router.Route, error) {
+	options := selector.NewSelectOptions(opts...)
+	for _, f := range options.Filters {
+		routes = f(routes)
+	}
+	if len(routes) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	// the metadata of the nodes of the services routed to, by address
+	nodes := make(map[string]map[string]string)
+	for _, route := range routes {
+		if _, ok := nodes[route.Address]; ok {
+			continue
+		}
+		services, err := s.cache.GetService(route.Service)
+		if err != nil {
+			// without the nodes the routes can only be selected between by the selector wrapped
+			break
+		}
+		for _, srv := range services {
+			for _, node := range srv.Nodes {
+				nodes[node.Address] = node.Metadata
+			}
+		}
+	}
+
+	var stable, canary, green []router.Route
+	var traffic int
+	for _, route := range routes {
+		md := nodes[route.Address]
+		switch md[MetadataKey] {
+		case Canary:
+			if t, err := strconv.Atoi(md[TrafficKey]); err == nil {
+				traffic = t
+			}
+			canary = append(canary, route)
+		case Green:
+			green = append(green, route)
+		default:
+			stable = append(stable, route)
+		}
+	}
+
+	routes = stable
+	if len(canary) > 0 && (len(stable) == 0 || rand.Intn(100) < traffic) {
+		routes = canary
+	}
+	if len(routes) == 0 {
+		routes = green
+	}
+	return s.Selector.Select(routes)
+}
+
+func (s *rolloutSelector) Close() error {
+	s.cache.Stop()
+	return s.Selector.Close()
+}
+
+func (s *rolloutSelector) String() string {
+	return "rollout"
+}
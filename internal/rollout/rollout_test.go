@@ -0,0 +1,81 @@
+package rollout
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/registry/memory"
+	"github.com/micro/go-micro/v2/router"
+	"github.com/micro/go-micro/v2/selector/roundrobin"
+)
+
+// register the nodes of the service with the rollout tags provided, by address
+func register(t *testing.T, r registry.Registry, name string, tags map[string]string) []router.Route {
+	var routes []router.Route
+	for addr, tag := range tags {
+		node := &registry.Node{Id: addr, Address: addr, Metadata: map[string]string{}}
+		if len(tag) > 0 {
+			node.Metadata[MetadataKey] = tag
+			node.Metadata[TrafficKey] = "0"
+		}
+		if err := r.Register(&registry.Service{Name: name, Version: addr, Nodes: []*registry.Node{node}}); err != nil {
+			t.Fatal(err)
+		}
+		routes = append(routes, router.Route{Service: name, Address: addr})
+	}
+	return routes
+}
+
+func TestSelectorGreen(t *testing.T) {
+	r := memory.NewRegistry()
+	s := NewSelector(roundrobin.NewSelector(), r)
+	defer s.Close()
+
+	// the new nodes of a blue/green rollout aren't selected whilst the current nodes are registered
+	routes := register(t, r, "foo", map[string]string{"blue:1": "", "blue:2": "", "green:1": Green})
+	for i := 0; i < 20; i++ {
+		route, err := s.Select(routes)
+		if err != nil {
+			t.Fatalf("Unexpected error selecting a route: %v", err)
+		}
+		if route.Address == "green:1" {
+			t.Fatalf("Expected the green node not to be selected before the switch")
+		}
+	}
+
+	// once the current nodes have gone the green nodes serve the requests
+	route, err := s.Select([]router.Route{{Service: "foo", Address: "green:1"}})
+	if err != nil {
+		t.Fatalf("Unexpected error selecting a route: %v", err)
+	}
+	if route.Address != "green:1" {
+		t.Fatalf("Expected the green node to be selected, got %v", route.Address)
+	}
+}
+
+func TestSelectorWraps(t *testing.T) {
+	r := memory.NewRegistry()
+	s := NewSelector(roundrobin.NewSelector(), r)
+	if s.String() != "rollout" {
+		t.Fatalf("Expected the rollout selector, got %v", s.String())
+	}
+
+	// the configured selector picks between the routes, round robin visits each node in turn
+	routes := register(t, r, "foo", map[string]string{"foo:1": "", "foo:2": ""})
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		route, err := s.Select(routes)
+		if err != nil {
+			t.Fatalf("Unexpected error selecting a route: %v", err)
+		}
+		seen[route.Address] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("Expected the round robin selector to pick each node, got %v", seen)
+	}
+
+	// wrapping a rollout selector replaces it rather than nesting it
+	if rs := NewSelector(s, memory.NewRegistry()).(*rolloutSelector); rs.Selector.String() != "roundrobin" {
+		t.Fatalf("Expected the round robin selector to be wrapped, got %v", rs.Selector.String())
+	}
+}
@@ -52,6 +52,22 @@ resource "kubernetes_secret" "micro_keypair" {
   }
 }
 
+resource "random_id" "secrets_key" {
+  byte_length = 32
+}
+
+resource "kubernetes_secret" "secrets_key" {
+  metadata {
+    name        = "micro-secrets-key"
+    namespace   = kubernetes_namespace.platform.id
+    labels      = local.common_labels
+    annotations = local.common_annotations
+  }
+  data = {
+    key = random_id.secrets_key.b64_std
+  }
+}
+
 resource "kubernetes_secret" "platform_ca" {
   metadata {
     name        = "platform-ca"
@@ -91,6 +91,15 @@ resource "kubernetes_deployment" "runtime" {
               }
             }
           }
+          env {
+            name = "MICRO_RUNTIME_SECRETS_KEY"
+            value_from {
+              secret_key_ref {
+                name = kubernetes_secret.secrets_key.metadata[0].name
+                key  = "key"
+              }
+            }
+          }
           args              = ["runtime"]
           image             = var.micro_image
           image_pull_policy = var.image_pull_policy
@@ -188,6 +197,14 @@ resource "kubernetes_cluster_role" "runtime" {
       "watch",
     ]
   }
+  rule {
+    api_groups = [""]
+    resources  = ["pods/exec"]
+    verbs = [
+      "create",
+      "get",
+    ]
+  }
 }
 
 resource "kubernetes_cluster_role_binding" "runtime" {
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// config of the services run by the server, loaded from the file passed to micro server --config
+//
+//	services:
+//	  bot:
+//	    disabled: true
+//	  api:
+//	    address: :9090
+//	    env:
+//	      MICRO_API_NAMESPACE: foo
+//	    flags:
+//	      enable_cors: "true"
+//	  billing:
+//	    command: /usr/local/bin/billing
+//	    service: foo.service.billing
+//	    deps: [store, auth]
+//	    replicas: 2
+//
+// Disabling a core service, e.g. to use one run elsewhere, removes it from the deps of the other
+// core services. The deps set in the config can't be disabled. Core services which listen on a
+// fixed address can't run replicas.
+type config struct {
+	Services map[string]*serviceConfig `yaml:"services"`
+}
+
+// serviceConfig overrides a core service or adds a custom one
+type serviceConfig struct {
+	// Disabled services aren't run by the server
+	Disabled bool `yaml:"disabled"`
+	// Command runs a custom service, core services are run by the micro binary
+	Command string `yaml:"command"`
+	// Image of a custom service, for runtimes which run images
+	Image string `yaml:"image"`
+	// Service is the name a custom service registers with, it isn't health checked without one
+	Service string `yaml:"service"`
+	// Deps are started before the service, in addition to the deps of a core service
+	Deps []string `yaml:"deps"`
+	// Env vars set for the service, overriding the env of the server
+	Env map[string]string `yaml:"env"`
+	// Flags passed to the service as --key=value
+	Flags map[string]string `yaml:"flags"`
+	// Address the service listens on
+	Address string `yaml:"address"`
+	// Replicas of the service to run, one by default
+	Replicas int `yaml:"replicas"`
+	// Check overrides how the service is determined to be healthy: health, registered or none
+	Check string `yaml:"check"`
+}
+
+// loadConfig reads the config and checks the services it sets are valid, the deps of the services
+// are checked once the config is applied
+func loadConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c *config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, fmt.Errorf("Error parsing config %v: %v", path, err)
+	}
+	if c == nil {
+		return &config{}, nil
+	}
+
+	// the core services and the address they listen on, if it's fixed
+	core := make(map[string]string, len(services))
+	for _, srv := range services {
+		core[srv.Name] = srv.Address
+	}
+
+	for name, srv := range c.Services {
+		if srv == nil {
+			return nil, fmt.Errorf("Service %v in config %v is empty", name, path)
+		}
+		_, isCore := core[name]
+		if !isCore && len(srv.Command) == 0 {
+			return nil, fmt.Errorf("Service %v in config %v isn't a core service and is missing a command", name, path)
+		}
+		if isCore && (len(srv.Command) > 0 || len(srv.Image) > 0 || len(srv.Service) > 0) {
+			return nil, fmt.Errorf("Service %v in config %v is a core service, its command, image and service can't be set", name, path)
+		}
+		if srv.Replicas < 0 {
+			return nil, fmt.Errorf("Service %v in config %v has negative replicas", name, path)
+		}
+		if srv.Replicas > 1 && len(srv.Address) > 0 {
+			return nil, fmt.Errorf("Service %v in config %v sets an address so it can't run %v replicas", name, path, srv.Replicas)
+		}
+		if addr := core[name]; srv.Replicas > 1 && len(addr) > 0 {
+			return nil, fmt.Errorf("Service %v in config %v listens on %v so it can't run %v replicas", name, path, addr, srv.Replicas)
+		}
+		switch srv.Check {
+		case "", checkHealth, checkRegistered, checkNone:
+		default:
+			return nil, fmt.Errorf("Service %v in config %v has unknown check %v, expected %v, %v or %v", name, path, srv.Check, checkHealth, checkRegistered, checkNone)
+		}
+		if (srv.Check == checkHealth || srv.Check == checkRegistered) && !isCore && len(srv.Service) == 0 {
+			return nil, fmt.Errorf("Service %v in config %v must set the service it registers as to be checked", name, path)
+		}
+	}
+
+	return c, nil
+}
+
+// apply the config to the services, returning the services the server runs. The services passed
+// aren't modified. Custom services are run after the core services, sorted by name.
+func (c *config) apply(services []*coreService) ([]*coreService, error) {
+	disabled := make(map[string]bool)
+	for name, cfg := range c.Services {
+		if cfg.Disabled {
+			disabled[name] = true
+		}
+	}
+
+	var result []*coreService
+	for _, srv := range services {
+		if disabled[srv.Name] {
+			continue
+		}
+
+		// the core services don't wait for the disabled ones
+		var deps []string
+		for _, d := range srv.Deps {
+			if !disabled[d] {
+				deps = append(deps, d)
+			}
+		}
+		cfg, ok := c.Services[srv.Name]
+		if !ok && len(deps) == len(srv.Deps) {
+			result = append(result, srv)
+			continue
+		}
+
+		s := *srv
+		s.Deps = deps
+		if !ok {
+			result = append(result, &s)
+			continue
+		}
+		if err := checkDeps(srv.Name, cfg.Deps, disabled); err != nil {
+			return nil, err
+		}
+		s.Deps = append(s.Deps, cfg.Deps...)
+		s.Env = append(append([]string{}, srv.Env...), cfg.env()...)
+		s.Flags = append(append([]string{}, srv.Flags...), cfg.flags()...)
+		if len(cfg.Check) > 0 {
+			s.Check = cfg.Check
+		}
+		if cfg.Replicas > 0 {
+			s.Replicas = cfg.Replicas
+		}
+		if len(cfg.Address) > 0 && s.AddressEnv {
+			s.Env = append(s.Env, "MICRO_SERVER_ADDRESS="+cfg.Address)
+		} else if len(cfg.Address) > 0 {
+			s.Flags = append(s.Flags, "--address="+cfg.Address)
+		}
+		result = append(result, &s)
+	}
+
+	var names []string
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	core := make(map[string]bool, len(services))
+	for _, srv := range services {
+		core[srv.Name] = true
+	}
+	for _, name := range names {
+		cfg := c.Services[name]
+		if core[name] || cfg.Disabled {
+			continue
+		}
+		if err := checkDeps(name, cfg.Deps, disabled); err != nil {
+			return nil, err
+		}
+
+		s := &coreService{
+			Name:     name,
+			Service:  cfg.Service,
+			Command:  strings.Fields(cfg.Command),
+			Image:    cfg.Image,
+			Flags:    cfg.flags(),
+			Deps:     cfg.Deps,
+			Env:      cfg.env(),
+			Check:    cfg.Check,
+			Replicas: cfg.Replicas,
+		}
+		if len(s.Check) == 0 && len(s.Service) == 0 {
+			s.Check = checkNone
+		}
+		if len(cfg.Address) > 0 {
+			s.Env = append(s.Env, "MICRO_SERVER_ADDRESS="+cfg.Address)
+		}
+		result = append(result, s)
+	}
+
+	return result, nil
+}
+
+// checkDeps returns an error if the deps of a service set in the config are disabled
+func checkDeps(name string, deps []string, disabled map[string]bool) error {
+	for _, d := range deps {
+		if disabled[d] {
+			return fmt.Errorf("Service %v depends on %v which is disabled", name, d)
+		}
+	}
+	return nil
+}
+
+// env returns the env vars in the format accepted by the runtime, sorted so they're stable
+func (s *serviceConfig) env() []string {
+	var env []string
+	for k, v := range s.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// flags returns the flags passed to the service, sorted so they're stable
+func (s *serviceConfig) flags() []string {
+	var flags []string
+	for k, v := range s.Flags {
+		flags = append(flags, "--"+k+"="+v)
+	}
+	sort.Strings(flags)
+	return flags
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	load := func(content string) (*config, error) {
+		path := filepath.Join(dir, "server.yaml")
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return loadConfig(path)
+	}
+
+	invalid := map[string]string{
+		"unknown field":          "services:\n  api:\n    port: 8080\n",
+		"custom without command": "services:\n  billing:\n    deps: [store]\n",
+		"core command":           "services:\n  api:\n    command: ./api\n",
+		"negative replicas":      "services:\n  web:\n    replicas: -1\n",
+		"replicas with address":  "services:\n  web:\n    replicas: 2\n    address: :9000\n",
+		"replicas of fixed port": "services:\n  web:\n    replicas: 2\n",
+		"unknown check":          "services:\n  api:\n    check: ping\n",
+		"unregistered check":     "services:\n  billing:\n    command: ./billing\n    check: health\n",
+	}
+	for name, content := range invalid {
+		if _, err := load(content); err == nil {
+			t.Errorf("Expected an error loading the config with %v", name)
+		}
+	}
+
+	c, err := load(`services:
+  bot:
+    disabled: true
+  api:
+    address: :9090
+    env:
+      MICRO_API_NAMESPACE: foo
+    flags:
+      enable_cors: "true"
+  config:
+    address: :9091
+  billing:
+    command: /usr/local/bin/billing --verbose
+    service: foo.service.billing
+    deps: [store, auth]
+    replicas: 2
+  audit:
+    command: ./audit
+    deps: [billing]
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the config: %v", err)
+	}
+	run, err := c.apply(services)
+	if err != nil {
+		t.Fatalf("Unexpected error applying the config: %v", err)
+	}
+	if _, err := startOrder(run); err != nil {
+		t.Fatalf("Unexpected error ordering the services: %v", err)
+	}
+
+	byName := make(map[string]*coreService)
+	for _, srv := range run {
+		byName[srv.Name] = srv
+	}
+	if len(run) != len(services)+1 || byName["bot"] != nil {
+		t.Fatalf("Expected the bot to be disabled and two services to be added, got %v services", len(run))
+	}
+	if run[len(run)-2].Name != "audit" || run[len(run)-1].Name != "billing" {
+		t.Errorf("Expected the custom services to be run last in order of name")
+	}
+
+	api := byName["api"]
+	if !reflect.DeepEqual(api.Flags, []string{"--enable_cors=true", "--address=:9090"}) {
+		t.Errorf("Unexpected api flags %v", api.Flags)
+	}
+	if !reflect.DeepEqual(api.Env, []string{"MICRO_AUTH=service", "MICRO_ROUTER=service", "MICRO_API_NAMESPACE=foo"}) {
+		t.Errorf("Unexpected api env %v", api.Env)
+	}
+	if conf := byName["config"]; len(conf.Flags) > 0 || !reflect.DeepEqual(conf.Env, []string{"MICRO_SERVER_ADDRESS=:9091"}) {
+		t.Errorf("Expected the config address to be set in the env, got flags %v and env %v", conf.Flags, conf.Env)
+	}
+	if v := versions(byName["billing"], "latest"); !reflect.DeepEqual(v, []string{"latest", "latest-replica-1"}) {
+		t.Errorf("Unexpected billing replicas %v", v)
+	}
+
+	billing := byName["billing"]
+	if !reflect.DeepEqual(billing.Command, []string{"/usr/local/bin/billing", "--verbose"}) || registeredName(billing) != "foo.service.billing" || billing.Check != "" {
+		t.Errorf("Unexpected billing service %+v", billing)
+	}
+	if audit := byName["audit"]; audit.Check != checkNone {
+		t.Errorf("Expected services without a registered name not to be checked, got %v", audit.Check)
+	}
+
+	// the services passed aren't modified
+	for _, srv := range services {
+		if srv.Name == "api" && len(srv.Flags) > 0 {
+			t.Errorf("Expected the core services not to be modified")
+		}
+	}
+
+	// disabled services are removed from the deps of the core services
+	if c, err = load("services:\n  router:\n    disabled: true\n"); err != nil {
+		t.Fatal(err)
+	}
+	if run, err = c.apply(services); err != nil {
+		t.Fatalf("Unexpected error disabling a core service: %v", err)
+	}
+	for _, srv := range run {
+		for _, d := range srv.Deps {
+			if d == "router" {
+				t.Errorf("Expected %v not to depend on the disabled router", srv.Name)
+			}
+		}
+	}
+	for _, srv := range services {
+		if srv.Name == "api" && len(srv.Deps) != 3 {
+			t.Errorf("Expected the core services not to be modified")
+		}
+	}
+
+	// services can't be disabled while the config sets others to depend on them
+	if c, err = load("services:\n  store:\n    disabled: true\n  billing:\n    command: ./billing\n    deps: [store]\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.apply(services); err == nil {
+		t.Errorf("Expected an error disabling a service the config depends on")
+	}
+}
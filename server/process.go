@@ -0,0 +1,371 @@
+package server
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2"
+	goauth "github.com/micro/go-micro/v2/auth"
+	gobroker "github.com/micro/go-micro/v2/broker"
+	memBroker "github.com/micro/go-micro/v2/broker/memory"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/micro/go-micro/v2/config/cmd"
+	log "github.com/micro/go-micro/v2/logger"
+	goregistry "github.com/micro/go-micro/v2/registry"
+	memRegistry "github.com/micro/go-micro/v2/registry/memory"
+	gorouter "github.com/micro/go-micro/v2/router"
+	routerSrv "github.com/micro/go-micro/v2/router/service"
+	"github.com/micro/go-micro/v2/selector"
+	gosrv "github.com/micro/go-micro/v2/server"
+	gostore "github.com/micro/go-micro/v2/store"
+	memStore "github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/client/api"
+	"github.com/micro/micro/v2/client/bot"
+	"github.com/micro/micro/v2/client/proxy"
+	"github.com/micro/micro/v2/client/web"
+	"github.com/micro/micro/v2/internal/rollout"
+	"github.com/micro/micro/v2/service/auth"
+	"github.com/micro/micro/v2/service/broker"
+	configsrv "github.com/micro/micro/v2/service/config"
+	"github.com/micro/micro/v2/service/debug"
+	"github.com/micro/micro/v2/service/network"
+	"github.com/micro/micro/v2/service/registry"
+	"github.com/micro/micro/v2/service/router"
+	"github.com/micro/micro/v2/service/runtime"
+	"github.com/micro/micro/v2/service/store"
+)
+
+// inProcess are the core services which the server runs in its own process with --single_process.
+// Init isn't run since it restarts the processes of the core services when micro is updated.
+var inProcess = map[string]func(*cli.Context, ...micro.Option){
+	"registry": registry.Run,
+	"broker":   broker.Run,
+	"store":    store.Run,
+	"config":   configsrv.Run,
+	"auth":     auth.Run,
+	"network":  network.Run,
+	"router":   router.Run,
+	"debug":    debug.Run,
+	"runtime":  runtime.Run,
+	"proxy":    proxy.Run,
+	"api":      api.Run,
+	"web":      web.Run,
+	"bot": func(ctx *cli.Context, srvOpts ...micro.Option) {
+		if err := bot.Run(ctx, srvOpts...); err != nil {
+			log.Errorf("Error running the bot: %v", err)
+		}
+	},
+}
+
+// singleProcess checks the services can be run in a single process and returns those the server
+// runs. Services with a command are still created in the runtime. The core services share the env
+// of the server, so the env of a service is only applied to the flags it sets. Env vars which
+// aren't read from a flag can't be set, nor can they run more than one replica since the replicas
+// would listen on the same address.
+func singleProcess(app *cli.App, services []*coreService) ([]*coreService, error) {
+	var result []*coreService
+	for _, srv := range services {
+		if len(srv.Command) > 0 {
+			result = append(result, srv)
+			continue
+		}
+		if srv.Name == "init" {
+			continue
+		}
+		if _, ok := inProcess[srv.Name]; !ok {
+			return nil, fmt.Errorf("Service %v can't be run in a single process", srv.Name)
+		}
+		if srv.Replicas > 1 {
+			return nil, fmt.Errorf("Service %v can't run %v replicas in a single process", srv.Name, srv.Replicas)
+		}
+		command := app.Command(srv.Name)
+		if command == nil {
+			return nil, fmt.Errorf("Service %v isn't a micro command", srv.Name)
+		}
+		if _, _, err := envFlags(app, command, srv.Env); err != nil {
+			return nil, fmt.Errorf("Service %v can't set its env in a single process: %v", srv.Name, err)
+		}
+		result = append(result, srv)
+	}
+	return result, nil
+}
+
+// processes are the core services run in the server's process
+type processes struct {
+	// options shared by every service: the registry, broker and store
+	options []micro.Option
+
+	registry goregistry.Registry
+	broker   gobroker.Broker
+	selector selector.Selector
+
+	sync.Mutex
+	cancel map[string]context.CancelFunc
+	done   map[string]chan struct{}
+}
+
+// newProcesses replaces the registry, broker and store of the server with memory implementations
+// shared by the services it runs in its process. The services still listen on their own addresses
+// so they can be called like those run in separate processes.
+func newProcesses() *processes {
+	opts := cmd.DefaultCmd.Options()
+
+	reg := memRegistry.NewRegistry()
+	brk := memBroker.NewBroker()
+	storeOpts := (*opts.Store).Options()
+	st := memStore.NewStore(gostore.Database(storeOpts.Database), gostore.Table(storeOpts.Table))
+
+	// the services which use the defaults rather than the options of their service use the memory
+	// implementations too
+	rtr := gorouter.NewRouter(gorouter.Registry(reg))
+	sel := rollout.NewSelector(*opts.Selector, reg)
+	*opts.Registry, *opts.Broker, *opts.Store, *opts.Router, *opts.Selector = reg, brk, st, rtr, sel
+	(*opts.Client).Init(client.Registry(reg), client.Broker(brk), client.Router(rtr), client.Selector(sel))
+
+	return &processes{
+		options:  []micro.Option{micro.Registry(reg), micro.Broker(brk), micro.Store(st), micro.Router(rtr), micro.Selector(sel)},
+		registry: reg,
+		broker:   brk,
+		selector: sel,
+		cancel:   make(map[string]context.CancelFunc),
+		done:     make(map[string]chan struct{}),
+	}
+}
+
+// Create runs the service in a goroutine, it's stopped by Delete rather than the signals received
+// by the server so the services can be stopped in order
+func (p *processes) Create(ctx *cli.Context, srv *coreService) error {
+	c, err := serviceContext(ctx, srv)
+	if err != nil {
+		return err
+	}
+
+	run := inProcess[srv.Name]
+	srvCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	// each service needs its own server, the default is used by the server itself
+	opts := append([]micro.Option{micro.Server(newServer())}, p.options...)
+	srvOpts, err := p.serviceOptions(ctx, c)
+	if err != nil {
+		cancel()
+		return err
+	}
+	opts = append(opts, srvOpts...)
+	opts = append(opts, micro.HandleSignal(false), micro.Context(srvCtx))
+
+	p.Lock()
+	p.cancel[srv.Name] = cancel
+	p.done[srv.Name] = done
+	p.Unlock()
+
+	go func() {
+		defer close(done)
+		run(c, opts...)
+	}()
+	return nil
+}
+
+// Delete stops the service
+func (p *processes) Delete(srv *coreService) error {
+	p.Lock()
+	defer p.Unlock()
+	if cancel, ok := p.cancel[srv.Name]; ok {
+		cancel()
+	}
+	return nil
+}
+
+// Stopped returns true once the service has returned
+func (p *processes) Stopped(srv *coreService) bool {
+	p.Lock()
+	done, ok := p.done[srv.Name]
+	p.Unlock()
+	if !ok {
+		return true
+	}
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// serviceOptions returns the options for the address, auth and router of a service when they
+// differ from the server's, e.g. the api, proxy and web set MICRO_AUTH=service and
+// MICRO_ROUTER=service. A service with its own router is given its own client so the router of the
+// others isn't changed.
+func (p *processes) serviceOptions(ctx, c *cli.Context) ([]micro.Option, error) {
+	opts := cmd.DefaultCmd.Options()
+
+	var result []micro.Option
+	if addr := c.String("server_address"); len(addr) > 0 && addr != ctx.String("server_address") {
+		result = append(result, micro.Address(addr))
+	}
+	if name := c.String("auth"); len(name) > 0 && name != (*opts.Auth).String() {
+		newAuth, ok := opts.Auths[name]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported auth %v", name)
+		}
+		o := (*opts.Auth).Options()
+		result = append(result, micro.Auth(newAuth(
+			goauth.Credentials(o.ID, o.Secret),
+			goauth.PublicKey(o.PublicKey),
+			goauth.PrivateKey(o.PrivateKey),
+			goauth.Issuer(o.Issuer),
+			goauth.WithClient(*opts.Client),
+		)))
+	}
+	if name := c.String("router"); len(name) > 0 && name != (*opts.Router).String() {
+		newRouter, ok := opts.Routers[name]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported router %v", name)
+		}
+		newClient, ok := opts.Clients[(*opts.Client).String()]
+		if !ok {
+			newClient = client.NewClient
+		}
+		cl := newClient(
+			client.Registry(p.registry),
+			client.Broker(p.broker),
+			client.Transport(*opts.Transport),
+			client.Selector(p.selector),
+		)
+		rtr := newRouter(gorouter.Registry(p.registry), routerSrv.Client(cl))
+		result = append(result, micro.Client(cl), micro.Router(rtr))
+	}
+	return result, nil
+}
+
+// setEnv sets the env vars the server passes to its services in its own env, so the services run
+// in process read them from their flags. Env vars already set are left as is since they override
+// those passed by the server, as does the store which is in memory.
+func setEnv(env []string) {
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 || kv[0] == "MICRO_STORE" {
+			continue
+		}
+		if _, ok := os.LookupEnv(kv[0]); !ok {
+			os.Setenv(kv[0], kv[1])
+		}
+	}
+}
+
+// serviceContext returns the context the service would be run with by micro, with the flags of its
+// command and the global flags of the server. The env of the service is applied to the flags it
+// sets, its flags take precedence as they would in its own process.
+func serviceContext(ctx *cli.Context, srv *coreService) (*cli.Context, error) {
+	command := ctx.App.Command(srv.Name)
+	if command == nil {
+		return nil, fmt.Errorf("Service %v isn't a micro command", srv.Name)
+	}
+	cmdEnv, globalEnv, err := envFlags(ctx.App, command, srv.Env)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid env for %v: %v", srv.Name, err)
+	}
+
+	set := flag.NewFlagSet(srv.Name, flag.ContinueOnError)
+	for _, f := range command.Flags {
+		if err := f.Apply(set); err != nil {
+			return nil, err
+		}
+	}
+	for name, value := range cmdEnv {
+		if err := set.Set(name, value); err != nil {
+			return nil, fmt.Errorf("Invalid env for %v: %v", srv.Name, err)
+		}
+	}
+	if err := set.Parse(srv.Flags); err != nil {
+		return nil, fmt.Errorf("Invalid flags for %v: %v", srv.Name, err)
+	}
+
+	// the global flags are looked up in the lineage as they are for a command of micro, those set
+	// by the env of the service are looked up first
+	parent := ctx
+	if lineage := ctx.Lineage(); len(lineage) > 1 {
+		parent = lineage[1]
+	}
+	if len(globalEnv) > 0 {
+		global := flag.NewFlagSet("micro", flag.ContinueOnError)
+		for _, f := range ctx.App.Flags {
+			if _, ok := globalEnv[f.Names()[0]]; !ok {
+				continue
+			}
+			if err := f.Apply(global); err != nil {
+				return nil, err
+			}
+		}
+		for name, value := range globalEnv {
+			if err := global.Set(name, value); err != nil {
+				return nil, fmt.Errorf("Invalid env for %v: %v", srv.Name, err)
+			}
+		}
+		parent = cli.NewContext(ctx.App, global, parent)
+	}
+	return cli.NewContext(ctx.App, set, parent), nil
+}
+
+// envFlags returns the values of the flags of the command and the global flags which are set by
+// the env vars, an env var which doesn't set a flag is an error
+func envFlags(app *cli.App, command *cli.Command, env []string) (map[string]string, map[string]string, error) {
+	lookup := func(flags []cli.Flag, key string) string {
+		for _, f := range flags {
+			for _, e := range flagEnvVars(f) {
+				if e == key {
+					return f.Names()[0]
+				}
+			}
+		}
+		return ""
+	}
+
+	cmdEnv, globalEnv := make(map[string]string), make(map[string]string)
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, fmt.Errorf("%v isn't in the format key=value", e)
+		}
+		if name := lookup(command.Flags, kv[0]); len(name) > 0 {
+			cmdEnv[name] = kv[1]
+		} else if name := lookup(app.Flags, kv[0]); len(name) > 0 {
+			globalEnv[name] = kv[1]
+		} else {
+			return nil, nil, fmt.Errorf("%v isn't read from a flag", kv[0])
+		}
+	}
+	return cmdEnv, globalEnv, nil
+}
+
+// flagEnvVars returns the env vars a flag is read from
+func flagEnvVars(f cli.Flag) []string {
+	v := reflect.ValueOf(f)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if field := v.FieldByName("EnvVars"); field.IsValid() {
+		if env, ok := field.Interface().([]string); ok {
+			return env
+		}
+	}
+	return nil
+}
+
+// newServer returns a server of the type the server was configured with
+func newServer() gosrv.Server {
+	opts := cmd.DefaultCmd.Options()
+	if fn, ok := opts.Servers[(*opts.Server).String()]; ok {
+		return fn()
+	}
+	return gosrv.NewServer()
+}
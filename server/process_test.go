@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/micro/go-micro/v2/client/grpc"
+	"github.com/micro/go-micro/v2/config/cmd"
+	storeproto "github.com/micro/go-micro/v2/store/service/proto"
+	"github.com/micro/micro/v2/service/store"
+)
+
+// testContext returns the context of micro server with the commands passed, the other core
+// services are given a command without flags
+func testContext(t *testing.T, commands ...*cli.Command) *cli.Context {
+	app := *cmd.DefaultCmd.App()
+	app.Commands = commands
+	for _, srv := range services {
+		if app.Command(srv.Name) == nil {
+			app.Commands = append(app.Commands, &cli.Command{Name: srv.Name})
+		}
+	}
+
+	set := flag.NewFlagSet("micro", flag.ContinueOnError)
+	for _, f := range app.Flags {
+		if err := f.Apply(set); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cli.NewContext(&app, set, nil)
+}
+
+func TestSingleProcess(t *testing.T) {
+	ctx := testContext(t)
+	run, err := singleProcess(ctx.App, services)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(run) != len(services)-1 {
+		t.Fatalf("Expected every core service but init to be run, got %v services", len(run))
+	}
+	for _, srv := range run {
+		if srv.Name == "init" {
+			t.Fatalf("Expected init not to be run in a single process")
+		}
+	}
+	if _, err := startOrder(run); err != nil {
+		t.Fatalf("Unexpected error ordering the services: %v", err)
+	}
+
+	// custom services are still run by the runtime
+	custom := append(append([]*coreService{}, services...), &coreService{Name: "billing", Command: []string{"./billing"}, Replicas: 2})
+	if run, err := singleProcess(ctx.App, custom); err != nil || run[len(run)-1].Name != "billing" {
+		t.Fatalf("Expected the custom service to be run, got %v", err)
+	}
+
+	if _, err := singleProcess(ctx.App, []*coreService{{Name: "api", Env: []string{"FOO=bar"}}}); err == nil {
+		t.Errorf("Expected an error setting an env var which isn't read from a flag")
+	}
+	if _, err := singleProcess(ctx.App, []*coreService{{Name: "web", Replicas: 2}}); err == nil {
+		t.Errorf("Expected an error running replicas of a core service")
+	}
+	if _, err := singleProcess(ctx.App, []*coreService{{Name: "foo"}}); err == nil {
+		t.Errorf("Expected an error running an unknown core service")
+	}
+
+	// the env of the core services is applied to the flags of their context
+	for _, srv := range services {
+		if srv.Name != "api" {
+			continue
+		}
+		c, err := serviceContext(ctx, srv)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if v := c.String("auth"); v != "service" {
+			t.Errorf("Expected the api to use the auth service, got %q", v)
+		}
+		if v := c.String("router"); v != "service" {
+			t.Errorf("Expected the api to use the router service, got %q", v)
+		}
+	}
+	if v := ctx.String("auth"); v == "service" {
+		t.Errorf("Expected the env of the api not to be applied to the server")
+	}
+
+	// the flags of a service take precedence over its env
+	c, err := serviceContext(testContext(t, store.Commands()...), &coreService{
+		Name:  "store",
+		Env:   []string{"MICRO_SERVER_ADDRESS=:9000"},
+		Flags: []string{"--address=:9001"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if v := c.String("address"); v != ":9001" {
+		t.Errorf("Expected the flag to override the env, got %q", v)
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	ctx := testContext(t, store.Commands()...)
+	srv := &coreService{Name: "store"}
+
+	p := newProcesses()
+	if err := p.Create(ctx, srv); err != nil {
+		t.Fatalf("Unexpected error creating the store: %v", err)
+	}
+	defer func() {
+		p.Delete(srv)
+		for i := 0; i < 50 && !p.Stopped(srv); i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !p.Stopped(srv) {
+			t.Errorf("Expected the store to be stopped")
+		}
+	}()
+
+	// the store is called through the registry the services share, micro runs grpc servers
+	cl := grpc.NewClient(client.Registry(p.registry), client.Broker(p.broker), client.Selector(p.selector))
+	st := storeproto.NewStoreService("go.micro.store", cl)
+
+	var err error
+	for i := 0; i < 50; i++ {
+		if _, err = st.Databases(context.TODO(), &storeproto.DatabasesRequest{}); err == nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("Expected the store to be called, got %v", err)
+}
+
+func TestSetEnv(t *testing.T) {
+	defer os.Unsetenv("MICRO_TEST_SET")
+	defer os.Unsetenv("MICRO_TEST_KEEP")
+	os.Setenv("MICRO_TEST_KEEP", "user")
+	store, hasStore := os.LookupEnv("MICRO_STORE")
+
+	setEnv([]string{"MICRO_TEST_SET=server", "MICRO_TEST_KEEP=server", "MICRO_STORE=file"})
+	if v := os.Getenv("MICRO_TEST_SET"); v != "server" {
+		t.Errorf("Expected the env var to be set, got %q", v)
+	}
+	if v := os.Getenv("MICRO_TEST_KEEP"); v != "user" {
+		t.Errorf("Expected the env var set by the user to be kept, got %q", v)
+	}
+	if v, ok := os.LookupEnv("MICRO_STORE"); v != store || ok != hasStore {
+		t.Errorf("Expected the store not to be set, got %q", v)
+	}
+}
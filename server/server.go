@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/micro/cli/v2"
 	"github.com/micro/go-micro/v2"
@@ -13,26 +14,43 @@ import (
 	handler "github.com/micro/go-micro/v2/util/file"
 	"github.com/micro/micro/v2/internal/platform"
 	"github.com/micro/micro/v2/internal/update"
+	"github.com/micro/micro/v2/service/runtime"
+	"github.com/micro/micro/v2/service/runtime/upload"
 )
 
 var (
-	// list of services managed
-	services = []string{
-		// runtime services
-		"config",   // ????
-		"auth",     // :8010
-		"network",  // :8085
-		"runtime",  // :8088
-		"registry", // :8000
-		"broker",   // :8001
-		"store",    // :8002
-		"router",   // :8084
-		"debug",    // :????
-		"proxy",    // :8081
-		"api",      // :8080
-		"web",      // :8082
-		"bot",      // :????
-		"init",     // no port, manage self
+	// services run by the server, each is started once the services it depends on are healthy
+	services = []*coreService{
+		{Name: "registry", Address: ":8000"},
+		{Name: "broker", Address: ":8001"},
+		{Name: "store", Address: ":8002"},
+		{Name: "config", Deps: []string{"store"}, AddressEnv: true},
+		{Name: "auth", Deps: []string{"store"}, Address: ":8010"},
+		{Name: "network", Deps: []string{"registry"}, Address: ":8085"},
+		{Name: "router", Deps: []string{"registry"}, Address: ":8084"},
+		{Name: "debug", Deps: []string{"registry"}, Address: ":8089"},
+		{Name: "runtime", Deps: []string{"registry", "broker", "store", "config", "auth"}, Address: ":8088"},
+		{
+			Name:    "proxy",
+			Deps:    []string{"auth", "registry", "router"},
+			Env:     []string{"MICRO_AUTH=service", "MICRO_ROUTER=service"},
+			Check:   checkRegistered,
+			Address: ":8081",
+		},
+		{
+			Name:    "api",
+			Deps:    []string{"auth", "registry", "router"},
+			Env:     []string{"MICRO_AUTH=service", "MICRO_ROUTER=service"},
+			Address: ":8080",
+		},
+		{
+			Name:    "web",
+			Deps:    []string{"auth", "registry", "router"},
+			Env:     []string{"MICRO_AUTH=service", "MICRO_ROUTER=service"},
+			Address: ":8082",
+		},
+		{Name: "bot", Deps: []string{"registry"}, AddressEnv: true},
+		{Name: "init", Deps: []string{"config"}, Check: checkNone}, // no port, manage self
 	}
 )
 
@@ -49,26 +67,51 @@ func Commands(options ...micro.Option) []*cli.Command {
 		Usage: "Run the micro server",
 		Description: `Launching the micro server ('micro server') will enable one to connect to it by
 		setting the appropriate Micro environment (see 'micro env' && 'micro env --help') commands.`,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "address",
 				Usage:   "Set the micro server address :10001",
 				EnvVars: []string{"MICRO_SERVER_ADDRESS"},
 			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Set the yaml file which disables, adds and configures the services run by the server",
+			},
+			&cli.BoolFlag{
+				Name:    "single_process",
+				Aliases: []string{"single-process"},
+				Usage:   "Run the core services in the server's process, sharing a memory registry, broker and store",
+			},
 			&cli.BoolFlag{
 				Name:  "peer",
 				Usage: "Peer with the global network to share services",
 			},
-			&cli.StringFlag{
-				Name:    "profile",
-				Usage:   "Set the runtime profile to use for services e.g local, kubernetes, platform",
-				EnvVars: []string{"MICRO_RUNTIME_PROFILE"},
+			&cli.DurationFlag{
+				Name:  "startup_timeout",
+				Usage: "Set how long to wait for each service to become healthy before starting the services which depend on it",
+				Value: time.Minute,
 			},
-		},
+			&cli.DurationFlag{
+				Name:  "grace_period",
+				Usage: "Set how long to wait for the services to stop when shutting down",
+				Value: time.Second * 10,
+			},
+		}, runtime.ProfileFlags()...),
 		Action: func(ctx *cli.Context) error {
 			Run(ctx)
 			return nil
 		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "profiles",
+				Usage: "List the runtime profiles: micro server profiles [name]",
+				Flags: runtime.ProfileFlags(),
+				Action: func(ctx *cli.Context) error {
+					runtime.ListProfiles(ctx)
+					return nil
+				},
+			},
+		},
 	}
 
 	for _, p := range Plugins() {
@@ -106,6 +149,23 @@ func Run(context *cli.Context) error {
 	}
 
 	env = append(env, "MICRO_RUNTIME_PROFILE="+profile)
+
+	// the profile is resolved by the runtime, profiles in the config service can't be checked
+	// until it's running
+	if path := context.String("profile_file"); len(path) > 0 {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			log.Errorf("Failed to load profiles: %v", err)
+			return err
+		}
+		env = append(env, "MICRO_RUNTIME_PROFILE_FILE="+abs)
+	}
+	if reg, err := runtime.LoadProfiles(context, nil); err != nil {
+		log.Errorf("Failed to load profiles: %v", err)
+		return err
+	} else if _, err := reg.Get(profile); err != nil {
+		log.Warnf("%v, the runtime will look for it in the config service", err)
+	}
 	env = append(env, os.Environ()...)
 
 	// connect to the network if specified
@@ -142,76 +202,170 @@ func Run(context *cli.Context) error {
 		(*muRuntime).Init(options...)
 	}
 
-	for _, service := range services {
-		name := service
+	// the config is checked before anything is started
+	run := services
+	var conf *config
+	if path := context.String("config"); len(path) > 0 {
+		var err error
+		if conf, err = loadConfig(path); err != nil {
+			log.Errorf("Failed to load config: %v", err)
+			return err
+		}
+		if run, err = conf.apply(services); err != nil {
+			log.Errorf("Failed to load config %v: %v", path, err)
+			return err
+		}
+	}
 
+	// the core services run in the server's process, sharing its registry, broker and store
+	var procs *processes
+	if context.Bool("single_process") {
+		var err error
+		if run, err = singleProcess(context.App, run); err != nil {
+			log.Errorf("Failed to run a single process: %v", err)
+			return err
+		}
+		procs = newProcesses()
+		setEnv(env)
+	}
+
+	// the services are started in the order of their dependencies
+	levels, err := startOrder(run)
+	if err != nil {
+		log.Errorf("Failed to order services: %v", err)
+		return err
+	}
+
+	log.Info("Starting service runtime")
+
+	// start the runtime, the services are started as they're created
+	if err := (*muRuntime).Start(); err != nil {
+		log.Fatal(err)
+		return err
+	}
+
+	log.Info("Service runtime started")
+
+	// the name of the service in the runtime
+	runtimeName := func(srv *coreService) string {
 		if namespace := context.String("namespace"); len(namespace) > 0 {
-			name = fmt.Sprintf("%s.%s", namespace, service)
+			return fmt.Sprintf("%s.%s", namespace, srv.Name)
+		}
+		return srv.Name
+	}
+
+	create := func(srv *coreService) error {
+		if procs != nil && len(srv.Command) == 0 {
+			log.Infof("Running %s in process", srv.Name)
+			return procs.Create(context, srv)
 		}
 
+		name := runtimeName(srv)
 		log.Infof("Registering %s", name)
-		// @todo this is a hack
-		envs := env
-		switch service {
-		case "proxy", "web", "api":
-			envs = append(envs, "MICRO_AUTH=service")
-			envs = append(envs, "MICRO_ROUTER=service")
-		}
-
-		cmdArgs := []string{}
-		// we want to pass through the global args so go up one level in the context lineage
-		if len(context.Lineage()) > 1 {
-			globCtx := context.Lineage()[1]
-			for _, f := range globCtx.FlagNames() {
-				cmdArgs = append(cmdArgs, "--"+f, context.String(f))
+
+		command, cmdArgs := os.Args[0], []string{}
+		if len(srv.Command) > 0 {
+			command, cmdArgs = srv.Command[0], append(cmdArgs, srv.Command[1:]...)
+		} else {
+			// we want to pass through the global args so go up one level in the context lineage
+			if len(context.Lineage()) > 1 {
+				globCtx := context.Lineage()[1]
+				for _, f := range globCtx.FlagNames() {
+					cmdArgs = append(cmdArgs, "--"+f, globCtx.String(f))
+				}
 			}
+			cmdArgs = append(cmdArgs, srv.Name)
 		}
-		cmdArgs = append(cmdArgs, service)
+		cmdArgs = append(cmdArgs, srv.Flags...)
+
+		image := srv.Image
+		if len(image) == 0 {
+			image = "micro/micro"
+		}
+
+		// the services of a level are created concurrently so the env is copied
+		envs := append(append([]string{}, env...), srv.Env...)
 
 		// runtime based on environment we run the service in
 		args := []gorun.CreateOption{
-			gorun.WithCommand(os.Args[0]),
+			gorun.WithCommand(command),
 			gorun.WithArgs(cmdArgs...),
 			gorun.WithEnv(envs),
 			gorun.WithOutput(os.Stdout),
 			gorun.WithRetries(10),
-			gorun.CreateImage("micro/micro"),
+			gorun.CreateImage(image),
 		}
 
 		// NOTE: we use Version right now to check for the latest release
-		muService := &gorun.Service{Name: name, Version: platform.Version}
-		if err := (*muRuntime).Create(muService, args...); err != nil {
-			log.Errorf("Failed to create runtime environment: %v", err)
-			return err
+		for _, version := range versions(srv, platform.Version) {
+			muService := &gorun.Service{Name: name, Version: version}
+			if err := (*muRuntime).Create(muService, args...); err != nil {
+				log.Errorf("Failed to create runtime environment: %v", err)
+				return err
+			}
 		}
+		return nil
 	}
 
-	log.Info("Starting service runtime")
-
-	// start the runtime
-	if err := (*muRuntime).Start(); err != nil {
-		log.Fatal(err)
-		return err
+	// the health of the services is checked with the default client and registry
+	c, reg := *cmd.DefaultCmd.Options().Client, *cmd.DefaultCmd.Options().Registry
+	check := func(srv *coreService) error {
+		return checkService(c, reg, srv)
 	}
 
-	log.Info("Service runtime started")
+	results := startServices(levels, create, check, context.Duration("startup_timeout"))
+	printReadiness(os.Stdout, results)
 
 	// TODO: should we launch the console?
 	// start the console
 	// cli.Init(context)
 
-	server := micro.NewService(
+	srvOpts := []micro.Option{
 		micro.Name(Name),
 		micro.Address(Address),
-	)
+	}
+	if procs != nil {
+		srvOpts = append(append([]micro.Option{}, procs.options...), srvOpts...)
+	}
+	server := micro.NewService(srvOpts...)
 
 	// @todo make this configurable
-	uploadDir := filepath.Join(os.TempDir(), "micro", "uploads")
+	uploadDir := upload.DefaultDir
 	os.MkdirAll(uploadDir, 0777)
 	handler.RegisterHandler(server.Server(), uploadDir)
+	// sources uploaded by micro run are sent in chunks and reassembled in the same directory
+	if err := upload.RegisterHandler(server.Server(), uploadDir); err != nil {
+		log.Errorf("Failed to register the upload handler: %v", err)
+		return err
+	}
 	// start the server
 	server.Run()
 
+	log.Info("Stopping services")
+
+	// stop the services in the reverse order they were started, giving each level the chance to
+	// shutdown before the services it depends on are stopped
+	del := func(srv *coreService) error {
+		if procs != nil && len(srv.Command) == 0 {
+			return procs.Delete(srv)
+		}
+		for _, version := range versions(srv, platform.Version) {
+			if err := (*muRuntime).Delete(&gorun.Service{Name: runtimeName(srv), Version: version}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	stopped := func(srv *coreService) bool {
+		if procs != nil && len(srv.Command) == 0 {
+			return procs.Stopped(srv)
+		}
+		return serviceStopped(reg, srv)
+	}
+	for _, err := range stopServices(levels, del, stopped, context.Duration("grace_period")) {
+		log.Warn(err)
+	}
+
 	log.Info("Stopping service runtime")
 
 	// stop all the things
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micro/go-micro/v2/client"
+	debug "github.com/micro/go-micro/v2/debug/service/proto"
+	"github.com/micro/go-micro/v2/registry"
+)
+
+const (
+	// checkHealth calls Debug.Health on the service
+	checkHealth = "health"
+	// checkRegistered waits for the service to register, for services which proxy every request
+	checkRegistered = "registered"
+	// checkNone considers the service healthy once it's created, for services which don't register
+	checkNone = "none"
+)
+
+var (
+	// checkInterval is how often the health of a service is checked while waiting for it
+	checkInterval = time.Millisecond * 500
+	// checkTimeout is the timeout of each call to Debug.Health
+	checkTimeout = time.Second * 2
+)
+
+// coreService is a service run by the micro server
+type coreService struct {
+	// Name of the service, e.g. auth
+	Name string
+	// Service is the name the service registers with, go.micro.<name> by default
+	Service string
+	// Command to run the service with, by default the micro binary is run with the name of the
+	// service as its command
+	Command []string
+	// Image of the service for runtimes which run images, micro/micro by default
+	Image string
+	// Flags passed to the service, after its command
+	Flags []string
+	// Deps are the services which must be healthy before the service is started
+	Deps []string
+	// Env vars set in addition to the environment of the server
+	Env []string
+	// Check is how the service is determined to be healthy, health by default
+	Check string
+	// Replicas of the service to run, one by default
+	Replicas int
+	// AddressEnv is true if the address of the service is set in MICRO_SERVER_ADDRESS rather
+	// than the --address flag
+	AddressEnv bool
+	// Address the service listens on by default if it's fixed, it can't run replicas
+	Address string
+}
+
+// readiness of a service once the server started it
+type readiness struct {
+	Service *coreService
+	// Took is how long the service took to become healthy once it was created
+	Took time.Duration
+	// Error if the service couldn't be created or didn't become healthy
+	Error error
+}
+
+// startOrder sorts the services into the levels they're started in, each level only depends on the
+// services in the levels before it. Services keep their declared order within a level.
+func startOrder(services []*coreService) ([][]*coreService, error) {
+	declared := make(map[string]bool, len(services))
+	for _, srv := range services {
+		declared[srv.Name] = true
+	}
+	for _, srv := range services {
+		for _, d := range srv.Deps {
+			if !declared[d] {
+				return nil, fmt.Errorf("Service %v depends on %v which isn't run by the server", srv.Name, d)
+			}
+		}
+	}
+
+	var levels [][]*coreService
+	started := make(map[string]bool, len(services))
+	for len(started) < len(services) {
+		var level []*coreService
+		for _, srv := range services {
+			if started[srv.Name] {
+				continue
+			}
+			ready := true
+			for _, d := range srv.Deps {
+				ready = ready && started[d]
+			}
+			if ready {
+				level = append(level, srv)
+			}
+		}
+		if len(level) == 0 {
+			var waiting []string
+			for _, srv := range services {
+				if !started[srv.Name] {
+					waiting = append(waiting, srv.Name)
+				}
+			}
+			return nil, fmt.Errorf("Services %v have circular dependencies", waiting)
+		}
+		for _, srv := range level {
+			started[srv.Name] = true
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// startServices creates the services level by level, waiting for each level to become healthy
+// before the next is started. A level which doesn't become healthy within the timeout is logged in
+// the readiness, the services which depend on it are still started so the server degrades rather
+// than failing outright.
+func startServices(levels [][]*coreService, create func(*coreService) error, check func(*coreService) error, timeout time.Duration) []*readiness {
+	var result []*readiness
+	for _, level := range levels {
+		results := make([]*readiness, len(level))
+		var wg sync.WaitGroup
+		for i, srv := range level {
+			wg.Add(1)
+			go func(i int, srv *coreService) {
+				defer wg.Done()
+				results[i] = startService(srv, create, check, timeout)
+			}(i, srv)
+		}
+		wg.Wait()
+		result = append(result, results...)
+	}
+	return result
+}
+
+// startService creates the service and waits for it to become healthy
+func startService(srv *coreService, create func(*coreService) error, check func(*coreService) error, timeout time.Duration) *readiness {
+	r := &readiness{Service: srv}
+	start := time.Now()
+	if r.Error = create(srv); r.Error != nil {
+		return r
+	}
+
+	deadline := start.Add(timeout)
+	for {
+		err := check(srv)
+		if err == nil {
+			r.Took = time.Since(start)
+			return r
+		}
+		if time.Now().After(deadline) {
+			r.Error = fmt.Errorf("not healthy after %v: %v", timeout, err)
+			return r
+		}
+		time.Sleep(checkInterval)
+	}
+}
+
+// stopServices deletes the services in the reverse order they were started, waiting for each level
+// to stop before the next. The grace period is shared by all the levels, once it passes the
+// remaining services are deleted without waiting.
+func stopServices(levels [][]*coreService, del func(*coreService) error, stopped func(*coreService) bool, grace time.Duration) []error {
+	var errs []error
+	deadline := time.Now().Add(grace)
+	for i := len(levels) - 1; i >= 0; i-- {
+		for _, srv := range levels[i] {
+			if err := del(srv); err != nil {
+				errs = append(errs, fmt.Errorf("Error stopping %v: %v", srv.Name, err))
+			}
+		}
+		for _, srv := range levels[i] {
+			for !stopped(srv) && time.Now().Before(deadline) {
+				time.Sleep(checkInterval)
+			}
+		}
+	}
+	return errs
+}
+
+// versions of the service's replicas in the runtime, the first replica runs the version and the
+// others are suffixed with the index of the replica like those created by the runtime manager
+func versions(srv *coreService, version string) []string {
+	v := []string{version}
+	for i := 1; i < srv.Replicas; i++ {
+		v = append(v, fmt.Sprintf("%v-replica-%v", version, i))
+	}
+	return v
+}
+
+// registeredName is the name the core service registers with
+func registeredName(srv *coreService) string {
+	if len(srv.Service) > 0 {
+		return srv.Service
+	}
+	return "go.micro." + srv.Name
+}
+
+// checkService returns an error if the service isn't healthy
+func checkService(c client.Client, r registry.Registry, srv *coreService) error {
+	if srv.Check == checkNone {
+		return nil
+	}
+
+	name := registeredName(srv)
+	recs, err := r.GetService(name)
+	if err != nil {
+		return err
+	}
+	var nodes []*registry.Node
+	for _, rec := range recs {
+		nodes = append(nodes, rec.Nodes...)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("not registered")
+	}
+	if srv.Check == checkRegistered {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	rsp := &debug.HealthResponse{}
+	if err := c.Call(ctx, c.NewRequest(name, "Debug.Health", &debug.HealthRequest{}), rsp); err != nil {
+		return err
+	}
+	if rsp.Status != "ok" {
+		return fmt.Errorf("status is %v", rsp.Status)
+	}
+	return nil
+}
+
+// serviceStopped returns true once the service has deregistered
+func serviceStopped(r registry.Registry, srv *coreService) bool {
+	if srv.Check == checkNone {
+		return true
+	}
+	recs, err := r.GetService(registeredName(srv))
+	if err == registry.ErrNotFound {
+		return true
+	}
+	for _, rec := range recs {
+		if len(rec.Nodes) > 0 {
+			return false
+		}
+	}
+	return err == nil
+}
+
+// printReadiness writes the summary of the services started by the server
+func printReadiness(w io.Writer, results []*readiness) {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "SERVICE\tSTATUS\tREADY IN\tERROR")
+
+	var ready int
+	for _, r := range results {
+		if r.Error != nil {
+			fmt.Fprintf(writer, "%v\tnot ready\tn/a\t%v\n", r.Service.Name, r.Error)
+			continue
+		}
+		ready++
+		fmt.Fprintf(writer, "%v\tready\t%v\tn/a\n", r.Service.Name, r.Took.Truncate(time.Millisecond))
+	}
+	writer.Flush()
+	fmt.Fprintf(w, "%v of %v services ready\n", ready, len(results))
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartOrder(t *testing.T) {
+	levels, err := startOrder(services)
+	if err != nil {
+		t.Fatalf("Unexpected error ordering the services: %v", err)
+	}
+	level := make(map[string]int)
+	for i, l := range levels {
+		for _, srv := range l {
+			level[srv.Name] = i
+		}
+	}
+	if len(level) != len(services) {
+		t.Fatalf("Expected all %v services to be started, got %v", len(services), len(level))
+	}
+	for _, srv := range services {
+		for _, d := range srv.Deps {
+			if level[d] >= level[srv.Name] {
+				t.Errorf("Expected %v to be started before %v", d, srv.Name)
+			}
+		}
+	}
+
+	if _, err := startOrder([]*coreService{{Name: "api", Deps: []string{"auth"}}}); err == nil {
+		t.Errorf("Expected an error for an unknown dependency")
+	}
+	cycle := []*coreService{
+		{Name: "registry"},
+		{Name: "auth", Deps: []string{"registry", "store"}},
+		{Name: "store", Deps: []string{"auth"}},
+	}
+	if _, err := startOrder(cycle); err == nil || !strings.Contains(err.Error(), "auth store") {
+		t.Errorf("Expected an error listing the circular dependencies, got %v", err)
+	}
+}
+
+func TestStartStopServices(t *testing.T) {
+	defer func(i time.Duration) { checkInterval = i }(checkInterval)
+	checkInterval = time.Millisecond
+
+	levels, err := startOrder([]*coreService{
+		{Name: "registry"},
+		{Name: "auth", Deps: []string{"registry"}},
+		{Name: "bot", Deps: []string{"registry"}},
+		{Name: "api", Deps: []string{"auth"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mtx sync.Mutex
+	healthy := make(map[string]bool)
+
+	// the services which depend on others are only created once they're healthy, the bot never
+	// becomes healthy and auth becomes healthy on the third check
+	create := func(srv *coreService) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		for _, d := range srv.Deps {
+			if !healthy[d] {
+				t.Errorf("Expected %v to be healthy before %v was started", d, srv.Name)
+			}
+		}
+		return nil
+	}
+	var checks int
+	check := func(srv *coreService) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		switch srv.Name {
+		case "bot":
+			return fmt.Errorf("not registered")
+		case "auth":
+			if checks++; checks < 3 {
+				return fmt.Errorf("not registered")
+			}
+		}
+		healthy[srv.Name] = true
+		return nil
+	}
+
+	results := startServices(levels, create, check, time.Millisecond*50)
+	if len(results) != 4 {
+		t.Fatalf("Expected the readiness of 4 services, got %v", len(results))
+	}
+	for _, r := range results {
+		if (r.Error != nil) != (r.Service.Name == "bot") {
+			t.Errorf("Unexpected readiness of %v: %v", r.Service.Name, r.Error)
+		}
+	}
+
+	var buf bytes.Buffer
+	printReadiness(&buf, results)
+	if !strings.Contains(buf.String(), "3 of 4 services ready") {
+		t.Errorf("Unexpected readiness summary:\n%v", buf.String())
+	}
+
+	// the services are stopped in reverse, waiting for each level to stop
+	var events []string
+	stopped := make(map[string]bool)
+	del := func(srv *coreService) error {
+		for _, l := range levels {
+			for _, s := range l {
+				for _, d := range s.Deps {
+					if d == srv.Name && !stopped[s.Name] {
+						t.Errorf("Expected %v to be stopped before %v", s.Name, srv.Name)
+					}
+				}
+			}
+		}
+		stopped[srv.Name] = true
+		events = append(events, "delete "+srv.Name)
+		return nil
+	}
+	if errs := stopServices(levels, del, func(srv *coreService) bool { return stopped[srv.Name] }, time.Second); len(errs) > 0 {
+		t.Fatalf("Unexpected errors stopping the services: %v", errs)
+	}
+	if events[0] != "delete api" || events[len(events)-1] != "delete registry" {
+		t.Errorf("Expected the services to be stopped in reverse order, got %v", events)
+	}
+}
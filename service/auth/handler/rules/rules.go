@@ -11,6 +11,7 @@ import (
 	"github.com/micro/go-micro/v2/errors"
 	"github.com/micro/go-micro/v2/store"
 	memStore "github.com/micro/go-micro/v2/store/memory"
+	inauth "github.com/micro/micro/v2/internal/auth"
 	"github.com/micro/micro/v2/internal/namespace"
 )
 
@@ -96,10 +97,45 @@ func (r *Rules) setupDefaultRules(ns string) {
 		r.Create(ctx, req, &pb.CreateResponse{})
 	}
 
+	// the runtime rules are added to namespaces which don't have them, including those created
+	// before they were, since without them anyone could exec in the namespace's services or collect
+	// the runtime's garbage
+	ids := make(map[string]bool, len(recs))
+	for _, rec := range recs {
+		ids[strings.TrimPrefix(rec.Key, key)] = true
+	}
+	for _, rule := range inauth.RuntimeRules {
+		if ids[rule.ID] {
+			continue
+		}
+		if err := r.Create(ctx, &pb.CreateRequest{Rule: serializeRule(rule)}, &pb.CreateResponse{}); err != nil {
+			return
+		}
+	}
+
 	// set the namespace in the cache
 	r.namespaces[ns] = true
 }
 
+// serializeRule converts a rule to its proto
+func serializeRule(r *auth.Rule) *pb.Rule {
+	access := pb.Access_GRANTED
+	if r.Access == auth.AccessDenied {
+		access = pb.Access_DENIED
+	}
+	return &pb.Rule{
+		Id:       r.ID,
+		Scope:    r.Scope,
+		Access:   access,
+		Priority: int32(r.Priority),
+		Resource: &pb.Resource{
+			Type:     r.Resource.Type,
+			Name:     r.Resource.Name,
+			Endpoint: r.Resource.Endpoint,
+		},
+	}
+}
+
 // Create a rule giving a scope access to a resource
 func (r *Rules) Create(ctx context.Context, req *pb.CreateRequest, rsp *pb.CreateResponse) error {
 	// Validate the request
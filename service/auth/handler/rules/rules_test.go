@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/micro/go-micro/v2/auth"
+	authrules "github.com/micro/go-micro/v2/auth/rules"
+	pb "github.com/micro/go-micro/v2/auth/service/proto"
+	"github.com/micro/go-micro/v2/store"
+	memStore "github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/internal/namespace"
+)
+
+func TestRuntimeRules(t *testing.T) {
+	s := memStore.NewStore()
+	r := &Rules{}
+	r.Init(auth.Store(s))
+
+	// a namespace setup before the runtime rules were added only has the default rule
+	b, _ := json.Marshal(&pb.Rule{
+		Id:       defaultRule.ID,
+		Scope:    defaultRule.Scope,
+		Access:   pb.Access_GRANTED,
+		Resource: &pb.Resource{Type: "*", Name: "*", Endpoint: "*"},
+	})
+	if err := s.Write(&store.Record{Key: "rules/foo/default", Value: b}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ns := range []string{"foo", "bar"} {
+		var rsp pb.ListResponse
+		ctx := namespace.ContextWithNamespace(context.TODO(), ns)
+		if err := r.List(ctx, &pb.ListRequest{}, &rsp); err != nil {
+			t.Fatal(err)
+		}
+
+		rules := make([]*auth.Rule, 0, len(rsp.Rules))
+		for _, rule := range rsp.Rules {
+			access := auth.AccessGranted
+			if rule.Access == pb.Access_DENIED {
+				access = auth.AccessDenied
+			}
+			rules = append(rules, &auth.Rule{
+				ID:       rule.Id,
+				Scope:    rule.Scope,
+				Access:   access,
+				Priority: rule.Priority,
+				Resource: &auth.Resource{
+					Type:     rule.Resource.Type,
+					Name:     rule.Resource.Name,
+					Endpoint: rule.Resource.Endpoint,
+				},
+			})
+		}
+
+		tests := []struct {
+			name     string
+			account  *auth.Account
+			endpoint string
+			err      error
+		}{
+			{"anonymous exec", nil, "Manager.Exec", auth.ErrForbidden},
+			{"account exec", &auth.Account{ID: "user"}, "Manager.Exec", auth.ErrForbidden},
+			{"admin exec", &auth.Account{ID: "admin", Scopes: []string{"admin"}}, "Manager.Exec", nil},
+			{"anonymous gc", nil, "Manager.GC", auth.ErrForbidden},
+			{"account gc", &auth.Account{ID: "user"}, "Manager.GC", auth.ErrForbidden},
+			{"admin gc", &auth.Account{ID: "admin", Scopes: []string{"admin"}}, "Manager.GC", nil},
+			{"anonymous read", nil, "Manager.Read", nil},
+		}
+		for _, tt := range tests {
+			res := &auth.Resource{Type: "service", Name: "go.micro.runtime", Endpoint: tt.endpoint}
+			if err := authrules.Verify(rules, tt.account, res); err != tt.err {
+				t.Errorf("%s in %s: expected %v, got %v", tt.name, ns, tt.err, err)
+			}
+		}
+	}
+}
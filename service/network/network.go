@@ -104,11 +104,11 @@ func Run(ctx *cli.Context, srvOpts ...micro.Option) {
 	}
 
 	// Initialise service
-	service := micro.NewService(
+	service := micro.NewService(append(srvOpts,
 		micro.Name(Name),
 		micro.RegisterTTL(time.Duration(ctx.Int("register_ttl"))*time.Second),
 		micro.RegisterInterval(time.Duration(ctx.Int("register_interval"))*time.Second),
-	)
+	)...)
 
 	// create a tunnel
 	tunOpts := []tunnel.Option{
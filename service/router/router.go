@@ -187,12 +187,12 @@ func Run(ctx *cli.Context, srvOpts ...micro.Option) {
 	}
 
 	// Initialise service
-	service := micro.NewService(
+	service := micro.NewService(append(srvOpts,
 		micro.Name(Name),
 		micro.Address(Address),
 		micro.RegisterTTL(time.Duration(ctx.Int("register_ttl"))*time.Second),
 		micro.RegisterInterval(time.Duration(ctx.Int("register_interval"))*time.Second),
-	)
+	)...)
 
 	r := router.NewRouter(
 		router.Id(service.Server().Options().Id),
@@ -0,0 +1,340 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/runtime/local/git"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ApplyUsage message for the apply command
+	ApplyUsage = "Apply a manifest: micro apply -f micro.yaml"
+	// specKey is the metadata key used to store the checksum of the manifest spec a service was
+	// created with. Services without it weren't created by apply and are never removed by it.
+	specKey = "spec"
+)
+
+// manifest declares the services which should be running
+type manifest struct {
+	Services []*manifestService `yaml:"services"`
+}
+
+// manifestService is a service declared in a manifest
+type manifestService struct {
+	// Source of the service, e.g. github.com/micro/services/helloworld or ./helloworld
+	Source string `yaml:"source"`
+	// Version of the service, the git ref to run
+	Version string `yaml:"version"`
+	// Type of service
+	Type string `yaml:"type"`
+	// Image to use for the container
+	Image string `yaml:"image"`
+	// Command to exec
+	Command string `yaml:"command"`
+	// Args for the command
+	Args string `yaml:"args"`
+	// Env vars to set
+	Env map[string]string `yaml:"env"`
+	// Retries when starting the service
+	Retries *int `yaml:"retries"`
+}
+
+// loadManifest reads and validates the manifest at the path provided
+func loadManifest(path string) (*manifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m *manifest
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return nil, fmt.Errorf("Error parsing manifest %v: %v", path, err)
+	}
+	if m == nil || len(m.Services) == 0 {
+		return nil, fmt.Errorf("Manifest %v does not contain any services", path)
+	}
+
+	for i, srv := range m.Services {
+		if len(srv.Source) == 0 {
+			return nil, fmt.Errorf("Service %v in manifest %v is missing a source", i, path)
+		}
+		if len(srv.Version) > 0 && strings.Contains(srv.Source, "@") {
+			return nil, fmt.Errorf("Service %v in manifest %v sets a version in both the source and version", srv.Source, path)
+		}
+		if srv.Retries != nil && *srv.Retries < 0 {
+			return nil, fmt.Errorf("Service %v in manifest %v has negative retries", srv.Source, path)
+		}
+	}
+
+	return m, nil
+}
+
+// sourceArg returns the source in the format accepted by micro run, e.g. helloworld@v1
+func (s *manifestService) sourceArg() string {
+	if len(s.Version) == 0 {
+		return s.Source
+	}
+	return s.Source + "@" + s.Version
+}
+
+// env returns the env vars in the format accepted by the runtime, sorted so they're stable
+func (s *manifestService) env() []string {
+	var env []string
+	for k, v := range s.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// retries returns the number of retries, falling back to the default
+func (s *manifestService) retries() int {
+	if s.Retries == nil {
+		return DefaultRetries
+	}
+	return *s.Retries
+}
+
+// checksum of everything in the spec which can't be changed with an update, the source is
+// compared separately
+func (s *manifestService) checksum() string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"type":    s.Type,
+		"image":   s.Image,
+		"command": s.Command,
+		"args":    s.Args,
+		"env":     s.env(),
+		"retries": s.retries(),
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// createOptions returns the options the service should be created with
+func (s *manifestService) createOptions(source *git.Source) []runtime.CreateOption {
+	image := s.Image
+	if len(image) == 0 {
+		formattedName := strings.ReplaceAll(source.Folder, "/", "-")
+		image = fmt.Sprintf("%v/%v", Image, formattedName)
+	}
+
+	opts := []runtime.CreateOption{
+		runtime.WithRetries(s.retries()),
+		runtime.CreateImage(image),
+		runtime.CreateType(s.Type),
+	}
+	if env := s.env(); len(env) > 0 {
+		opts = append(opts, runtime.WithEnv(env))
+	}
+	if command := strings.TrimSpace(s.Command); len(command) > 0 {
+		opts = append(opts, runtime.WithCommand(strings.Split(command, " ")...))
+	}
+	if args := strings.TrimSpace(s.Args); len(args) > 0 {
+		opts = append(opts, runtime.WithArgs(strings.Split(args, " ")...))
+	}
+	return opts
+}
+
+// action to take when applying a manifest
+type action string
+
+const (
+	// actionCreate creates a service which isn't running
+	actionCreate action = "create"
+	// actionUpdate updates the source of a running service
+	actionUpdate action = "update"
+	// actionRecreate deletes and creates a service whose spec has changed
+	actionRecreate action = "recreate"
+	// actionDelete deletes a service which was applied but is no longer in the manifest
+	actionDelete action = "delete"
+	// actionNone leaves a service unchanged
+	actionNone action = "unchanged"
+)
+
+// change is a single step of a plan
+type change struct {
+	// Action to take
+	Action action
+	// Service is the service to create, update or delete
+	Service *runtime.Service
+	// Spec the service was declared with, nil for deletes
+	Spec *manifestService
+	// Source parsed from the spec, nil for deletes
+	Source *git.Source
+}
+
+// plan diffs the desired services against the current ones and returns the changes needed to
+// converge them. Local sources are always updated since the code could have changed.
+func plan(desired []*change, current []*runtime.Service) []*change {
+	running := make(map[string]*runtime.Service, len(current))
+	for _, srv := range current {
+		running[srv.Name+":"+srv.Version] = srv
+	}
+
+	var changes []*change
+	declared := make(map[string]bool, len(desired))
+	for _, c := range desired {
+		key := c.Service.Name + ":" + c.Service.Version
+		declared[key] = true
+
+		curr, ok := running[key]
+		switch {
+		case !ok:
+			c.Action = actionCreate
+		case curr.Metadata[specKey] != c.Service.Metadata[specKey]:
+			c.Action = actionRecreate
+		case curr.Source != c.Service.Source || c.Source.Local:
+			c.Action = actionUpdate
+		default:
+			c.Action = actionNone
+		}
+		changes = append(changes, c)
+	}
+
+	// only delete services which were created by apply
+	for _, srv := range current {
+		if declared[srv.Name+":"+srv.Version] || len(srv.Metadata[specKey]) == 0 {
+			continue
+		}
+		changes = append(changes, &change{Action: actionDelete, Service: srv})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Service.Name < changes[j].Service.Name
+	})
+	return changes
+}
+
+// desiredServices parses the sources in the manifest into the services which should be running.
+// Local sources are only uploaded if upload is true.
+func desiredServices(ctx *cli.Context, m *manifest, upload bool) ([]*change, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var desired []*change
+	seen := make(map[string]bool, len(m.Services))
+	for _, spec := range m.Services {
+		var source *git.Source
+		var runtimeSource string
+
+		if upload {
+			source, runtimeSource, err = resolveSource(ctx, spec.sourceArg())
+		} else {
+			source, err = git.ParseSourceLocal(wd, spec.sourceArg())
+			if err == nil {
+				runtimeSource = source.RuntimeSource()
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing source %v: %v", spec.Source, err)
+		}
+
+		srv := &runtime.Service{
+			Name:     source.RuntimeName(),
+			Source:   runtimeSource,
+			Version:  source.Ref,
+			Metadata: map[string]string{specKey: spec.checksum()},
+		}
+		if len(srv.Version) == 0 {
+			srv.Version = "latest"
+		}
+
+		key := srv.Name + ":" + srv.Version
+		if seen[key] {
+			return nil, fmt.Errorf("Service %v is declared more than once", key)
+		}
+		seen[key] = true
+
+		desired = append(desired, &change{Service: srv, Spec: spec, Source: source})
+	}
+
+	return desired, nil
+}
+
+// printPlan writes the changes in a table
+func printPlan(changes []*change) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "ACTION\tNAME\tVERSION\tSOURCE")
+	for _, c := range changes {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", c.Action, c.Service.Name, c.Service.Version, c.Service.Source)
+	}
+	writer.Flush()
+}
+
+// applyChange issues the runtime calls needed for the change
+func applyChange(r runtime.Runtime, c *change) error {
+	switch c.Action {
+	case actionCreate:
+		return r.Create(c.Service, c.Spec.createOptions(c.Source)...)
+	case actionUpdate:
+		return r.Update(c.Service)
+	case actionRecreate:
+		if err := r.Delete(c.Service); err != nil {
+			return err
+		}
+		return r.Create(c.Service, c.Spec.createOptions(c.Source)...)
+	case actionDelete:
+		return r.Delete(c.Service)
+	}
+	return nil
+}
+
+func applyManifest(ctx *cli.Context, srvOpts ...micro.Option) {
+	path := ctx.String("file")
+	if len(path) == 0 {
+		fmt.Println(ApplyUsage)
+		return
+	}
+
+	m, err := loadManifest(path)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// don't upload anything when only printing the plan
+	dryRun := ctx.Bool("dry-run")
+	desired, err := desiredServices(ctx, m, !dryRun)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	r := runtimeFromContext(ctx)
+	current, err := r.Read()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	changes := plan(desired, current)
+	printPlan(changes)
+	if dryRun {
+		return
+	}
+
+	var failed bool
+	for _, c := range changes {
+		if err := applyChange(r, c); err != nil {
+			fmt.Printf("Error applying %v to %v:%v: %v\n", c.Action, c.Service.Name, c.Service.Version, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/runtime/local/git"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tt := []struct {
+		name     string
+		manifest string
+		err      bool
+	}{
+		{
+			name: "Valid",
+			manifest: `services:
+  - source: github.com/micro/services/helloworld
+    version: v1
+    env:
+      FOO: bar
+    retries: 0`,
+		},
+		{name: "Empty", manifest: `services: []`, err: true},
+		{name: "MissingSource", manifest: "services:\n  - version: v1", err: true},
+		{name: "VersionTwice", manifest: "services:\n  - source: helloworld@v1\n    version: v2", err: true},
+		{name: "UnknownField", manifest: "services:\n  - source: helloworld\n    replicas: 2", err: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name+".yaml")
+			if err := ioutil.WriteFile(path, []byte(tc.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := loadManifest(path); tc.err && err == nil {
+				t.Errorf("Expected an error loading the manifest")
+			} else if !tc.err && err != nil {
+				t.Errorf("Unexpected error loading the manifest: %v", err)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	spec := &manifestService{Source: "github.com/micro/services/foo"}
+	changed := &manifestService{Source: "github.com/micro/services/foo", Env: map[string]string{"FOO": "bar"}}
+
+	desired := func(name, source string, spec *manifestService) *change {
+		return &change{
+			Service: &runtime.Service{
+				Name:     name,
+				Version:  "latest",
+				Source:   source,
+				Metadata: map[string]string{specKey: spec.checksum()},
+			},
+			Spec:   spec,
+			Source: &git.Source{},
+		}
+	}
+
+	current := []*runtime.Service{
+		// unchanged
+		{Name: "a", Version: "latest", Source: "a", Metadata: map[string]string{specKey: spec.checksum()}},
+		// source changed
+		{Name: "b", Version: "latest", Source: "b", Metadata: map[string]string{specKey: spec.checksum()}},
+		// spec changed
+		{Name: "c", Version: "latest", Source: "c", Metadata: map[string]string{specKey: spec.checksum()}},
+		// applied but no longer declared
+		{Name: "d", Version: "latest", Source: "d", Metadata: map[string]string{specKey: spec.checksum()}},
+		// not applied and not declared
+		{Name: "e", Version: "latest", Source: "e", Metadata: map[string]string{}},
+	}
+
+	changes := plan([]*change{
+		desired("a", "a", spec),
+		desired("b", "b@v2", spec),
+		desired("c", "c", changed),
+		desired("f", "f", spec),
+	}, current)
+
+	expected := map[string]action{
+		"a": actionNone,
+		"b": actionUpdate,
+		"c": actionRecreate,
+		"d": actionDelete,
+		"f": actionCreate,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %v changes, got %v", len(expected), len(changes))
+	}
+	for _, c := range changes {
+		if a, ok := expected[c.Service.Name]; !ok {
+			t.Errorf("Unexpected change for %v", c.Service.Name)
+		} else if c.Action != a {
+			t.Errorf("Expected %v for %v, got %v", a, c.Service.Name, c.Action)
+		}
+	}
+}
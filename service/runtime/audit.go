@@ -0,0 +1,130 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+)
+
+// EventsUsage message for the events command
+const EventsUsage = "List the events in the audit log: micro events [--service name] [--since 1h] [-f]"
+
+var (
+	// eventsPollFrequency is how often the audit log is queried when following it
+	eventsPollFrequency = time.Second * 2
+	// eventsOverlap is how far back each query goes from the latest event seen, events written by
+	// other runtime instances can have an earlier timestamp than those already returned
+	eventsOverlap = time.Second * 10
+)
+
+// eventsFlags are the flags for the events command
+func eventsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "service",
+			Usage: "Only list the events of the service",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Only list the events in the duration, e.g. 1h",
+		},
+		&cli.BoolFlag{
+			Name:    "follow",
+			Aliases: []string{"f"},
+			Usage:   "Continue to list events as they're written",
+		},
+	}
+}
+
+// listEvents prints the events in the audit log, following it if requested
+func listEvents(ctx *cli.Context, srvOpts ...micro.Option) {
+	var since time.Time
+	if v := ctx.String("since"); len(v) > 0 {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			fmt.Printf("Invalid duration %v\n", v)
+			os.Exit(1)
+		}
+		since = time.Now().Add(-d)
+	}
+
+	m, err := managerFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "TIME\tACCOUNT\tACTION\tSERVICE\tVERSION\tOUTCOME")
+
+	// the ids and timestamps of the events printed which could be returned again
+	seen := make(map[string]time.Time)
+	var latest time.Time
+
+	for {
+		req := &pb.EventsRequest{Service: ctx.String("service")}
+		if !since.IsZero() {
+			req.Since = since.UnixNano()
+		}
+		polled := time.Now()
+		rsp, err := m.Events(context.TODO(), req)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		for _, ev := range rsp.Events {
+			if _, ok := seen[ev.Id]; ok {
+				continue
+			}
+			t := time.Unix(0, ev.Timestamp)
+			seen[ev.Id] = t
+			printEvent(writer, ev)
+
+			if t.After(latest) {
+				latest = t
+			}
+		}
+		writer.Flush()
+
+		if !ctx.Bool("follow") {
+			return
+		}
+
+		// only the events in the overlap can be returned again, if none have been returned the
+		// next query goes back from when the log was last read
+		from := latest
+		if from.IsZero() {
+			from = polled
+		}
+		if from = from.Add(-eventsOverlap); from.After(since) {
+			since = from
+		}
+		for id, t := range seen {
+			if t.Before(since) {
+				delete(seen, id)
+			}
+		}
+		time.Sleep(eventsPollFrequency)
+	}
+}
+
+func printEvent(w io.Writer, ev *pb.AuditEvent) {
+	account := ev.Account
+	if len(account) == 0 {
+		account = "n/a"
+	}
+	outcome := ev.Outcome
+	if len(ev.Error) > 0 {
+		outcome += ": " + ev.Error
+	}
+	created := time.Unix(0, ev.Timestamp).Format(time.RFC3339)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", created, account, ev.Action, ev.Service, ev.Version, outcome)
+}
@@ -0,0 +1,159 @@
+// Package build caches the dependencies and builds of services. The cache is kept per namespace:
+// the module caches of each language, the binaries of go services keyed by the checksum of their
+// source and the node_modules of node services keyed by the checksum of their dependency
+// manifests. Services whose source hasn't changed are started without being built again.
+//
+// The local runtime runs services in the directory of the cache. Kubernetes services mount the
+// directory of their namespace from the node they run on, so their builds are cached per node and
+// the cell images run the command of the cache in place of the one detected for the service.
+package build
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultDir is the directory the builds are cached in
+var DefaultDir = filepath.Join(os.TempDir(), "micro", "builds")
+
+const (
+	// Hit is the status of a service which was started from a cached build
+	Hit = "hit"
+	// Miss is the status of a service which was built when it was started
+	Miss = "miss"
+)
+
+// checksum is the shell command which hashes its input, sha256sum isn't installed on macos
+const checksum = `$(command -v sha256sum || echo shasum -a 256)`
+
+// Cache of the builds of services, in a directory per namespace
+type Cache struct {
+	dir string
+}
+
+// NewCache returns a cache in the directory
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Env returns the env vars which point the package managers of each language at the caches of
+// the namespace
+func (c *Cache) Env(ns string) []string {
+	dir := c.Dir(ns)
+	return []string{
+		"GOPATH=" + filepath.Join(dir, "go"),
+		"GOCACHE=" + filepath.Join(dir, "go-build"),
+		"npm_config_cache=" + filepath.Join(dir, "npm"),
+		"PIP_CACHE_DIR=" + filepath.Join(dir, "pip"),
+		"BUNDLE_PATH=" + filepath.Join(dir, "bundle"),
+		"CARGO_HOME=" + filepath.Join(dir, "cargo"),
+		"GRADLE_USER_HOME=" + filepath.Join(dir, "gradle"),
+		"MAVEN_OPTS=-Dmaven.repo.local=" + filepath.Join(dir, "m2"),
+		"COMPOSER_CACHE_DIR=" + filepath.Join(dir, "composer"),
+	}
+}
+
+// Command returns the shell command which runs the service using the cache. The command is the one
+// detected for the cell of the service, blank for go services which the runtime runs natively. The
+// checksums are computed each time the service starts since the runtime fetches the source again
+// when it's updated. Blank is returned if the cell's builds aren't cached.
+func (c *Cache) Command(ns, name, version, cell, command string) string {
+	dir := c.Dir(ns)
+	status := quote(c.statusPath(ns, name, version))
+
+	switch {
+	case (len(cell) == 0 || cell == "go") && len(command) == 0:
+		// the binary is keyed by the checksum of every file in the module, along with the path of
+		// the service within it since a module can contain many services
+		bin := quote(filepath.Join(dir, "bin"))
+		return strings.Join([]string{
+			"set -e",
+			"SUM=" + checksum,
+			"MOD=$(go env GOMOD)",
+			`case "$MOD" in ""|/dev/null) ROOT=. ;; *) ROOT=$(dirname "$MOD") ;; esac`,
+			`ROOT=$(cd "$ROOT" && pwd -P)`,
+			`KEY=$( (pwd -P | sed "s|^$ROOT||"; cd "$ROOT" && find . -type f ! -path '*/.git/*' -exec $SUM {} + | LC_ALL=C sort -k 2) | $SUM | cut -c 1-64)`,
+			"mkdir -p " + bin + " " + quote(filepath.Dir(c.statusPath(ns, name, version))),
+			`BIN=` + bin + `/$KEY`,
+			`if [ -x "$BIN" ]; then echo ` + Hit + ` > ` + status + `; touch "$BIN"; else echo ` + Miss + ` > ` + status + `; go build -o "$BIN.$$" . && mv "$BIN.$$" "$BIN"; fi`,
+			`exec "$BIN"`,
+		}, "\n")
+	case cell == "node" && strings.HasPrefix(command, "npm install && "):
+		// node_modules is keyed by the checksum of the manifests and linked into the source
+		modules := quote(filepath.Join(dir, "node_modules"))
+		return strings.Join([]string{
+			"set -e",
+			"SUM=" + checksum,
+			`KEY=$(cat package.json package-lock.json yarn.lock 2>/dev/null | $SUM | cut -c 1-64)`,
+			`MODULES=` + modules + `/$KEY`,
+			"mkdir -p \"$MODULES\" " + quote(filepath.Dir(c.statusPath(ns, name, version))),
+			`[ -L node_modules ] || rm -rf node_modules`,
+			`ln -sfn "$MODULES" node_modules`,
+			`if [ -f "$MODULES/.installed" ]; then echo ` + Hit + ` > ` + status + `; touch "$MODULES"; else echo ` + Miss + ` > ` + status + `; npm install && touch "$MODULES/.installed"; fi`,
+			strings.TrimPrefix(command, "npm install && "),
+		}, "\n")
+	default:
+		return ""
+	}
+}
+
+// Status returns whether the service was last started from a cached build, hit or miss. Blank is
+// returned if its builds aren't cached.
+func (c *Cache) Status(ns, name, version string) string {
+	b, err := ioutil.ReadFile(c.statusPath(ns, name, version))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// Unused returns the cached binaries and node_modules which haven't been used for the duration,
+// they're touched each time a service is started from them
+func (c *Cache) Unused(age time.Duration) ([]string, error) {
+	namespaces, err := ioutil.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, ns := range namespaces {
+		for _, kind := range []string{"bin", "node_modules"} {
+			dir := filepath.Join(c.dir, ns.Name(), kind)
+			entries, err := ioutil.ReadDir(dir)
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if time.Since(e.ModTime()) > age {
+					paths = append(paths, filepath.Join(dir, e.Name()))
+				}
+			}
+		}
+	}
+	return paths, nil
+}
+
+// Dir returns the directory of the namespace's cache, everything the cache of the namespace uses
+// is within it
+func (c *Cache) Dir(ns string) string {
+	return filepath.Join(c.dir, url.PathEscape(ns))
+}
+
+// statusPath returns the path of the file the build status of the service is written to
+func (c *Cache) statusPath(ns, name, version string) string {
+	return filepath.Join(c.Dir(ns), "status", url.PathEscape(name+":"+version))
+}
+
+// quote the path for the shell
+func quote(path string) string {
+	return fmt.Sprintf("'%v'", strings.ReplaceAll(path, "'", `'\''`))
+}
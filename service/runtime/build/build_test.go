@@ -0,0 +1,104 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGoCommand(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go isn't installed")
+	}
+
+	tmp, err := ioutil.TempDir("", "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	src := filepath.Join(tmp, "src")
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/hello\n\ngo 1.13\n")
+	write("foo/main.go", "package main\n\nfunc main() { println(\"foo\") }\n")
+	write("bar/main.go", "package main\n\nfunc main() { println(\"bar\") }\n")
+
+	c := NewCache(filepath.Join(tmp, "cache"))
+	run := func(name string) string {
+		cmd := exec.Command("sh", "-c", c.Command("foo", name, "latest", "go", ""))
+		cmd.Dir = filepath.Join(src, name)
+		cmd.Env = append(os.Environ(), c.Env("foo")...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Error running %v: %v\n%s", name, err, out)
+		}
+		return string(out)
+	}
+
+	if out := run("foo"); out != "foo\n" {
+		t.Fatalf("Expected the output foo, got %q", out)
+	}
+	if s := c.Status("foo", "foo", "latest"); s != Miss {
+		t.Fatalf("Expected the first build to be a %v, got %v", Miss, s)
+	}
+	if out := run("foo"); out != "foo\n" {
+		t.Fatalf("Expected the output foo, got %q", out)
+	}
+	if s := c.Status("foo", "foo", "latest"); s != Hit {
+		t.Fatalf("Expected the unchanged source to be a %v, got %v", Hit, s)
+	}
+
+	// services in the same module have their own binaries
+	if out := run("bar"); out != "bar\n" {
+		t.Fatalf("Expected the output bar, got %q", out)
+	}
+	if s := c.Status("foo", "bar", "latest"); s != Miss {
+		t.Fatalf("Expected the other service to be a %v, got %v", Miss, s)
+	}
+
+	// changing the source builds the service again
+	write("foo/main.go", "package main\n\nfunc main() { println(\"baz\") }\n")
+	if out := run("foo"); out != "baz\n" {
+		t.Fatalf("Expected the output baz, got %q", out)
+	}
+	if s := c.Status("foo", "foo", "latest"); s != Miss {
+		t.Fatalf("Expected the changed source to be a %v, got %v", Miss, s)
+	}
+
+	unused, err := c.Unused(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unused) > 0 {
+		t.Fatalf("Expected no unused builds, got %v", unused)
+	}
+	if unused, err = c.Unused(0); err != nil {
+		t.Fatal(err)
+	}
+	if len(unused) != 3 {
+		t.Fatalf("Expected 3 unused builds, got %v", unused)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	c := NewCache("/tmp/builds")
+	if cmd := c.Command("foo", "bar", "latest", "python", "exec python3 main.py"); len(cmd) > 0 {
+		t.Fatalf("Expected python builds not to be cached, got %v", cmd)
+	}
+	if cmd := c.Command("foo", "bar", "latest", "node", "exec npm start"); len(cmd) > 0 {
+		t.Fatalf("Expected node services without an install not to be cached, got %v", cmd)
+	}
+	if cmd := c.Command("foo", "bar", "latest", "node", "npm install && exec node index.js"); len(cmd) == 0 {
+		t.Fatalf("Expected the node_modules of node services to be cached")
+	}
+}
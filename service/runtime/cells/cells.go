@@ -0,0 +1,312 @@
+// Package cells detects the language of a service's source and the cell which builds and runs it.
+// The cells are the images built from the Dockerfiles in this directory.
+package cells
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Image the cells are tagged under, e.g. micro/cells:node
+	Image = "micro/cells"
+
+	// CellKey is the service metadata key the detected cell is stored under
+	CellKey = "cell"
+	// CommandKey is the service metadata key the command the cell runs the service with is stored
+	// under, it's only set for cells the runtime doesn't run natively
+	CommandKey = "cell_command"
+	// EnvKey is the env var the command is passed to the cell images in
+	EnvKey = "MICRO_CELL_COMMAND"
+
+	// the cells which sources can be detected for
+	Go     = "go"
+	Node   = "node"
+	Python = "python"
+	Ruby   = "ruby"
+	Rust   = "rust"
+	Java   = "java"
+	PHP    = "php"
+	HTML   = "html"
+	SPA    = "spa"
+	Shell  = "shell"
+)
+
+// Plan to build and run a service, detected from its source
+type Plan struct {
+	// Cell which builds and runs the service, e.g. node
+	Cell string
+	// Detected is the file the cell was detected from, e.g. package.json
+	Detected string
+	// Entrypoint the service is started from, e.g. index.js
+	Entrypoint string
+	// Command run in the source directory to start the service, e.g. "npm install && exec node index.js"
+	Command string
+}
+
+// Image returns the image of the cell, e.g. micro/cells:node
+func (p *Plan) Image() string {
+	return Image + ":" + p.Cell
+}
+
+// LocalCommand returns the command and args to run the service with in the local runtime, nil for
+// go services which the runtime runs natively. The local runtime joins the command into the path it
+// executes, so the script is passed to the shell in the args.
+func (p *Plan) LocalCommand() ([]string, []string) {
+	if p.Cell == Go {
+		return nil, nil
+	}
+	return []string{"sh"}, []string{"-c", p.Command}
+}
+
+// Metadata adds the cell and its command to the service metadata, so the runtime manager can run
+// the service in both the local and kubernetes runtimes. The command of go services is blank so
+// it's cleared when a service is updated to go.
+func (p *Plan) Metadata(md map[string]string) {
+	md[CellKey] = p.Cell
+	md[CommandKey] = ""
+	if p.Cell != Go {
+		md[CommandKey] = p.Command
+	}
+}
+
+// detectors are tried in order, the first to match a source decides its cell. Sources often
+// contain files of other languages (e.g. a node service with an index.html) so the languages of
+// services are checked before static sites and scripts.
+var detectors = []func(dir string) (*Plan, error){
+	detectGo,
+	detectNode,
+	detectPython,
+	detectRuby,
+	detectRust,
+	detectJava,
+	detectPHP,
+	detectHTML,
+	detectShell,
+}
+
+// Detect inspects the source in the directory and returns the plan to build and run it
+func Detect(dir string) (*Plan, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%v is not a directory", dir)
+	}
+
+	for _, d := range detectors {
+		plan, err := d(dir)
+		if err != nil {
+			return nil, err
+		}
+		if plan != nil {
+			return plan, nil
+		}
+	}
+	return nil, fmt.Errorf("Unable to detect the language of the source in %v, expected e.g. a go main package, package.json, requirements.txt, Cargo.toml or index.html", dir)
+}
+
+// exists returns the first of the files which exists in the directory
+func exists(dir string, files ...string) string {
+	for _, f := range files {
+		if info, err := os.Stat(filepath.Join(dir, f)); err == nil && !info.IsDir() {
+			return f
+		}
+	}
+	return ""
+}
+
+// withSuffix returns the files in the directory with the suffix, sorted by name
+func withSuffix(dir, suffix string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), suffix) {
+			files = append(files, e.Name())
+		}
+	}
+	return files
+}
+
+// detectGo matches sources with go files, which must be a main package
+func detectGo(dir string) (*Plan, error) {
+	files := withSuffix(dir, ".go")
+	if len(files) == 0 {
+		if exists(dir, "go.mod") == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Directory does not contain a main package")
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			continue
+		}
+		if strings.Contains(string(b), "package main") {
+			detected := "go.mod"
+			if exists(dir, detected) == "" {
+				detected = f
+			}
+			return &Plan{Cell: Go, Detected: detected, Entrypoint: ".", Command: "go run ."}, nil
+		}
+	}
+	return nil, fmt.Errorf("Directory does not contain a main package")
+}
+
+// detectNode matches sources with a package.json, started from its main file or start script
+func detectNode(dir string) (*Plan, error) {
+	if exists(dir, "package.json") == "" {
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Main    string            `json:"main"`
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(b, &pkg); err != nil {
+		return nil, fmt.Errorf("Error parsing package.json: %v", err)
+	}
+
+	plan := &Plan{Cell: Node, Detected: "package.json"}
+	if len(pkg.Main) > 0 && exists(dir, pkg.Main) != "" {
+		plan.Entrypoint = pkg.Main
+	} else if len(pkg.Scripts["start"]) > 0 {
+		plan.Entrypoint = "npm start"
+		plan.Command = "npm install && exec npm start"
+		return plan, nil
+	} else if plan.Entrypoint = exists(dir, "index.js", "server.js", "app.js"); plan.Entrypoint == "" {
+		return nil, fmt.Errorf("package.json has no main file or start script")
+	}
+	plan.Command = "npm install && exec node " + plan.Entrypoint
+	return plan, nil
+}
+
+// detectPython matches sources with python requirements or a python entrypoint
+func detectPython(dir string) (*Plan, error) {
+	detected := exists(dir, "requirements.txt", "Pipfile", "pyproject.toml", "setup.py")
+	entrypoint := exists(dir, "main.py", "app.py", "server.py", "__main__.py")
+	if len(detected) == 0 && len(entrypoint) == 0 {
+		return nil, nil
+	}
+	if len(entrypoint) == 0 {
+		return nil, fmt.Errorf("Found %v but no main.py, app.py, server.py or __main__.py to run", detected)
+	}
+
+	command := "exec python3 " + entrypoint
+	switch detected {
+	case "requirements.txt":
+		command = "pip3 install -r requirements.txt && " + command
+	case "Pipfile":
+		command = "pip3 install pipenv && pipenv install --system && " + command
+	case "pyproject.toml", "setup.py":
+		command = "pip3 install . && " + command
+	default:
+		detected = entrypoint
+	}
+	return &Plan{Cell: Python, Detected: detected, Entrypoint: entrypoint, Command: command}, nil
+}
+
+// detectRuby matches sources with a Gemfile or a ruby entrypoint, rack apps are run with rackup
+func detectRuby(dir string) (*Plan, error) {
+	detected := exists(dir, "Gemfile")
+	entrypoint := exists(dir, "config.ru", "main.rb", "app.rb", "server.rb")
+	if len(detected) == 0 && len(entrypoint) == 0 {
+		return nil, nil
+	}
+	if len(entrypoint) == 0 {
+		return nil, fmt.Errorf("Found Gemfile but no config.ru, main.rb, app.rb or server.rb to run")
+	}
+
+	run := "ruby " + entrypoint
+	if entrypoint == "config.ru" {
+		run = "rackup --host 0.0.0.0 --port 8080"
+	}
+	command := "exec " + run
+	if len(detected) > 0 {
+		command = "bundle install && exec bundle exec " + run
+	} else {
+		detected = entrypoint
+	}
+	return &Plan{Cell: Ruby, Detected: detected, Entrypoint: entrypoint, Command: command}, nil
+}
+
+// detectRust matches cargo packages
+func detectRust(dir string) (*Plan, error) {
+	if exists(dir, "Cargo.toml") == "" {
+		return nil, nil
+	}
+	return &Plan{Cell: Rust, Detected: "Cargo.toml", Entrypoint: "src/main.rs", Command: "exec cargo run --release"}, nil
+}
+
+// detectJava matches maven and gradle projects
+func detectJava(dir string) (*Plan, error) {
+	switch detected := exists(dir, "pom.xml", "build.gradle", "build.gradle.kts"); detected {
+	case "":
+		return nil, nil
+	case "pom.xml":
+		return &Plan{Cell: Java, Detected: detected, Entrypoint: detected, Command: "exec mvn -q compile exec:java"}, nil
+	default:
+		gradle := "gradle"
+		if exists(dir, "gradlew") != "" {
+			gradle = "./gradlew"
+		}
+		return &Plan{Cell: Java, Detected: detected, Entrypoint: detected, Command: "exec " + gradle + " run"}, nil
+	}
+}
+
+// detectPHP matches composer packages and php sites, served by the php built in server
+func detectPHP(dir string) (*Plan, error) {
+	detected := exists(dir, "composer.json")
+	entrypoint := exists(dir, "index.php")
+	if len(detected) == 0 && len(entrypoint) == 0 {
+		return nil, nil
+	}
+
+	command := "exec php -S 0.0.0.0:8080"
+	if len(entrypoint) > 0 {
+		command += " " + entrypoint
+	}
+	if len(detected) > 0 {
+		command = "composer install && " + command
+	} else {
+		detected = entrypoint
+	}
+	return &Plan{Cell: PHP, Detected: detected, Entrypoint: entrypoint, Command: command}, nil
+}
+
+// detectHTML matches static sites. Built single page applications are detected by their web app
+// manifest, they're served with a fallback to the index so routing is done by the application.
+func detectHTML(dir string) (*Plan, error) {
+	if exists(dir, "index.html") == "" {
+		return nil, nil
+	}
+	command := "exec python3 -m http.server 8080"
+	if detected := exists(dir, "asset-manifest.json", "manifest.json", "manifest.webmanifest"); len(detected) > 0 {
+		return &Plan{Cell: SPA, Detected: detected, Entrypoint: "index.html", Command: command}, nil
+	}
+	return &Plan{Cell: HTML, Detected: "index.html", Entrypoint: "index.html", Command: command}, nil
+}
+
+// detectShell matches shell scripts, the first of the conventional names or the only script
+func detectShell(dir string) (*Plan, error) {
+	entrypoint := exists(dir, "main.sh", "run.sh", "start.sh", "entrypoint.sh")
+	if len(entrypoint) == 0 {
+		scripts := withSuffix(dir, ".sh")
+		if len(scripts) != 1 {
+			return nil, nil
+		}
+		entrypoint = scripts[0]
+	}
+	return &Plan{Cell: Shell, Detected: entrypoint, Entrypoint: entrypoint, Command: "exec sh " + entrypoint}, nil
+}
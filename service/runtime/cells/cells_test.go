@@ -0,0 +1,144 @@
+package cells
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		plan  *Plan
+		err   bool
+	}{
+		{
+			name:  "go module",
+			files: map[string]string{"go.mod": "module foo", "main.go": "package main"},
+			plan:  &Plan{Cell: Go, Detected: "go.mod", Entrypoint: ".", Command: "go run ."},
+		},
+		{
+			name:  "go without main package",
+			files: map[string]string{"go.mod": "module foo", "foo.go": "package foo"},
+			err:   true,
+		},
+		{
+			name:  "node main",
+			files: map[string]string{"package.json": `{"main": "server.js"}`, "server.js": "", "index.html": ""},
+			plan:  &Plan{Cell: Node, Detected: "package.json", Entrypoint: "server.js", Command: "npm install && exec node server.js"},
+		},
+		{
+			name:  "node start script",
+			files: map[string]string{"package.json": `{"scripts": {"start": "node lib/app.js"}}`},
+			plan:  &Plan{Cell: Node, Detected: "package.json", Entrypoint: "npm start", Command: "npm install && exec npm start"},
+		},
+		{
+			name:  "python requirements",
+			files: map[string]string{"requirements.txt": "flask", "app.py": ""},
+			plan:  &Plan{Cell: Python, Detected: "requirements.txt", Entrypoint: "app.py", Command: "pip3 install -r requirements.txt && exec python3 app.py"},
+		},
+		{
+			name:  "python without entrypoint",
+			files: map[string]string{"requirements.txt": "flask"},
+			err:   true,
+		},
+		{
+			name:  "rack app",
+			files: map[string]string{"Gemfile": "", "config.ru": ""},
+			plan:  &Plan{Cell: Ruby, Detected: "Gemfile", Entrypoint: "config.ru", Command: "bundle install && exec bundle exec rackup --host 0.0.0.0 --port 8080"},
+		},
+		{
+			name:  "rust",
+			files: map[string]string{"Cargo.toml": ""},
+			plan:  &Plan{Cell: Rust, Detected: "Cargo.toml", Entrypoint: "src/main.rs", Command: "exec cargo run --release"},
+		},
+		{
+			name:  "gradle wrapper",
+			files: map[string]string{"build.gradle": "", "gradlew": ""},
+			plan:  &Plan{Cell: Java, Detected: "build.gradle", Entrypoint: "build.gradle", Command: "exec ./gradlew run"},
+		},
+		{
+			name:  "php",
+			files: map[string]string{"index.php": ""},
+			plan:  &Plan{Cell: PHP, Detected: "index.php", Entrypoint: "index.php", Command: "exec php -S 0.0.0.0:8080 index.php"},
+		},
+		{
+			name:  "single page application",
+			files: map[string]string{"index.html": "", "asset-manifest.json": "{}"},
+			plan:  &Plan{Cell: SPA, Detected: "asset-manifest.json", Entrypoint: "index.html", Command: "exec python3 -m http.server 8080"},
+		},
+		{
+			name:  "html",
+			files: map[string]string{"index.html": "", "deploy.sh": ""},
+			plan:  &Plan{Cell: HTML, Detected: "index.html", Entrypoint: "index.html", Command: "exec python3 -m http.server 8080"},
+		},
+		{
+			name:  "shell",
+			files: map[string]string{"hello.sh": ""},
+			plan:  &Plan{Cell: Shell, Detected: "hello.sh", Entrypoint: "hello.sh", Command: "exec sh hello.sh"},
+		},
+		{
+			name:  "unknown",
+			files: map[string]string{"README.md": ""},
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "cells")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for name, content := range tt.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			plan, err := Detect(dir)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("Expected an error, got plan %+v", plan)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if *plan != *tt.plan {
+				t.Fatalf("Expected plan %+v, got %+v", tt.plan, plan)
+			}
+		})
+	}
+}
+
+func TestPlanMetadata(t *testing.T) {
+	md := map[string]string{}
+	(&Plan{Cell: Go, Command: "go run ."}).Metadata(md)
+	if v, ok := md[CommandKey]; md[CellKey] != Go || !ok || len(v) > 0 {
+		t.Fatalf("Expected a blank command for go services, got %v", md)
+	}
+
+	md = map[string]string{}
+	(&Plan{Cell: Node, Command: "npm install && exec npm start"}).Metadata(md)
+	if md[CellKey] != Node || md[CommandKey] != "npm install && exec npm start" {
+		t.Fatalf("Expected the cell and command, got %v", md)
+	}
+}
+
+func TestLocalCommand(t *testing.T) {
+	if command, args := (&Plan{Cell: Go, Command: "go run ."}).LocalCommand(); command != nil || args != nil {
+		t.Fatalf("Expected go services to be run natively, got %v %v", command, args)
+	}
+
+	// the local runtime executes the command joined, so it must be a single path
+	command, args := (&Plan{Cell: Node, Command: "npm install && exec npm start"}).LocalCommand()
+	if !reflect.DeepEqual(command, []string{"sh"}) || !reflect.DeepEqual(args, []string{"-c", "npm install && exec npm start"}) {
+		t.Fatalf("Expected the script in the args of sh, got %v %v", command, args)
+	}
+}
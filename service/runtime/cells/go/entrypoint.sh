@@ -20,4 +20,5 @@ fi
 
 # run the source
 echo "Running service"
-go run .
\ No newline at end of file
+# the runtime manager passes the command which runs the service from the build cache
+sh -c "${MICRO_CELL_COMMAND:-go run .}"
\ No newline at end of file
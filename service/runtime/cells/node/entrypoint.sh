@@ -18,5 +18,5 @@ if [ $# -eq 2 ]
     cd $2
 fi
 
-npm install
-node index.js
\ No newline at end of file
+# run the command detected from the source by micro run
+sh -c "${MICRO_CELL_COMMAND:-npm install && exec node index.js}"
\ No newline at end of file
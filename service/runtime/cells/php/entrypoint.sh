@@ -0,0 +1,23 @@
+#!/bin/dumb-init /bin/sh
+
+set -x  
+set -e
+
+REPO=$1
+
+mkdir /app
+cd /app
+
+# clone the repo
+echo "Cloning $REPO"
+git clone $REPO .
+
+# If parameter 2nd parameter is supplied, it's the path
+if [ $# -eq 2 ]
+  then
+    cd $2
+fi
+
+# run the command detected from the source by micro run
+echo "Running service"
+sh -c "${MICRO_CELL_COMMAND:-exec php -S 0.0.0.0:8080 index.php}"
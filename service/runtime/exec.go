@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+)
+
+// ExecUsage message for the exec command
+const ExecUsage = "Run a command in a running service: micro exec [source] -- [command]"
+
+// execService runs the command in the args in the service, streaming stdin to it and its output to
+// stdout and stderr. The exit code of the command is used as ours.
+func execService(ctx *cli.Context, srvOpts ...micro.Option) {
+	if ctx.Args().Len() < 2 {
+		fmt.Println(ExecUsage)
+		os.Exit(1)
+	}
+
+	name, version, err := serviceFromArgs(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if ctx.IsSet("version") {
+		version = ctx.String("version")
+	}
+
+	m, err := managerFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	stream, err := m.Exec(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer stream.Close()
+
+	if err := stream.Send(&pb.ExecRequest{
+		Service: name,
+		Version: version,
+		Command: ctx.Args().Slice()[1:],
+	}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				if stream.Send(&pb.ExecRequest{Stdin: buf[:n]}) != nil {
+					return
+				}
+			}
+			if err != nil {
+				stream.Send(&pb.ExecRequest{CloseStdin: true})
+				return
+			}
+		}
+	}()
+
+	for {
+		rsp, err := stream.Recv()
+		if err == io.EOF {
+			fmt.Println("Connection closed before the command exited")
+			os.Exit(1)
+		} else if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		os.Stdout.Write(rsp.Stdout)
+		os.Stderr.Write(rsp.Stderr)
+
+		if !rsp.Exited {
+			continue
+		}
+		if len(rsp.Error) > 0 {
+			fmt.Println(rsp.Error)
+			os.Exit(1)
+		}
+		os.Exit(int(rsp.ExitCode))
+	}
+}
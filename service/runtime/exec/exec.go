@@ -0,0 +1,58 @@
+// Package exec runs commands inside the processes of local services and the pods of kubernetes
+// services, streaming their input and output
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/micro/v2/service/runtime/limits"
+)
+
+// EnvKey is the env var set on local services so the processes running them can be found
+const EnvKey = "MICRO_RUNTIME_SERVICE"
+
+// Options for a command
+type Options struct {
+	// Namespace of the service
+	Namespace string
+	// Context stops the command when it's done, e.g. on a timeout. Defaults to a context which is
+	// never done.
+	Context context.Context
+	// Limits the service is sandboxed with, the command is run with the same limits
+	Limits *limits.Limits
+	// Stdin of the command, nil if it has no input
+	Stdin io.Reader
+	// Stdout and Stderr of the command
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Executor runs commands inside the services running in a runtime
+type Executor interface {
+	// Exec runs the command in the service, returning its exit code once it exits
+	Exec(srv *runtime.Service, command []string, opts Options) (int, error)
+}
+
+// New returns the executor for the runtime
+func New(r runtime.Runtime) (Executor, error) {
+	switch r.String() {
+	case "local":
+		return &Local{}, nil
+	case "kubernetes":
+		k, err := NewKubernetes()
+		if err != nil {
+			return nil, err
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("Exec is not supported by the %v runtime", r.String())
+	}
+}
+
+// ID identifies the service in the env of its processes
+func ID(namespace string, srv *runtime.Service) string {
+	return namespace + "/" + srv.Name + "/" + srv.Version
+}
@@ -0,0 +1,243 @@
+package exec
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/util/kubernetes/client"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// serviceAccountPath is where the credentials of the pod's service account are mounted
+	serviceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// the exec subprotocols, v5 adds closing stdin
+	protocolV4 = "v4.channel.k8s.io"
+	protocolV5 = "v5.channel.k8s.io"
+
+	// the channels multiplexed over the exec websocket, each message is prefixed with the channel
+	channelStdin  = 0
+	channelStdout = 1
+	channelStderr = 2
+	channelStatus = 3
+	channelClose  = 255
+)
+
+// Kubernetes runs commands in the pods of kubernetes services using the exec api
+type Kubernetes struct {
+	// Host of the kubernetes api, e.g. 10.0.0.1:443
+	Host string
+	// Token to authenticate with
+	Token string
+	// TLS config to connect with
+	TLS *tls.Config
+}
+
+// NewKubernetes returns an executor using the in cluster config
+func NewKubernetes() (*Kubernetes, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if len(host) == 0 || len(port) == 0 {
+		return nil, fmt.Errorf("Kubernetes exec is only supported in cluster")
+	}
+
+	token, err := ioutil.ReadFile(filepath.Join(serviceAccountPath, "token"))
+	if err != nil {
+		return nil, err
+	}
+	ca, err := ioutil.ReadFile(filepath.Join(serviceAccountPath, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("Invalid CA in %v", serviceAccountPath)
+	}
+
+	return &Kubernetes{
+		Host:  net.JoinHostPort(host, port),
+		Token: string(token),
+		TLS:   &tls.Config{RootCAs: pool},
+	}, nil
+}
+
+// Exec runs the command in a running pod of the service
+func (k *Kubernetes) Exec(srv *runtime.Service, command []string, opts Options) (int, error) {
+	if len(command) == 0 {
+		return 0, fmt.Errorf("No command to exec")
+	}
+
+	ns := client.Format(opts.Namespace)
+	if len(ns) == 0 {
+		ns = "default"
+	}
+	pod, err := k.findPod(ns, srv)
+	if err != nil {
+		return 0, err
+	}
+
+	query := url.Values{"stdout": {"true"}, "stderr": {"true"}, "command": command}
+	if opts.Stdin != nil {
+		query.Set("stdin", "true")
+	}
+	path := fmt.Sprintf("wss://%v/api/v1/namespaces/%v/pods/%v/exec?%v", k.Host, ns, pod, query.Encode())
+
+	config, err := websocket.NewConfig(path, "https://"+k.Host)
+	if err != nil {
+		return 0, err
+	}
+	config.Protocol = []string{protocolV5, protocolV4}
+	config.TlsConfig = k.TLS
+	config.Header = http.Header{"Authorization": {"Bearer " + k.Token}}
+
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return 0, fmt.Errorf("Error connecting to pod %v: %v", pod, err)
+	}
+	defer ws.Close()
+
+	// closing the connection stops the command
+	if opts.Context != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-opts.Context.Done():
+				ws.Close()
+			case <-done:
+			}
+		}()
+	}
+
+	if opts.Stdin != nil {
+		canClose := len(ws.Config().Protocol) > 0 && ws.Config().Protocol[0] == protocolV5
+		go writeStdin(ws, opts.Stdin, canClose)
+	}
+
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err == io.EOF {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		switch msg[0] {
+		case channelStdout:
+			opts.Stdout.Write(msg[1:])
+		case channelStderr:
+			opts.Stderr.Write(msg[1:])
+		case channelStatus:
+			return exitCode(msg[1:])
+		}
+	}
+}
+
+// findPod returns the name of a running pod of the service, they're labelled with the formatted
+// name and version of the service by the runtime
+func (k *Kubernetes) findPod(ns string, srv *runtime.Service) (string, error) {
+	selector := "name=" + client.Format(srv.Name) + ",version=" + client.Format(srv.Version)
+	path := fmt.Sprintf("https://%v/api/v1/namespaces/%v/pods?labelSelector=%v", k.Host, ns, url.QueryEscape(selector))
+
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.Token)
+
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: k.TLS}}
+	rsp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(rsp.Body)
+		return "", fmt.Errorf("Error listing pods: %v %s", rsp.Status, body)
+	}
+
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&pods); err != nil {
+		return "", err
+	}
+	for _, p := range pods.Items {
+		if p.Status.Phase == "Running" {
+			return p.Metadata.Name, nil
+		}
+	}
+	return "", fmt.Errorf("No running pod found for service %v:%v", srv.Name, srv.Version)
+}
+
+// writeStdin copies the input to the stdin channel, closing it at the end of the input if the
+// protocol supports it
+func writeStdin(ws *websocket.Conn, r io.Reader, canClose bool) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := websocket.Message.Send(ws, append([]byte{channelStdin}, buf[:n]...)); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if canClose {
+		websocket.Message.Send(ws, []byte{channelClose, channelStdin})
+	}
+}
+
+// exitCode parses the status sent once the command exits, a non zero exit code is sent as a failure
+// with the code as the cause
+func exitCode(msg []byte) (int, error) {
+	var status struct {
+		Status  string `json:"status"`
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+		Details struct {
+			Causes []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"causes"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(msg, &status); err != nil {
+		return 0, err
+	}
+
+	if status.Status == "Success" {
+		return 0, nil
+	}
+	if status.Reason == "NonZeroExitCode" {
+		for _, c := range status.Details.Causes {
+			if c.Reason == "ExitCode" {
+				return strconv.Atoi(c.Message)
+			}
+		}
+	}
+	return 0, fmt.Errorf("Error running command: %v", status.Message)
+}
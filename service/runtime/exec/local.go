@@ -0,0 +1,174 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/micro/v2/service/runtime/limits"
+)
+
+// procDir is where the processes running on the host are listed
+var procDir = "/proc"
+
+// process running a local service
+type process struct {
+	Pid int
+	// Dir is the working directory of the process
+	Dir string
+	// Env of the process
+	Env []string
+}
+
+// Local runs commands in the sandbox of local services: the working directory and env of the
+// process running the service, with the same resource limits
+type Local struct{}
+
+// Exec runs the command in the service
+func (l *Local) Exec(srv *runtime.Service, command []string, opts Options) (int, error) {
+	if len(command) == 0 {
+		return 0, fmt.Errorf("No command to exec")
+	}
+
+	id := ID(opts.Namespace, srv)
+	proc, err := findProcess(id)
+	if err != nil {
+		return 0, err
+	}
+
+	path, err := lookPath(command[0], proc.Env)
+	if err != nil {
+		return 0, err
+	}
+	args := command[1:]
+
+	// the limits are applied by wrapping the command, the same way the service was started
+	if opts.Limits != nil {
+		cmd, wrapped, err := limits.Command(opts.Limits, id, []string{path}, args)
+		if err != nil {
+			return 0, err
+		}
+		path, args = cmd[0], wrapped
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = proc.Dir
+	cmd.Env = proc.Env
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+
+	// stdin is copied separately since waiting for the command would otherwise block until the
+	// input is closed, even once the command has exited
+	var stdin io.WriteCloser
+	if opts.Stdin != nil {
+		if stdin, err = cmd.StdinPipe(); err != nil {
+			return 0, err
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	if stdin != nil {
+		go func() {
+			io.Copy(stdin, opts.Stdin)
+			stdin.Close()
+		}()
+	}
+
+	err = cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	} else if err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// findProcess returns the process running the service with the id, found by the env var set when
+// it was started. The processes are listed in /proc so this is only supported on linux.
+func findProcess(id string) (*process, error) {
+	entries, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return nil, fmt.Errorf("Exec is not supported on this platform: %v", err)
+	}
+
+	var pids []int
+	for _, e := range entries {
+		if pid, err := strconv.Atoi(e.Name()); err == nil && e.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+
+	marker := []byte(EnvKey + "=" + id)
+	for _, pid := range pids {
+		dir := filepath.Join(procDir, strconv.Itoa(pid))
+
+		// processes of other users can't be read
+		environ, err := ioutil.ReadFile(filepath.Join(dir, "environ"))
+		if err != nil {
+			continue
+		}
+		var env []string
+		var found bool
+		for _, e := range bytes.Split(environ, []byte{0}) {
+			if len(e) == 0 {
+				continue
+			}
+			if bytes.Equal(e, marker) {
+				found = true
+			}
+			env = append(env, string(e))
+		}
+		if !found {
+			continue
+		}
+
+		cwd, err := os.Readlink(filepath.Join(dir, "cwd"))
+		if err != nil {
+			continue
+		}
+		return &process{Pid: pid, Dir: cwd, Env: env}, nil
+	}
+
+	return nil, fmt.Errorf("No running process found for service %v", id)
+}
+
+// lookPath finds the executable using the PATH of the service rather than our own
+func lookPath(file string, env []string) (string, error) {
+	if strings.Contains(file, "/") {
+		return file, nil
+	}
+
+	var path string
+	for _, e := range env {
+		if strings.HasPrefix(e, "PATH=") {
+			path = strings.TrimPrefix(e, "PATH=")
+		}
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if len(dir) == 0 {
+			continue
+		}
+		p := filepath.Join(dir, file)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return p, nil
+		}
+	}
+
+	// fallback to our own path
+	return exec.LookPath(file)
+}
@@ -0,0 +1,63 @@
+package exec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/v2/runtime"
+)
+
+func TestLocal(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(procDir, "self", "environ")); err != nil {
+		t.Skip("Processes can't be listed on this platform")
+	}
+
+	dir, err := ioutil.TempDir("", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// a process running the service, found by its env
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest"}
+	proc := exec.Command("sleep", "10")
+	proc.Dir = dir
+	proc.Env = append(os.Environ(), EnvKey+"="+ID("test", srv), "FOO=bar")
+	if err := proc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Process.Kill()
+
+	var stdout, stderr bytes.Buffer
+	code, err := (&Local{}).Exec(srv, []string{"sh", "-c", "pwd; echo $FOO; cat; echo oops >&2; exit 3"}, Options{
+		Namespace: "test",
+		Stdin:     strings.NewReader("hello"),
+		Stdout:    &stdout,
+		Stderr:    &stderr,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error running command: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("Expected exit code 3, got %v", code)
+	}
+	if expected := dir + "\nbar\nhello"; stdout.String() != expected {
+		t.Errorf("Expected stdout %q, got %q", expected, stdout.String())
+	}
+	if stderr.String() != "oops\n" {
+		t.Errorf("Expected stderr %q, got %q", "oops\n", stderr.String())
+	}
+
+	// the namespace is part of the id so the service isn't found in another namespace
+	if _, err := (&Local{}).Exec(srv, []string{"pwd"}, Options{Namespace: "other"}); err == nil {
+		t.Errorf("Expected an error running a command in a service which isn't running")
+	}
+}
@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/micro/cli/v2"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+)
+
+// GCUsage message for the gc command
+const GCUsage = "Remove the state left behind by services which no longer exist or whose namespace was deleted: micro runtime gc [--dry-run]"
+
+// collectGarbage removes the orphaned records, statuses, processes and uploaded sources of services
+// which no longer exist along with unused builds, printing what was removed or would be on a dry run
+func collectGarbage(ctx *cli.Context) {
+	m, err := managerFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	dryRun := ctx.Bool("dry-run")
+	rsp, err := m.GC(context.TODO(), &pb.GCRequest{DryRun: dryRun})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if len(rsp.Orphans) == 0 {
+		fmt.Println("Nothing to remove")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "KIND\tNAMESPACE\tSERVICE\tVERSION\tKEY")
+	for _, o := range rsp.Orphans {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", o.Kind, orNA(o.Namespace), orNA(o.Service), orNA(o.Version), o.Key)
+	}
+	writer.Flush()
+
+	if dryRun {
+		fmt.Printf("Would remove %v orphans\n", len(rsp.Orphans))
+	} else {
+		fmt.Printf("Removed %v orphans\n", len(rsp.Orphans))
+	}
+}
+
+// orNA returns n/a for blank values in tables
+func orNA(v string) string {
+	if len(v) == 0 {
+		return "n/a"
+	}
+	return v
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/v2/auth"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/micro/v2/service/runtime/manager"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+)
+
+// audit writes the outcome of the action taken on the service to the audit log, along with the
+// account which made the request. The outcome of the actions applied asynchronously is written
+// by the manager, so they're only written here if the request is rejected.
+func audit(ctx context.Context, a manager.Auditor, action string, srv *runtime.Service, err error) {
+	if a == nil {
+		return
+	}
+
+	ev := &manager.AuditEvent{
+		Action:    action,
+		Namespace: getNamespace(ctx),
+		Service:   srv.Name,
+		Version:   srv.Version,
+		Outcome:   manager.AuditSuccess,
+	}
+	if acc, ok := auth.AccountFromContext(ctx); ok {
+		ev.Account = acc.ID
+	}
+	if err != nil {
+		ev.Outcome = manager.AuditError
+		ev.Error = err.Error()
+	}
+
+	if err := a.Audit(ev); err != nil {
+		log.Warnf("Error writing %s of service %s version %s to the audit log: %v", action, srv.Name, srv.Version, err)
+	}
+}
+
+func toAuditEventProto(ev *manager.AuditEvent) *pb.AuditEvent {
+	return &pb.AuditEvent{
+		Id:        ev.ID,
+		Timestamp: ev.Timestamp.UnixNano(),
+		Action:    ev.Action,
+		Namespace: ev.Namespace,
+		Account:   ev.Account,
+		Service:   ev.Service,
+		Version:   ev.Version,
+		Outcome:   ev.Outcome,
+		Error:     ev.Error,
+	}
+}
@@ -9,6 +9,7 @@ import (
 	log "github.com/micro/go-micro/v2/logger"
 	"github.com/micro/go-micro/v2/runtime"
 	pb "github.com/micro/go-micro/v2/runtime/service/proto"
+	"github.com/micro/micro/v2/service/runtime/manager"
 )
 
 type Runtime struct {
@@ -16,6 +17,8 @@ type Runtime struct {
 	Runtime runtime.Runtime
 	// The client used to publish events
 	Client micro.Publisher
+	// The audit log the outcome of requests is written to
+	Audit manager.Auditor
 }
 
 func (r *Runtime) Read(ctx context.Context, req *pb.ReadRequest, rsp *pb.ReadResponse) error {
@@ -43,7 +46,10 @@ func (r *Runtime) Create(ctx context.Context, req *pb.CreateRequest, rsp *pb.Cre
 
 	log.Infof("Creating service %s version %s source %s", service.Name, service.Version, service.Source)
 
+	// the outcome is written to the audit log by the manager once the service is created, unless
+	// the request is rejected
 	if err := r.Runtime.Create(service, options...); err != nil {
+		audit(ctx, r.Audit, "create", service, err)
 		return errors.InternalServerError("go.micro.runtime", err.Error())
 	}
 
@@ -69,6 +75,7 @@ func (r *Runtime) Update(ctx context.Context, req *pb.UpdateRequest, rsp *pb.Upd
 	log.Infof("Updating service %s version %s source %s", service.Name, service.Version, service.Source)
 
 	if err := r.Runtime.Update(service, options...); err != nil {
+		audit(ctx, r.Audit, "update", service, err)
 		return errors.InternalServerError("go.micro.runtime", err.Error())
 	}
 
@@ -94,6 +101,7 @@ func (r *Runtime) Delete(ctx context.Context, req *pb.DeleteRequest, rsp *pb.Del
 	log.Infof("Deleting service %s version %s source %s", service.Name, service.Version, service.Source)
 
 	if err := r.Runtime.Delete(service, options...); err != nil {
+		audit(ctx, r.Audit, "delete", service, err)
 		return errors.InternalServerError("go.micro.runtime", err.Error())
 	}
 
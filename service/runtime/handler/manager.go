@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/micro/go-micro/v2/auth"
+	"github.com/micro/go-micro/v2/errors"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/runtime"
+	rpb "github.com/micro/go-micro/v2/runtime/service/proto"
+	"github.com/micro/micro/v2/service/runtime/exec"
+	"github.com/micro/micro/v2/service/runtime/manager"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+)
+
+// statusResync is how often the services are read when streaming their statuses, as well as when
+// the manager changes them, so the instances registered and the runs started by other managers
+// are included
+var statusResync = time.Second * 10
+
+type Manager struct {
+	// The manager used to track service revisions
+	Manager manager.Manager
+}
+
+func (m *Manager) History(ctx context.Context, req *pb.HistoryRequest, rsp *pb.HistoryResponse) error {
+	if len(req.Service) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank service")
+	}
+
+	revs, err := m.Manager.History(getNamespace(ctx), &runtime.Service{
+		Name:    req.Service,
+		Version: req.Version,
+	})
+	if err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+
+	for _, rev := range revs {
+		rsp.Revisions = append(rsp.Revisions, toRevisionProto(rev))
+	}
+
+	return nil
+}
+
+func (m *Manager) Rollback(ctx context.Context, req *pb.RollbackRequest, rsp *pb.RollbackResponse) error {
+	if len(req.Service) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank service")
+	}
+	if req.Revision < 0 {
+		return errors.BadRequest("go.micro.runtime", "invalid revision")
+	}
+
+	log.Infof("Rolling back service %s version %s to revision %d", req.Service, req.Version, req.Revision)
+
+	// the outcome is written to the audit log by the manager once the rollback is applied, unless
+	// the request is rejected
+	var account string
+	if acc, ok := auth.AccountFromContext(ctx); ok {
+		account = acc.ID
+	}
+	srv := &runtime.Service{Name: req.Service, Version: req.Version}
+	rev, err := m.Manager.Rollback(getNamespace(ctx), srv, req.Revision, account)
+	if err != nil {
+		audit(ctx, m.Manager, "rollback", srv, err)
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+
+	rsp.Revision = toRevisionProto(rev)
+	return nil
+}
+
+func (m *Manager) RunLogs(ctx context.Context, req *pb.RunLogsRequest, rsp *pb.RunLogsResponse) error {
+	if len(req.Service) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank service")
+	}
+	if len(req.Run) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank run")
+	}
+
+	lines, err := m.Manager.RunLogs(getNamespace(ctx), &runtime.Service{
+		Name:    req.Service,
+		Version: req.Version,
+	}, req.Run)
+	if err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+
+	rsp.Lines = lines
+	return nil
+}
+
+func (m *Manager) Exec(ctx context.Context, stream pb.Manager_ExecStream) error {
+	defer stream.Close()
+
+	// commands are always run on behalf of an account, the auth rules restrict exec to admins
+	acc, ok := auth.AccountFromContext(ctx)
+	if !ok {
+		return errors.Unauthorized("go.micro.runtime", "An account is required to exec commands")
+	}
+
+	// the first message sets the service and command
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if len(req.Service) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank service")
+	}
+	if len(req.Command) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank command")
+	}
+	if len(req.Version) == 0 {
+		req.Version = "latest"
+	}
+
+	log.Infof("Account %s running %v in service %s version %s", acc.ID, req.Command, req.Service, req.Version)
+
+	stdin, writer := io.Pipe()
+	defer stdin.Close()
+	go func(msg *pb.ExecRequest) {
+		for {
+			if len(msg.Stdin) > 0 {
+				if _, err := writer.Write(msg.Stdin); err != nil {
+					return
+				}
+			}
+			if msg.CloseStdin {
+				writer.Close()
+				return
+			}
+			var err error
+			if msg, err = stream.Recv(); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+	}(req)
+
+	var mtx sync.Mutex
+	srv := &runtime.Service{Name: req.Service, Version: req.Version}
+	code, err := m.Manager.Exec(getNamespace(ctx), srv, req.Command, exec.Options{
+		Stdin:  stdin,
+		Stdout: &execWriter{stream: stream, mtx: &mtx},
+		Stderr: &execWriter{stream: stream, mtx: &mtx, stderr: true},
+	})
+	audit(ctx, m.Manager, "exec", srv, err)
+
+	rsp := &pb.ExecResponse{Exited: true, ExitCode: int32(code)}
+	if err != nil {
+		rsp.Error = err.Error()
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	return stream.Send(rsp)
+}
+
+// execWriter sends the output of a command to the stream
+type execWriter struct {
+	stream pb.Manager_ExecStream
+	mtx    *sync.Mutex
+	stderr bool
+}
+
+func (w *execWriter) Write(p []byte) (int, error) {
+	rsp := &pb.ExecResponse{Stdout: p}
+	if w.stderr {
+		rsp = &pb.ExecResponse{Stderr: p}
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if err := w.stream.Send(rsp); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (m *Manager) Events(ctx context.Context, req *pb.EventsRequest, rsp *pb.EventsResponse) error {
+	var since time.Time
+	if req.Since > 0 {
+		since = time.Unix(0, req.Since)
+	}
+
+	events, err := m.Manager.AuditLog(getNamespace(ctx), req.Service, since)
+	if err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+
+	for _, ev := range events {
+		rsp.Events = append(rsp.Events, toAuditEventProto(ev))
+	}
+	return nil
+}
+
+func (m *Manager) GC(ctx context.Context, req *pb.GCRequest, rsp *pb.GCResponse) error {
+	// the state of every namespace is collected so it's restricted to admins by the auth rules
+	acc, ok := auth.AccountFromContext(ctx)
+	if !ok {
+		return errors.Unauthorized("go.micro.runtime", "An account is required to collect garbage")
+	}
+
+	log.Infof("Account %s collecting garbage, dry run %v", acc.ID, req.DryRun)
+
+	orphans, err := m.Manager.GC(req.DryRun, acc.ID)
+	if err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+
+	for _, o := range orphans {
+		rsp.Orphans = append(rsp.Orphans, &pb.Orphan{
+			Kind:      o.Kind,
+			Namespace: o.Namespace,
+			Service:   o.Service,
+			Version:   o.Version,
+			Key:       o.Key,
+		})
+	}
+	return nil
+}
+
+// Status streams the services in the namespace, sending them again each time their statuses may
+// have changed until the client closes the stream
+func (m *Manager) Status(ctx context.Context, req *pb.StatusRequest, stream pb.Manager_StatusStream) error {
+	options := toReadOptions(ctx, &rpb.ReadOptions{
+		Service: req.Service,
+		Version: req.Version,
+		Type:    req.Type,
+	})
+
+	changed, stop := m.Manager.WatchStatus(getNamespace(ctx))
+	defer stop()
+	ticker := time.NewTicker(statusResync)
+	defer ticker.Stop()
+
+	var prev *pb.StatusResponse
+	for {
+		srvs, err := m.Manager.Read(options...)
+		if err != nil {
+			return errors.InternalServerError("go.micro.runtime", err.Error())
+		}
+		rsp := &pb.StatusResponse{}
+		for _, srv := range srvs {
+			rsp.Services = append(rsp.Services, toServiceProto(srv))
+		}
+
+		// the services are only sent if they've changed since they were last sent
+		if prev == nil || !proto.Equal(rsp, prev) {
+			if err := stream.Send(rsp); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return errors.InternalServerError("go.micro.runtime", err.Error())
+			}
+			prev = rsp
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/v2/errors"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/micro/micro/v2/service/runtime/secrets"
+	pb "github.com/micro/micro/v2/service/runtime/secrets/proto"
+)
+
+type Secrets struct {
+	// The secrets referenced by services, encrypted per namespace
+	Secrets *secrets.Secrets
+}
+
+func (s *Secrets) Set(ctx context.Context, req *pb.SetRequest, rsp *pb.SetResponse) error {
+	if len(req.Name) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank name")
+	}
+
+	log.Infof("Setting secret %s in namespace %s", req.Name, getNamespace(ctx))
+
+	if err := s.Secrets.Set(getNamespace(ctx), req.Name, req.Value); err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+	return nil
+}
+
+func (s *Secrets) Get(ctx context.Context, req *pb.GetRequest, rsp *pb.GetResponse) error {
+	if len(req.Name) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank name")
+	}
+
+	value, err := s.Secrets.Get(getNamespace(ctx), req.Name)
+	if err == secrets.ErrNotFound {
+		return errors.NotFound("go.micro.runtime", "secret %v not found", req.Name)
+	} else if err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+
+	rsp.Value = value
+	return nil
+}
+
+func (s *Secrets) List(ctx context.Context, req *pb.ListRequest, rsp *pb.ListResponse) error {
+	names, err := s.Secrets.List(getNamespace(ctx))
+	if err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+
+	rsp.Names = names
+	return nil
+}
+
+func (s *Secrets) Delete(ctx context.Context, req *pb.DeleteRequest, rsp *pb.DeleteResponse) error {
+	if len(req.Name) == 0 {
+		return errors.BadRequest("go.micro.runtime", "blank name")
+	}
+
+	log.Infof("Deleting secret %s in namespace %s", req.Name, getNamespace(ctx))
+
+	err := s.Secrets.Delete(getNamespace(ctx), req.Name)
+	if err == secrets.ErrNotFound {
+		return errors.NotFound("go.micro.runtime", "secret %v not found", req.Name)
+	} else if err != nil {
+		return errors.InternalServerError("go.micro.runtime", err.Error())
+	}
+	return nil
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/micro/go-micro/v2/runtime"
 	pb "github.com/micro/go-micro/v2/runtime/service/proto"
 	"github.com/micro/micro/v2/internal/namespace"
+	"github.com/micro/micro/v2/service/runtime/manager"
+	mpb "github.com/micro/micro/v2/service/runtime/manager/proto"
 )
 
 func toProto(s *runtime.Service) *pb.Service {
@@ -33,6 +35,26 @@ func toService(s *pb.Service) *runtime.Service {
 	}
 }
 
+func toRevisionProto(r *manager.Revision) *mpb.Revision {
+	return &mpb.Revision{
+		Number:  r.Number,
+		Action:  r.Action,
+		Created: r.Created.Unix(),
+		Name:    r.Service.Name,
+		Version: r.Service.Version,
+		Source:  r.Service.Source,
+	}
+}
+
+func toServiceProto(s *runtime.Service) *mpb.Service {
+	return &mpb.Service{
+		Name:     s.Name,
+		Version:  s.Version,
+		Source:   s.Source,
+		Metadata: s.Metadata,
+	}
+}
+
 // getNamespace replaces the default auth namespace until we move
 // we wil replace go.micro with micro and move our default things there
 func getNamespace(ctx context.Context) string {
@@ -46,6 +68,7 @@ func toCreateOptions(ctx context.Context, opts *pb.CreateOptions) []runtime.Crea
 
 	options := []runtime.CreateOption{
 		runtime.CreateNamespace(getNamespace(ctx)),
+		runtime.CreateContext(ctx),
 	}
 
 	// command options
@@ -107,6 +130,7 @@ func toUpdateOptions(ctx context.Context, opts *pb.UpdateOptions) []runtime.Upda
 
 	return []runtime.UpdateOption{
 		runtime.UpdateNamespace(getNamespace(ctx)),
+		runtime.UpdateContext(ctx),
 	}
 }
 
@@ -117,6 +141,7 @@ func toDeleteOptions(ctx context.Context, opts *pb.DeleteOptions) []runtime.Dele
 
 	return []runtime.DeleteOption{
 		runtime.DeleteNamespace(getNamespace(ctx)),
+		runtime.DeleteContext(ctx),
 	}
 }
 
@@ -0,0 +1,124 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/runtime/local/git"
+	cliutil "github.com/micro/micro/v2/client/cli/util"
+	"github.com/micro/micro/v2/internal/client"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+)
+
+const (
+	// HistoryUsage message for the history command
+	HistoryUsage = "List the revisions of a service: micro history [source]"
+	// RollbackUsage message for the rollback command
+	RollbackUsage = "Rollback a service: micro rollback [source] [revision]"
+)
+
+// managerFromContext returns a client for the runtime manager, which is only available when
+// running against a micro server
+func managerFromContext(ctx *cli.Context) (pb.ManagerService, error) {
+	if cliutil.IsLocal(ctx) {
+		return nil, fmt.Errorf("Command is not supported in the local environment")
+	}
+	return pb.NewManagerService(Name, client.New(ctx)), nil
+}
+
+// serviceFromArgs parses the service name and version from the source in the first arg
+func serviceFromArgs(ctx *cli.Context) (string, string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", "", err
+	}
+	source, err := git.ParseSourceLocal(wd, ctx.Args().Get(0))
+	if err != nil {
+		return "", "", err
+	}
+	version := source.Ref
+	if len(version) == 0 {
+		version = "latest"
+	}
+	return source.RuntimeName(), version, nil
+}
+
+func getHistory(ctx *cli.Context, srvOpts ...micro.Option) {
+	if ctx.Args().Len() == 0 {
+		fmt.Println(HistoryUsage)
+		return
+	}
+
+	name, version, err := serviceFromArgs(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	m, err := managerFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rsp, err := m.History(context.TODO(), &pb.HistoryRequest{Service: name, Version: version})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "REVISION\tACTION\tSOURCE\tCREATED")
+	for _, rev := range rsp.Revisions {
+		created := time.Unix(rev.Created, 0).Format(time.RFC3339)
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", rev.Number, rev.Action, rev.Source, timeAgo(created))
+	}
+	writer.Flush()
+}
+
+func rollbackService(ctx *cli.Context, srvOpts ...micro.Option) {
+	if ctx.Args().Len() == 0 {
+		fmt.Println(RollbackUsage)
+		return
+	}
+
+	name, version, err := serviceFromArgs(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// the revision defaults to the previous one
+	var revision int64
+	if ctx.Args().Len() > 1 {
+		revision, err = strconv.ParseInt(ctx.Args().Get(1), 10, 64)
+		if err != nil || revision <= 0 {
+			fmt.Printf("Invalid revision %v\n", ctx.Args().Get(1))
+			os.Exit(1)
+		}
+	}
+
+	m, err := managerFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rsp, err := m.Rollback(context.TODO(), &pb.RollbackRequest{
+		Service:  name,
+		Version:  version,
+		Revision: revision,
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Rolled back %v:%v to %v, now at revision %v\n", name, version, rsp.Revision.Source, rsp.Revision.Number)
+}
@@ -0,0 +1,28 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/micro/v2/service/runtime/limits"
+)
+
+// limitProcess applies the resource limits passed as flags and then execs the command in the args.
+// The runtime manager uses it to start local services with limits.
+func limitProcess(ctx *cli.Context) {
+	l := &limits.Limits{
+		CPU:    ctx.Float64("cpu"),
+		Memory: ctx.Int64("memory"),
+		Disk:   ctx.Int64("disk"),
+	}
+
+	if err := limits.Apply(ctx.String("name"), l); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := limits.Exec(ctx.Args().Slice()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,167 @@
+// Package limits parses the resource limits of runtime services and enforces them on local
+// processes. On kubernetes the limits are set as the resources of the container, the disk limit as
+// its ephemeral storage which covers its logs and writable layer. The cpu and memory of a local
+// service are limited by a cgroup, they aren't enforced where cgroups aren't available. The disk
+// used by a local process can't be limited, so the disk limit of a local service is enforced as the
+// max size of a single file it writes.
+package limits
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CPUKey is the service metadata key for the cpu limit, in cores e.g. 0.5 or 500m
+	CPUKey = "cpu"
+	// MemoryKey is the service metadata key for the memory limit, e.g. 256Mi or 1Gi
+	MemoryKey = "memory"
+	// DiskKey is the service metadata key for the disk limit, e.g. 10Gi
+	DiskKey = "disk"
+)
+
+// Keys are the service metadata keys which hold resource limits
+var Keys = []string{CPUKey, MemoryKey, DiskKey}
+
+// Limits on the resources a service can use, a zero value means unlimited
+type Limits struct {
+	// CPU in cores
+	CPU float64
+	// Memory in bytes
+	Memory int64
+	// Disk in bytes, the max size of a file written by a local service
+	Disk int64
+}
+
+// FromMetadata parses the limits set in the metadata of a service, returning nil if there are none
+func FromMetadata(md map[string]string) (*Limits, error) {
+	var l Limits
+	var err error
+
+	if v := md[CPUKey]; len(v) > 0 {
+		if l.CPU, err = ParseCPU(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := md[MemoryKey]; len(v) > 0 {
+		if l.Memory, err = ParseBytes(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := md[DiskKey]; len(v) > 0 {
+		if l.Disk, err = ParseBytes(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if l == (Limits{}) {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+// ParseCPU parses a number of cores, either as a decimal e.g. 1.5 or in millicores e.g. 1500m
+func ParseCPU(v string) (float64, error) {
+	s := strings.TrimSpace(v)
+	div := 1.0
+	if strings.HasSuffix(s, "m") {
+		s = strings.TrimSuffix(s, "m")
+		div = 1000
+	}
+
+	cpu, err := strconv.ParseFloat(s, 64)
+	if err != nil || cpu <= 0 {
+		return 0, fmt.Errorf("Invalid cpu %v, must be a number of cores e.g. 0.5 or 500m", v)
+	}
+	return cpu / div, nil
+}
+
+// byteUnits are the suffixes accepted by ParseBytes, binary units are checked first
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+	{"Ti", 1 << 40},
+	{"K", 1e3},
+	{"M", 1e6},
+	{"G", 1e9},
+	{"T", 1e12},
+}
+
+// ParseBytes parses a size in bytes with an optional unit, e.g. 512Mi, 1G or 1048576
+func ParseBytes(v string) (int64, error) {
+	s := strings.TrimSpace(v)
+	size := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSuffix(s, u.suffix)
+			size = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("Invalid size %v, must be a number of bytes e.g. 512Mi or 1G", v)
+	}
+	return int64(n * float64(size)), nil
+}
+
+// Args returns the flags for the limit command which enforce the limits
+func (l *Limits) Args() []string {
+	var args []string
+	if l.CPU > 0 {
+		args = append(args, "--cpu", strconv.FormatFloat(l.CPU, 'f', -1, 64))
+	}
+	if l.Memory > 0 {
+		args = append(args, "--memory", strconv.FormatInt(l.Memory, 10))
+	}
+	if l.Disk > 0 {
+		args = append(args, "--disk", strconv.FormatInt(l.Disk, 10))
+	}
+	return args
+}
+
+// Resources returns the limits as kubernetes quantities, e.g. {"cpu": "500m"}, to set as the
+// resources of a container
+func (l *Limits) Resources() map[string]string {
+	resources := make(map[string]string)
+	if l.CPU > 0 {
+		resources["cpu"] = strconv.FormatInt(int64(math.Ceil(l.CPU*1000)), 10) + "m"
+	}
+	if l.Memory > 0 {
+		resources["memory"] = strconv.FormatInt(l.Memory, 10)
+	}
+	if l.Disk > 0 {
+		resources["ephemeral-storage"] = strconv.FormatInt(l.Disk, 10)
+	}
+	return resources
+}
+
+// Command wraps the command of a local service so it's started by the micro binary with the limits
+// applied, e.g. "micro runtime limit --memory 268435456 --name foo -- go run .". The returned
+// command and args replace those passed to the runtime.
+func Command(l *Limits, name string, command, args []string) ([]string, []string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the local runtime runs the service from source if no command is set
+	if len(command) == 0 {
+		command = []string{"go"}
+		args = []string{"run", "."}
+	}
+
+	wrapped := append([]string{"runtime", "limit", "--name", name}, l.Args()...)
+	wrapped = append(wrapped, "--")
+	wrapped = append(wrapped, command...)
+	wrapped = append(wrapped, args...)
+	return []string{exe}, wrapped, nil
+}
@@ -0,0 +1,64 @@
+package limits
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromMetadata(t *testing.T) {
+	tt := []struct {
+		Name   string
+		MD     map[string]string
+		Limits *Limits
+		Error  bool
+	}{
+		{Name: "None", MD: map[string]string{"owner": "john"}},
+		{Name: "Cores", MD: map[string]string{CPUKey: "1.5"}, Limits: &Limits{CPU: 1.5}},
+		{Name: "Millicores", MD: map[string]string{CPUKey: "250m"}, Limits: &Limits{CPU: 0.25}},
+		{Name: "Binary", MD: map[string]string{MemoryKey: "256Mi"}, Limits: &Limits{Memory: 256 << 20}},
+		{Name: "Decimal", MD: map[string]string{DiskKey: "2G"}, Limits: &Limits{Disk: 2e9}},
+		{Name: "Bytes", MD: map[string]string{MemoryKey: "1024", DiskKey: "1Ki"}, Limits: &Limits{Memory: 1024, Disk: 1024}},
+		{Name: "InvalidCPU", MD: map[string]string{CPUKey: "lots"}, Error: true},
+		{Name: "NegativeCPU", MD: map[string]string{CPUKey: "-1"}, Error: true},
+		{Name: "InvalidMemory", MD: map[string]string{MemoryKey: "1Zi"}, Error: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			l, err := FromMetadata(tc.MD)
+			if tc.Error && err == nil {
+				t.Fatalf("Expected an error")
+			} else if !tc.Error && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(l, tc.Limits) {
+				t.Errorf("Expected limits %+v, got %+v", tc.Limits, l)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	l := &Limits{CPU: 0.5, Memory: 1024}
+
+	cmd, args, err := Command(l, "foo", nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cmd) != 1 {
+		t.Fatalf("Expected the command to be the micro binary, got %v", cmd)
+	}
+
+	expected := []string{"runtime", "limit", "--name", "foo", "--cpu", "0.5", "--memory", "1024", "--", "go", "run", "."}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args %v, got %v", expected, args)
+	}
+}
+
+func TestResources(t *testing.T) {
+	l := &Limits{CPU: 0.25, Memory: 256 << 20, Disk: 1024}
+	expected := map[string]string{"cpu": "250m", "memory": "268435456", "ephemeral-storage": "1024"}
+	if r := l.Resources(); !reflect.DeepEqual(r, expected) {
+		t.Errorf("Expected resources %v, got %v", expected, r)
+	}
+}
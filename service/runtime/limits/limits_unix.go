@@ -0,0 +1,86 @@
+//go:build !windows
+// +build !windows
+
+package limits
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// cgroupRoot is where the unified (v2) cgroup hierarchy is mounted
+var cgroupRoot = "/sys/fs/cgroup"
+
+// cpuPeriod is the cgroup cpu period in microseconds, the quota is a fraction of it
+const cpuPeriod = 100000
+
+// Apply the limits to the current process so they're inherited by the processes it starts. The cpu
+// and memory are limited by a cgroup since it limits the whole process tree, they aren't enforced
+// without cgroups: limiting the address space instead stops go binaries starting since they
+// reserve more than they use. The disk used by the process tree can't be limited, so the disk
+// limit is enforced as the max size of a file written by the process.
+func Apply(name string, l *Limits) error {
+	if (l.CPU > 0 || l.Memory > 0) && !applyCgroup(name, l) {
+		fmt.Fprintf(os.Stderr, "Warning: cpu and memory limits not enforced, cgroups are not available\n")
+	}
+	if l.Disk > 0 {
+		lim := &syscall.Rlimit{Cur: uint64(l.Disk), Max: uint64(l.Disk)}
+		if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, lim); err != nil {
+			return fmt.Errorf("Error limiting the file size: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// applyCgroup moves the current process into a cgroup for the service with the cpu and memory
+// limits set, returning false if the cgroup couldn't be created
+func applyCgroup(name string, l *Limits) bool {
+	if l.CPU <= 0 && l.Memory <= 0 {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return false
+	}
+
+	dir := filepath.Join(cgroupRoot, "micro", strings.NewReplacer("/", "-", ":", "-").Replace(name))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false
+	}
+
+	write := func(file, value string) error {
+		return ioutil.WriteFile(filepath.Join(dir, file), []byte(value), 0644)
+	}
+	if l.CPU > 0 {
+		quota := int64(l.CPU * cpuPeriod)
+		if err := write("cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod)); err != nil {
+			return false
+		}
+	}
+	if l.Memory > 0 {
+		if err := write("memory.max", strconv.FormatInt(l.Memory, 10)); err != nil {
+			return false
+		}
+	}
+
+	return write("cgroup.procs", strconv.Itoa(os.Getpid())) == nil
+}
+
+// Exec replaces the current process with the command, the limits applied are inherited
+func Exec(command []string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("No command to exec")
+	}
+
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, command, os.Environ())
+}
@@ -0,0 +1,15 @@
+package limits
+
+import (
+	"fmt"
+)
+
+// Apply the limits to the current process, limits are not supported on windows
+func Apply(name string, l *Limits) error {
+	return fmt.Errorf("Resource limits are not supported on windows")
+}
+
+// Exec replaces the current process with the command, which isn't supported on windows
+func Exec(command []string) error {
+	return fmt.Errorf("Resource limits are not supported on windows")
+}
@@ -0,0 +1,204 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/micro/go-micro/v2/auth"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/micro/v2/internal/namespace"
+)
+
+const (
+	// auditPrefix is prefixed to the key for audit log records
+	auditPrefix = "audit:"
+
+	// AuditSuccess is the outcome of an action which succeeded
+	AuditSuccess = "success"
+	// AuditError is the outcome of an action which failed
+	AuditError = "error"
+	// AuditSkipped is the outcome of an action which was superseded by a newer action on the
+	// service before it was applied
+	AuditSkipped = "skipped"
+)
+
+// AuditEvent is an entry in the audit log, written each time an account takes an action on a
+// service, e.g. create, update, delete, rollback or exec. The removal of the state of a service
+// by garbage collection is written as a gc action.
+type AuditEvent struct {
+	// ID of the event
+	ID string `json:"id"`
+	// Timestamp the action was taken
+	Timestamp time.Time `json:"timestamp"`
+	// Action taken on the service
+	Action string `json:"action"`
+	// Namespace of the service
+	Namespace string `json:"namespace"`
+	// Account which took the action, blank if the request wasn't authenticated
+	Account string `json:"account"`
+	// Service and version the action was taken on
+	Service string `json:"service"`
+	Version string `json:"version"`
+	// Outcome of the action, success or error
+	Outcome string `json:"outcome"`
+	// Error returned if the action failed
+	Error string `json:"error,omitempty"`
+}
+
+// Auditor writes events to the audit log
+type Auditor interface {
+	Audit(ev *AuditEvent) error
+}
+
+// auditKey to write the event to the store under. The events are never updated or expired, the
+// timestamp is padded so the keys of a namespace sort in the order the events were written, e.g:
+// "audit:foo:01593604800000000000:go.micro.service.bar:<id>"
+func auditKey(ev *AuditEvent) string {
+	return fmt.Sprintf("%v%v:%020d:%v:%v", auditPrefix, ev.Namespace, ev.Timestamp.UnixNano(), ev.Service, ev.ID)
+}
+
+// auditRanges returns the prefixes of the keys of the events in the namespace written between the
+// times, to the second. Reading them returns the events in the range without the events before it.
+func auditRanges(ns string, from, to time.Time) []string {
+	prefix := auditPrefix + ns + ":"
+	if from.IsZero() {
+		return []string{prefix}
+	}
+
+	// the keys are prefixed with the padded timestamps in seconds, followed by the nanoseconds
+	var ranges []string
+	for _, r := range digitRanges(fmt.Sprintf("%011d", from.Unix()), fmt.Sprintf("%011d", to.Unix())) {
+		ranges = append(ranges, prefix+r)
+	}
+	return ranges
+}
+
+// digitRanges returns the fewest prefixes of the numbers from a to b, which are padded to the same
+// length, e.g. 0195 to 0219 is 0195, 0196, 0197, 0198, 0199, 020 and 021
+func digitRanges(a, b string) []string {
+	if a >= b {
+		return []string{b}
+	}
+	i := 0
+	for a[i] == b[i] {
+		i++
+	}
+	if strings.Trim(a[i:], "0") == "" && strings.Trim(b[i:], "9") == "" {
+		return []string{a[:i]}
+	}
+
+	// the numbers from a to the end of its digit, the digits between and those of b up to b
+	ranges := digitRanges(a, a[:i+1]+strings.Repeat("9", len(a)-i-1))
+	for d := a[i] + 1; d < b[i]; d++ {
+		ranges = append(ranges, a[:i]+string(d))
+	}
+	return append(ranges, digitRanges(b[:i+1]+strings.Repeat("0", len(b)-i-1), b)...)
+}
+
+// auditService returns the service of the event written under the key
+func auditService(ns, key string) string {
+	parts := strings.SplitN(strings.TrimPrefix(key, auditPrefix+ns+":"), ":", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// requestAudit returns the entry in the audit log of an action requested on a service. The
+// outcome is set once the action is applied.
+func requestAudit(action, ns, account string, srv *runtime.Service) *AuditEvent {
+	return &AuditEvent{Action: action, Namespace: ns, Account: account, Service: srv.Name, Version: srv.Version}
+}
+
+// accountFromContext returns the id of the account which made a request, blank if the request
+// wasn't authenticated
+func accountFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if acc, ok := auth.AccountFromContext(ctx); ok {
+		return acc.ID
+	}
+	return ""
+}
+
+// auditOutcome writes the outcome of the requested action to the audit log
+func (m *manager) auditOutcome(ev *AuditEvent, outcome string, err error) {
+	if ev == nil {
+		return
+	}
+	a := *ev
+	a.ID, a.Timestamp, a.Outcome = "", time.Time{}, outcome
+	if err != nil {
+		a.Outcome, a.Error = AuditError, err.Error()
+	}
+	if err := m.Audit(&a); err != nil {
+		logger.Warnf("Error writing %v of service %v:%v to the audit log: %v", a.Action, a.Service, a.Version, err)
+	}
+}
+
+// Audit appends the event to the audit log
+func (m *manager) Audit(ev *AuditEvent) error {
+	if len(ev.Namespace) == 0 {
+		ev.Namespace = namespace.DefaultNamespace
+	}
+	if len(ev.ID) == 0 {
+		ev.ID = uuid.New().String()
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	bytes, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return m.options.Store.Write(&store.Record{Key: auditKey(ev), Value: bytes})
+}
+
+// AuditLog returns the events in the audit log of the namespace written after the time, oldest
+// first. If a service is passed only its events are returned. The events are keyed by the time
+// they were written so only those since the time are read.
+func (m *manager) AuditLog(ns, service string, since time.Time) ([]*AuditEvent, error) {
+	if len(ns) == 0 {
+		ns = namespace.DefaultNamespace
+	}
+
+	// events written by managers whose clocks are ahead are included
+	var recs []*store.Record
+	for _, prefix := range auditRanges(ns, since, time.Now().Add(time.Minute)) {
+		r, err := m.options.Store.Read(prefix, store.ReadPrefix())
+		if err != nil && err != store.ErrNotFound {
+			return nil, err
+		}
+		recs = append(recs, r...)
+	}
+
+	events := make([]*AuditEvent, 0, len(recs))
+	for _, r := range recs {
+		if len(service) > 0 && auditService(ns, r.Key) != service {
+			continue
+		}
+		var ev *AuditEvent
+		if err := json.Unmarshal(r.Value, &ev); err != nil {
+			return nil, err
+		}
+		if !ev.Timestamp.After(since) {
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	// the store doesn't guarantee any ordering and the events of services are interleaved
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
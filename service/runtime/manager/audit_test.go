@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/auth"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/memory"
+)
+
+func TestAuditLog(t *testing.T) {
+	m := New(&testRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	start := time.Now()
+	events := []*AuditEvent{
+		{Action: "create", Namespace: "foo", Account: "alice", Service: "go.micro.service.foo", Version: "latest", Outcome: AuditSuccess},
+		{Action: "create", Namespace: "foo", Account: "bob", Service: "go.micro.service.foo.bar", Version: "latest", Outcome: AuditSuccess},
+		{Action: "delete", Namespace: "foo", Account: "alice", Service: "go.micro.service.foo", Version: "latest", Outcome: AuditError, Error: "not found"},
+		{Action: "create", Namespace: "bar", Account: "alice", Service: "go.micro.service.foo", Version: "latest", Outcome: AuditSuccess},
+	}
+	for i, ev := range events {
+		ev.Timestamp = start.Add(time.Second * time.Duration(i))
+		if err := m.Audit(ev); err != nil {
+			t.Fatalf("Unexpected error writing to the audit log: %v", err)
+		}
+	}
+
+	tests := []struct {
+		Name      string
+		Namespace string
+		Service   string
+		Since     time.Time
+		Actions   []string
+	}{
+		{"namespace", "foo", "", time.Time{}, []string{"create:alice", "create:bob", "delete:alice"}},
+		{"service", "foo", "go.micro.service.foo", time.Time{}, []string{"create:alice", "delete:alice"}},
+		{"since", "foo", "", start, []string{"create:bob", "delete:alice"}},
+		{"other namespace", "bar", "", time.Time{}, []string{"create:alice"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			evs, err := m.AuditLog(tc.Namespace, tc.Service, tc.Since)
+			if err != nil {
+				t.Fatalf("Unexpected error reading the audit log: %v", err)
+			}
+			var actions []string
+			for _, ev := range evs {
+				actions = append(actions, ev.Action+":"+ev.Account)
+			}
+			if fmt.Sprint(actions) != fmt.Sprint(tc.Actions) {
+				t.Errorf("Expected events %v, got %v", tc.Actions, actions)
+			}
+		})
+	}
+}
+
+func TestAuditRanges(t *testing.T) {
+	if r := digitRanges("0195", "0213"); fmt.Sprint(r) != "[0195 0196 0197 0198 0199 020 0210 0211 0212 0213]" {
+		t.Errorf("Unexpected ranges from 0195 to 0213: %v", r)
+	}
+	if r := digitRanges("0999", "1000"); fmt.Sprint(r) != "[0999 1000]" {
+		t.Errorf("Unexpected ranges from 0999 to 1000: %v", r)
+	}
+	if r := digitRanges("1000", "1999"); fmt.Sprint(r) != "[1]" {
+		t.Errorf("Unexpected ranges from 1000 to 1999: %v", r)
+	}
+
+	now := time.Now()
+	ranges := auditRanges("foo", now.Add(-time.Second*12), now)
+	inRange := func(ev *AuditEvent) bool {
+		for _, r := range ranges {
+			if strings.HasPrefix(auditKey(ev), r) {
+				return true
+			}
+		}
+		return false
+	}
+	ev := &AuditEvent{Namespace: "foo", Service: "go.micro.service.foo", ID: "1"}
+	for _, d := range []time.Duration{-time.Second * 12, -time.Second * 5, 0} {
+		if ev.Timestamp = now.Add(d); !inRange(ev) {
+			t.Errorf("Expected the key of the event %v ago to be in the ranges %v", -d, ranges)
+		}
+	}
+	if ev.Timestamp = now.Add(-time.Second * 14); inRange(ev) {
+		t.Errorf("Expected the key of the event before the range not to be in the ranges %v", ranges)
+	}
+	if auditService("foo", auditKey(ev)) != ev.Service {
+		t.Errorf("Expected the service to be parsed from the key")
+	}
+}
+
+func TestAuditApplied(t *testing.T) {
+	rt := &testRuntime{}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	// the outcome is only written once the event is applied
+	ctx := auth.ContextWithAccount(context.Background(), &auth.Account{ID: "alice"})
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest"}
+	if err := m.Create(srv, runtime.CreateNamespace("foo"), runtime.CreateContext(ctx)); err != nil {
+		t.Fatalf("Unexpected error creating the service: %v", err)
+	}
+	if evs, _ := m.AuditLog("foo", "", time.Time{}); len(evs) != 0 {
+		t.Fatalf("Expected nothing in the audit log before the event is applied, got %v events", len(evs))
+	}
+
+	recs, err := m.options.Store.Read(eventPrefix, store.ReadPrefix())
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("Expected the event to be published, got %v %v", recs, err)
+	}
+	var ev *event
+	if err := json.Unmarshal(recs[0].Value, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Options.Context != nil {
+		t.Errorf("Expected the context of the request not to be kept with the event")
+	}
+	m.processEvent(ev)
+
+	evs, err := m.AuditLog("foo", srv.Name, time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error reading the audit log: %v", err)
+	}
+	if len(evs) != 1 || evs[0].Action != "create" || evs[0].Account != "alice" || evs[0].Outcome != AuditSuccess {
+		t.Errorf("Expected the applied create in the audit log, got %+v", evs)
+	}
+}
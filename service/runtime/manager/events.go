@@ -2,10 +2,13 @@ package manager
 
 import (
 	"encoding/json"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/micro/go-micro/v2/broker"
 	"github.com/micro/go-micro/v2/logger"
 	"github.com/micro/go-micro/v2/runtime"
 	"github.com/micro/go-micro/v2/store"
@@ -15,24 +18,43 @@ import (
 var (
 	// eventTTL is the duration events will perist in the store before expiring
 	eventTTL = time.Minute * 10
-	// eventPollFrequency is the max frequency the manager will check for new events in the store
+	// eventPollFrequency is the max frequency the manager will check the store for events which
+	// were missed on the broker, e.g. whilst the manager was offline
 	eventPollFrequency = time.Minute
+	// eventQueueIdle is the duration a service's event queue can be idle before it is released
+	eventQueueIdle = time.Minute
 )
 
 const (
+	// eventTopic is the broker topic events are published to
+	eventTopic = "go.micro.runtime.manager.events"
 	// eventPrefix is prefixed to the key for event records
 	eventPrefix = "event/"
 	// eventProcessedPrefix is prefixed to the key for tracking event processing
 	eventProcessedPrefix = "processed/"
+	// eventAppliedPrefix is prefixed to the key for tracking the last event applied to a service
+	eventAppliedPrefix = "applied/"
 )
 
-// publishEvent will write the event to the global store and immediately process the event
-func (m *manager) publishEvent(eType runtime.EventType, srv *runtime.Service, opts *runtime.CreateOptions) error {
-	e := &runtime.Event{
-		ID:      uuid.New().String(),
-		Type:    eType,
-		Service: srv,
-		Options: opts,
+// event is a runtime event and the entry in the audit log of the action which published it, if
+// it was requested. Each manager writes the outcome of applying the event to the audit log.
+type event struct {
+	*runtime.Event
+	Audit *AuditEvent `json:"audit,omitempty"`
+}
+
+// publishEvent will write the event to the global store, which acts as a log for managers to catch
+// up from, and then publish it over the broker so every manager can process it immediately
+func (m *manager) publishEvent(eType runtime.EventType, srv *runtime.Service, opts *runtime.CreateOptions, audit *AuditEvent) error {
+	e := &event{
+		Event: &runtime.Event{
+			ID:        uuid.New().String(),
+			Type:      eType,
+			Timestamp: time.Now(),
+			Service:   srv,
+			Options:   opts,
+		},
+		Audit: audit,
 	}
 
 	bytes, err := json.Marshal(e)
@@ -45,82 +67,201 @@ func (m *manager) publishEvent(eType runtime.EventType, srv *runtime.Service, op
 		Value:  bytes,
 		Expiry: eventTTL,
 	}
-
 	if err := m.options.Store.Write(record); err != nil {
 		return err
 	}
 
-	go m.processEvent(record.Key)
+	// if the event can't be published, apply it to the managed runtime directly. Other managers will
+	// pick it up from the store next time they poll.
+	msg := &broker.Message{
+		Header: map[string]string{"id": e.ID, "type": e.Type.String()},
+		Body:   bytes,
+	}
+	if err := m.options.Broker.Publish(eventTopic, msg); err != nil {
+		logger.Warnf("Error publishing event %v: %v", e.ID, err)
+		m.queueEvent(e)
+	}
+
+	return nil
+}
+
+// subscribeEvents subscribes to the events published over the broker. Every manager needs to
+// process every event so no queue is used.
+func (m *manager) subscribeEvents() error {
+	sub, err := m.options.Broker.Subscribe(eventTopic, func(p broker.Event) error {
+		var ev *event
+		if err := json.Unmarshal(p.Message().Body, &ev); err != nil {
+			logger.Warnf("Error unmarshaling event: %v", err)
+			return err
+		}
+		m.queueEvent(ev)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	m.subscriber = sub
+	m.Unlock()
 	return nil
 }
 
 // watchEvents polls the store for events periodically and processes them if they have not already
-// done so
+// done so. Events are normally delivered by the broker, this catches up on anything missed.
 func (m *manager) watchEvents() {
 	ticker := time.NewTicker(eventPollFrequency)
 
 	for {
 		// get the keys of the events
-		events, err := m.options.Store.Read(eventPrefix, store.ReadPrefix())
+		recs, err := m.options.Store.Read(eventPrefix, store.ReadPrefix())
 		if err != nil {
 			logger.Warn("Error listing events: %v", err)
-			continue
 		}
 
-		// loop through every event
+		events := make([]*event, 0, len(recs))
+		for _, rec := range recs {
+			var ev *event
+			if err := json.Unmarshal(rec.Value, &ev); err != nil {
+				logger.Warnf("Error unmarshaling event %v: %v", rec.Key, err)
+				continue
+			}
+			events = append(events, ev)
+		}
+
+		// the store doesn't guarantee any ordering so queue the events in the order they were
+		// published
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].Timestamp.Before(events[j].Timestamp)
+		})
 		for _, ev := range events {
-			logger.Debugf("Process Event: %v", ev.Key)
-			m.processEvent(ev.Key)
+			m.queueEvent(ev)
 		}
 
 		<-ticker.C
 	}
 }
 
-// processEvent will take an event key, verify it hasn't been consumed and then execute it. We pass
-// the key and not the ID since the global store and the memory store use the same key prefix so there
-// is not point stripping and then re-prefixing.
-func (m *manager) processEvent(key string) {
-	// check to see if the event has been processed before
-	if _, err := m.fileCache.Read(eventProcessedPrefix + key); err != store.ErrNotFound {
+// eventQueue is the queue of events for a service
+type eventQueue struct {
+	events chan *event
+	// pending is the number of events being sent to the queue, it isn't released until they're sent
+	pending int32
+}
+
+// queueEvent adds the event to the queue for the service it relates to, starting a go routine to
+// process the queue if one isn't already running. Events for a service are processed one at a time
+// in the order they're queued. The event is sent once the lock is released so a full queue only
+// blocks the events of its service.
+func (m *manager) queueEvent(ev *event) {
+	if ev.Event == nil || ev.Service == nil {
+		logger.Warnf("Event %v is missing a service", ev.ID)
 		return
 	}
 
-	// lookup the event
-	recs, err := m.options.Store.Read(key)
-	if err != nil {
-		logger.Warnf("Error finding event %v: %v", key, err)
+	// check to see if the event has been processed before
+	if m.eventProcessed(ev.Event) {
 		return
 	}
-	var ev *runtime.Event
-	if err := json.Unmarshal(recs[0].Value, &ev); err != nil {
-		logger.Warnf("Error unmarshaling event %v: %v", key, err)
+
+	key := eventNamespace(ev.Event) + ":" + ev.Service.Name + ":" + ev.Service.Version
+
+	m.Lock()
+	queue, ok := m.queues[key]
+	if !ok {
+		queue = &eventQueue{events: make(chan *event, 64)}
+		m.queues[key] = queue
+		go m.processQueue(key, queue)
+	}
+	atomic.AddInt32(&queue.pending, 1)
+	m.Unlock()
+
+	queue.events <- ev
+	atomic.AddInt32(&queue.pending, -1)
+}
+
+// processQueue processes the events for a single service until the queue has been idle for
+// eventQueueIdle, at which point it is released
+func (m *manager) processQueue(key string, queue *eventQueue) {
+	idle := time.NewTimer(eventQueueIdle)
+	defer idle.Stop()
+
+	for {
+		select {
+		case ev := <-queue.events:
+			m.processEvent(ev)
+			idle.Reset(eventQueueIdle)
+		case <-idle.C:
+			m.Lock()
+			// an event may have been queued, or be about to be, whilst we were waiting for the lock
+			if len(queue.events) > 0 || atomic.LoadInt32(&queue.pending) > 0 {
+				m.Unlock()
+				idle.Reset(eventQueueIdle)
+				continue
+			}
+			delete(m.queues, key)
+			m.Unlock()
+			return
+		}
+	}
+}
+
+// processEvent will take an event, verify it hasn't been consumed or superseded by a newer event
+// for the same service and then execute it.
+func (m *manager) processEvent(ev *event) {
+	// the event could have been queued more than once, e.g. by the broker and the poller
+	if m.eventProcessed(ev.Event) {
+		return
 	}
 
 	// determine the namespace
-	ns := namespace.DefaultNamespace
-	if ev.Options != nil && len(ev.Options.Namespace) > 0 {
-		ns = ev.Options.Namespace
+	ns := eventNamespace(ev.Event)
+
+	// skip the event if a newer event has already been applied to the service
+	if last := m.lastApplied(ns, ev.Service); ev.Timestamp.Before(last) {
+		logger.Infof("Skipping %v event for service %v:%v in namespace %v, superseded by a newer event", ev.Type, ev.Service.Name, ev.Service.Version, ns)
+		m.auditOutcome(ev.Audit, AuditSkipped, nil)
+		m.markProcessed(ev.Event)
+		return
 	}
 
 	// log the event
 	logger.Infof("Processing %v event for service %v:%v in namespace %v", ev.Type, ev.Service.Name, ev.Service.Version, ns)
 
+	// the service is being recreated or removed so its probes start afresh, unless it's being
+	// restarted by a failed probe
+	if ev.Type != runtime.Update && !strings.HasPrefix(ev.ID, probeRestartPrefix) {
+		m.resetProbeStatus(ns, ev.Service)
+	}
+
+	// jobs aren't created in the runtime, their runs are when they're due
+	if isJob(ev.Options) {
+		if ev.Type == runtime.Delete {
+			if err := m.deleteJob(ns, ev.Service); err != nil {
+				logger.Warnf("Error deleting the runs of job %v:%v in namespace %v: %v", ev.Service.Name, ev.Service.Version, ns, err)
+			}
+		}
+		m.auditOutcome(ev.Audit, AuditSuccess, nil)
+		m.markApplied(ns, ev.Event)
+		m.markProcessed(ev.Event)
+		m.notifyStatus(ns)
+		return
+	}
+
 	// apply the event to the managed runtime
+	var err error
 	switch ev.Type {
 	case runtime.Delete:
 		err = m.Runtime.Delete(ev.Service, runtime.DeleteNamespace(ns))
 	case runtime.Update:
 		err = m.Runtime.Update(ev.Service, runtime.UpdateNamespace(ns))
 	case runtime.Create:
-		err = m.Runtime.Create(ev.Service,
-			runtime.CreateImage(ev.Options.Image),
-			runtime.CreateType(ev.Options.Type),
-			runtime.CreateNamespace(ns),
-			runtime.WithArgs(ev.Options.Args...),
-			runtime.WithCommand(ev.Options.Command...),
-			runtime.WithEnv(m.runtimeEnv(ev.Options)),
-		)
+		err = m.runtimeCreate(ns, ev.Service, ev.Options)
+	}
+
+	// apply the event to the replicas of the service
+	if err == nil {
+		err = m.processReplicas(ns, ev.Event)
 	}
 
 	// if there was an error update the status in the cache
@@ -132,10 +273,77 @@ func (m *manager) processEvent(key string) {
 		m.cacheStatus(ns, ev.Service)
 	}
 
-	// write to the store indicating the event has been consumed. We double the ttl to safely know the
-	// event will expire before this record
-	m.fileCache.Write(&store.Record{Key: eventProcessedPrefix + key, Expiry: eventTTL * 2})
+	m.auditOutcome(ev.Audit, AuditSuccess, err)
+	m.markApplied(ns, ev.Event)
+	m.markProcessed(ev.Event)
+	m.notifyStatus(ns)
+}
 
+// processReplicas applies the event to the replicas of the service, creating them if the service
+// has more than one replica
+func (m *manager) processReplicas(ns string, ev *runtime.Event) error {
+	switch ev.Type {
+	case runtime.Delete:
+		return m.scaleReplicas(ns, ev.Service, ev.Options, 0)
+	case runtime.Update:
+		return m.updateReplicas(ns, ev.Service)
+	case runtime.Create:
+		n, err := parseReplicas(ev.Service.Metadata)
+		if err != nil || n < 2 {
+			return err
+		}
+		return m.scaleReplicas(ns, ev.Service, ev.Options, n)
+	}
+	return nil
+}
+
+// eventProcessed returns true if the event has already been processed by this manager
+func (m *manager) eventProcessed(ev *runtime.Event) bool {
+	_, err := m.fileCache.Read(eventProcessedPrefix + eventPrefix + ev.ID)
+	return err != store.ErrNotFound
+}
+
+// markProcessed writes to the store indicating the event has been consumed. We double the ttl to
+// safely know the event will expire before this record
+func (m *manager) markProcessed(ev *runtime.Event) {
+	m.fileCache.Write(&store.Record{Key: eventProcessedPrefix + eventPrefix + ev.ID, Expiry: eventTTL * 2})
+}
+
+// lastApplied returns the timestamp of the last event applied to the service
+func (m *manager) lastApplied(ns string, srv *runtime.Service) time.Time {
+	recs, err := m.fileCache.Read(eventAppliedPrefix + ns + ":" + srv.Name + ":" + srv.Version)
+	if err != nil || len(recs) == 0 {
+		return time.Time{}
+	}
+
+	var t time.Time
+	if err := t.UnmarshalText(recs[0].Value); err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// markApplied records the timestamp of the event as the last one applied to the service. The record
+// only needs to outlive the events which could be processed after it.
+func (m *manager) markApplied(ns string, ev *runtime.Event) {
+	bytes, err := ev.Timestamp.MarshalText()
+	if err != nil {
+		return
+	}
+
+	m.fileCache.Write(&store.Record{
+		Key:    eventAppliedPrefix + ns + ":" + ev.Service.Name + ":" + ev.Service.Version,
+		Value:  bytes,
+		Expiry: eventTTL * 2,
+	})
+}
+
+// eventNamespace returns the namespace the event should be applied in
+func eventNamespace(ev *runtime.Event) string {
+	if ev.Options != nil && len(ev.Options.Namespace) > 0 {
+		return ev.Options.Namespace
+	}
+	return namespace.DefaultNamespace
 }
 
 // runtimeEnv returns the environment variables which should  be used when creating a service.
@@ -1,9 +1,12 @@
 package manager
 
 import (
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/micro/go-micro/v2/broker"
+	memBroker "github.com/micro/go-micro/v2/broker/memory"
 	"github.com/micro/go-micro/v2/runtime"
 	"github.com/micro/go-micro/v2/store/memory"
 	"github.com/micro/micro/v2/internal/namespace"
@@ -15,9 +18,13 @@ func TestEvents(t *testing.T) {
 	eventChan := make(chan *runtime.Service)
 
 	rt := &testRuntime{events: eventChan}
-	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore())).(*manager)
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+	if err := m.subscribeEvents(); err != nil {
+		t.Fatalf("Unexpected error when subscribing to events: %v", err)
+	}
 
-	// set the eventPollFrequency to 10ms so events are processed immediately
+	// set the eventPollFrequency to 10ms so the poller races the broker, events should still only
+	// be processed once
 	eventPollFrequency = time.Millisecond * 10
 	go m.watchEvents()
 
@@ -31,7 +38,7 @@ func TestEvents(t *testing.T) {
 	t.Run("Create", func(t *testing.T) {
 		defer rt.Reset()
 
-		if err := m.publishEvent(runtime.Create, testSrv, opts); err != nil {
+		if err := m.publishEvent(runtime.Create, testSrv, opts, nil); err != nil {
 			t.Errorf("Unexpected error when publishing events: %v", err)
 		}
 
@@ -54,7 +61,7 @@ func TestEvents(t *testing.T) {
 	t.Run("Update", func(t *testing.T) {
 		defer rt.Reset()
 
-		if err := m.publishEvent(runtime.Update, testSrv, opts); err != nil {
+		if err := m.publishEvent(runtime.Update, testSrv, opts, nil); err != nil {
 			t.Errorf("Unexpected error when publishing events: %v", err)
 		}
 
@@ -77,7 +84,7 @@ func TestEvents(t *testing.T) {
 	t.Run("Delete", func(t *testing.T) {
 		defer rt.Reset()
 
-		if err := m.publishEvent(runtime.Delete, testSrv, opts); err != nil {
+		if err := m.publishEvent(runtime.Delete, testSrv, opts, nil); err != nil {
 			t.Errorf("Unexpected error when publishing events: %v", err)
 		}
 
@@ -97,3 +104,176 @@ func TestEvents(t *testing.T) {
 		}
 	})
 }
+
+// newTestBroker returns a connected memory broker
+func newTestBroker(t *testing.T) broker.Broker {
+	b := memBroker.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatalf("Unexpected error when connecting to the broker: %v", err)
+	}
+	return b
+}
+
+// orderedRuntime records the events applied to it in the order they were applied
+type orderedRuntime struct {
+	events chan string
+	runtime.Runtime
+}
+
+func (r *orderedRuntime) Create(srv *runtime.Service, opts ...runtime.CreateOption) error {
+	r.events <- "create:" + srv.Name
+	return nil
+}
+
+func (r *orderedRuntime) Update(srv *runtime.Service, opts ...runtime.UpdateOption) error {
+	r.events <- "update:" + srv.Name
+	return nil
+}
+
+func (r *orderedRuntime) Delete(srv *runtime.Service, opts ...runtime.DeleteOption) error {
+	r.events <- "delete:" + srv.Name
+	return nil
+}
+
+func (r *orderedRuntime) Read(opts ...runtime.ReadOption) ([]*runtime.Service, error) {
+	return nil, nil
+}
+
+func (r *orderedRuntime) String() string {
+	return "test"
+}
+
+func TestEventsBroker(t *testing.T) {
+	// the store would only be polled once a minute, so any events processed within the test must
+	// have been delivered by the broker
+	eventPollFrequency = time.Minute
+
+	// both managers share the global store and the broker, but have their own runtime and caches
+	str := memory.NewStore()
+	brk := newTestBroker(t)
+
+	var runtimes []*orderedRuntime
+	var managers []*manager
+	for i := 0; i < 2; i++ {
+		rt := &orderedRuntime{events: make(chan string, 10)}
+		m := New(rt, Store(str), CacheStore(memory.NewStore()), Broker(brk)).(*manager)
+		if err := m.subscribeEvents(); err != nil {
+			t.Fatalf("Unexpected error when subscribing to events: %v", err)
+		}
+		runtimes = append(runtimes, rt)
+		managers = append(managers, m)
+	}
+
+	// expect waits for the runtime to be passed the events in the order provided
+	expect := func(t *testing.T, rt *orderedRuntime, events ...string) {
+		timeout := time.NewTimer(time.Millisecond * 50)
+		defer timeout.Stop()
+
+		for _, e := range events {
+			select {
+			case got := <-rt.events:
+				if got != e {
+					t.Errorf("Expected event %v, got %v", e, got)
+				}
+			case <-timeout.C:
+				t.Fatalf("Timed out waiting for event %v", e)
+			}
+		}
+
+		// no more events should be applied
+		select {
+		case got := <-rt.events:
+			t.Errorf("Unexpected event %v", got)
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+
+	testSrv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest"}
+	opts := &runtime.CreateOptions{Namespace: namespace.DefaultNamespace}
+
+	// an event published by one manager should be applied by both
+	t.Run("Converge", func(t *testing.T) {
+		if err := managers[0].publishEvent(runtime.Create, testSrv, opts, nil); err != nil {
+			t.Fatalf("Unexpected error when publishing events: %v", err)
+		}
+		for _, rt := range runtimes {
+			expect(t, rt, "create:"+testSrv.Name)
+		}
+	})
+
+	// events for a service should be applied in the order they were published, regardless of which
+	// manager published them
+	t.Run("Ordered", func(t *testing.T) {
+		if err := managers[0].publishEvent(runtime.Update, testSrv, opts, nil); err != nil {
+			t.Fatalf("Unexpected error when publishing events: %v", err)
+		}
+		if err := managers[1].publishEvent(runtime.Delete, testSrv, opts, nil); err != nil {
+			t.Fatalf("Unexpected error when publishing events: %v", err)
+		}
+		for _, rt := range runtimes {
+			expect(t, rt, "update:"+testSrv.Name, "delete:"+testSrv.Name)
+		}
+	})
+
+	// redelivering events, e.g. when catching up from the store, should not apply them again
+	t.Run("Idempotent", func(t *testing.T) {
+		for _, m := range managers {
+			go m.watchEvents()
+		}
+		for _, rt := range runtimes {
+			expect(t, rt)
+		}
+	})
+}
+
+func TestEventsQueueFull(t *testing.T) {
+	// the runtime blocks until its events are read, so the queue of the service fills up
+	rt := &orderedRuntime{events: make(chan string)}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	newEvent := func(name string, i int) *event {
+		return &event{Event: &runtime.Event{
+			ID:        name + "-" + strconv.Itoa(i),
+			Type:      runtime.Create,
+			Timestamp: time.Now(),
+			Service:   &runtime.Service{Name: name, Version: "latest"},
+			Options:   &runtime.CreateOptions{Namespace: namespace.DefaultNamespace},
+		}}
+	}
+
+	// one event is being processed, the queue is full and the last is waiting to be queued
+	foo := make(chan struct{})
+	go func() {
+		defer close(foo)
+		for i := 0; i < 66; i++ {
+			m.queueEvent(newEvent("go.micro.service.foo", i))
+		}
+	}()
+	select {
+	case <-foo:
+		t.Fatalf("Expected the queue of the service to be full")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	// the events of other services are still queued
+	bar := make(chan struct{})
+	go func() {
+		defer close(bar)
+		m.queueEvent(newEvent("go.micro.service.bar", 0))
+	}()
+	select {
+	case <-bar:
+	case <-time.After(time.Millisecond * 500):
+		t.Fatalf("Expected the event of another service to be queued while a queue is full")
+	}
+
+	// the queued events are processed once the runtime is unblocked
+	for i := 0; i < 67; i++ {
+		select {
+		case <-rt.events:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected every queued event to be processed, got %v", i)
+		}
+	}
+	<-foo
+}
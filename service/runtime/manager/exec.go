@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/micro/v2/service/runtime/exec"
+	"github.com/micro/micro/v2/service/runtime/limits"
+)
+
+// Exec runs a command in a running service, returning its exit code. Local services run the command
+// in the sandbox of their process and kubernetes services in one of their pods.
+func (m *manager) Exec(ns string, srv *runtime.Service, command []string, opts exec.Options) (int, error) {
+	srvs, err := m.readServices(ns, srv)
+	if err != nil {
+		return 0, err
+	}
+
+	var s *service
+	for _, r := range srvs {
+		if r.Service.Name == srv.Name && r.Service.Version == srv.Version {
+			s = r
+		}
+	}
+	if s == nil {
+		return 0, fmt.Errorf("Service %v:%v not found", srv.Name, srv.Version)
+	}
+	if isJob(s.Options) {
+		return 0, fmt.Errorf("Service %v:%v is a job, commands can only be run in long running services", srv.Name, srv.Version)
+	}
+
+	return m.execService(ns, s, command, opts)
+}
+
+// execService runs a command in the service using the executor of the managed runtime
+func (m *manager) execService(ns string, s *service, command []string, opts exec.Options) (int, error) {
+	e, err := exec.New(m.Runtime)
+	if err != nil {
+		return 0, err
+	}
+
+	opts.Namespace = ns
+	if l, err := limits.FromMetadata(s.Service.Metadata); err == nil && m.Runtime.String() == "local" {
+		opts.Limits = l
+	}
+	return e.Exec(s.Service, command, opts)
+}
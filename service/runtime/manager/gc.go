@@ -0,0 +1,328 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/util/kubernetes/client"
+	"github.com/micro/micro/v2/internal/namespace"
+	"github.com/micro/micro/v2/service/runtime/build"
+	"github.com/micro/micro/v2/service/runtime/upload"
+)
+
+const (
+	// OrphanRecord is a record in the store of a service which no longer exists
+	OrphanRecord = "record"
+	// OrphanStatus is a cached status or probe result of a service which no longer exists
+	OrphanStatus = "status"
+	// OrphanProcess is a service running in the runtime which no longer exists
+	OrphanProcess = "process"
+	// OrphanSource is an uploaded source which isn't used by any service
+	OrphanSource = "source"
+	// OrphanBuild is a cached build which no service has been started from recently
+	OrphanBuild = "build"
+)
+
+var (
+	// gcFrequency is how often the manager removes orphaned state
+	gcFrequency = time.Hour
+	// gcSourceAge is the age uploaded sources must reach before they can be removed, they may
+	// have just been uploaded for a service which is about to be created
+	gcSourceAge = time.Hour * 24
+	// gcBuildAge is how long a cached build is kept after a service was last started from it
+	gcBuildAge = time.Hour * 24 * 7
+)
+
+// Orphan is runtime state left behind by a service which no longer exists
+type Orphan struct {
+	// Kind of state, e.g. record, status, process or source
+	Kind string
+	// Namespace, Service and Version the state belonged to, blank for sources and builds
+	Namespace string
+	Service   string
+	Version   string
+	// Key of the record or status, or the path of the source or build
+	Key string
+}
+
+// GC removes the orphaned state of services which no longer exist. If dry run is true the orphans
+// are returned without being removed. The removal of the state of each service is written to the
+// audit log with the account which collected the garbage, blank for the periodic collection.
+func (m *manager) GC(dryRun bool, account string) ([]*Orphan, error) {
+	orphans, err := m.findOrphans()
+	if err != nil || dryRun {
+		return orphans, err
+	}
+
+	// the outcome of removing the state of each service, keyed by namespace:name:version
+	audits := make(map[string]*AuditEvent)
+	for _, o := range orphans {
+		var err error
+		switch o.Kind {
+		case OrphanRecord:
+			err = m.options.Store.Delete(o.Key)
+		case OrphanStatus:
+			err = m.cache.Delete(o.Key)
+		case OrphanProcess:
+			err = m.Runtime.Delete(&runtime.Service{Name: o.Service, Version: o.Version}, runtime.DeleteNamespace(o.Namespace))
+		case OrphanSource:
+			err = os.Remove(o.Key)
+		case OrphanBuild:
+			err = os.RemoveAll(o.Key)
+		}
+		if err != nil && err != store.ErrNotFound && !os.IsNotExist(err) {
+			logger.Warnf("Error removing orphaned %v %v: %v", o.Kind, o.Key, err)
+		} else {
+			err = nil
+		}
+
+		// sources and builds aren't owned by a service
+		if len(o.Service) == 0 {
+			continue
+		}
+		key := o.Namespace + ":" + o.Service + ":" + o.Version
+		ev, ok := audits[key]
+		if !ok {
+			ev = &AuditEvent{Action: "gc", Namespace: o.Namespace, Account: account, Service: o.Service, Version: o.Version, Outcome: AuditSuccess}
+			audits[key] = ev
+		}
+		if err != nil {
+			ev.Outcome = AuditError
+			ev.Error = err.Error()
+		}
+	}
+
+	for _, ev := range audits {
+		if err := m.Audit(ev); err != nil {
+			logger.Warnf("Error writing the removal of the state of service %v:%v to the audit log: %v", ev.Service, ev.Version, err)
+		}
+	}
+	return orphans, nil
+}
+
+// watchGC removes orphaned state periodically and should be run in a separate go routine. The
+// first run waits a period so the services are resurrected and their statuses synced.
+func (m *manager) watchGC() {
+	ticker := time.NewTicker(gcFrequency)
+
+	for {
+		<-ticker.C
+
+		orphans, err := m.GC(false, "")
+		if err != nil {
+			logger.Warnf("Error removing orphaned state: %v", err)
+		} else if len(orphans) > 0 {
+			logger.Infof("Removed %v orphaned records, statuses, processes, sources and builds", len(orphans))
+		}
+	}
+}
+
+// findOrphans returns the state of services which aren't in the store, or whose namespace has
+// been deleted
+func (m *manager) findOrphans() ([]*Orphan, error) {
+	recs, err := m.options.Store.Read(servicePrefix, store.ReadPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	// whether the namespaces exist, they're checked once each
+	namespaces := make(map[string]bool)
+	exists := func(ns string) (bool, error) {
+		if ok, checked := namespaces[ns]; checked {
+			return ok, nil
+		}
+		ok, err := m.namespaceExists(ns)
+		if err != nil {
+			return false, fmt.Errorf("Error checking namespace %v exists: %v", ns, err)
+		}
+		namespaces[ns] = ok
+		return ok, nil
+	}
+
+	// the services of deleted namespaces are orphaned along with their records
+	var orphans []*Orphan
+	services := make(map[string]bool, len(recs))
+	for _, r := range recs {
+		ns, name, version, ok := parseKey(r.Key, servicePrefix)
+		if !ok {
+			continue
+		}
+		if ok, err := exists(ns); err != nil {
+			return nil, err
+		} else if !ok {
+			orphans = append(orphans, &Orphan{Kind: OrphanRecord, Namespace: ns, Service: name, Version: version, Key: r.Key})
+			continue
+		}
+		services[ns+":"+name+":"+version] = true
+	}
+	owned := func(ns, name, version string) bool {
+		// the replicas of services and runs of jobs are created with suffixed versions
+		return services[ns+":"+name+":"+version] || services[ns+":"+name+":"+baseVersion(version)]
+	}
+
+	// the records of services kept in the store and the cache, the audit log is never removed
+	stores := []struct {
+		store    store.Store
+		kind     string
+		prefixes []string
+	}{
+		{m.options.Store, OrphanRecord, []string{revisionPrefix, rolloutPrefix, jobPrefix, jobLeasePrefix, runPrefix, runLogsPrefix, auditPrefix, probePrefix}},
+		{m.cache, OrphanStatus, []string{statusPrefix}},
+	}
+	for _, s := range stores {
+		for _, prefix := range s.prefixes {
+			keys, err := s.store.List(store.ListPrefix(prefix))
+			if err != nil {
+				return nil, err
+			}
+			for _, k := range keys {
+				ns, name, version, ok := parseKey(k, prefix)
+				if !ok {
+					continue
+				}
+				if _, err := exists(ns); err != nil {
+					return nil, err
+				}
+				if prefix == auditPrefix || owned(ns, name, version) {
+					continue
+				}
+				orphans = append(orphans, &Orphan{Kind: s.kind, Namespace: ns, Service: name, Version: version, Key: k})
+			}
+		}
+	}
+
+	// services running in the runtime are only removed if the audit log shows they were created
+	// by a manager, other services may be running in the same runtime. The namespaces of deleted
+	// services are found from their records, including the audit log. The services of a deleted
+	// namespace were removed with it.
+	for ns, ok := range namespaces {
+		if !ok {
+			continue
+		}
+		srvs, err := m.Runtime.Read(runtime.ReadNamespace(ns))
+		if err != nil {
+			return nil, err
+		}
+		keys, err := m.options.Store.List(store.ListPrefix(auditPrefix + ns + ":"))
+		if err != nil {
+			return nil, err
+		}
+		audited := make(map[string]bool, len(keys))
+		for _, k := range keys {
+			audited[auditService(ns, k)] = true
+		}
+		for _, srv := range srvs {
+			if owned(ns, srv.Name, srv.Version) || !audited[srv.Name] {
+				continue
+			}
+			orphans = append(orphans, &Orphan{
+				Kind:      OrphanProcess,
+				Namespace: ns,
+				Service:   srv.Name,
+				Version:   srv.Version,
+				Key:       ns + ":" + srv.Name + ":" + srv.Version,
+			})
+		}
+	}
+
+	// builds are cached by the checksum of the source so they aren't owned by a service, they're
+	// removed once no service has been started from them for a while
+	if len(m.options.BuildDir) > 0 {
+		paths, err := build.NewCache(m.options.BuildDir).Unused(gcBuildAge)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			orphans = append(orphans, &Orphan{Kind: OrphanBuild, Key: p})
+		}
+	}
+
+	// uploaded sources are in use if any service or revision which could be rolled back to uses
+	// them, the revisions of services which no longer exist are being removed
+	if len(m.options.UploadDir) == 0 {
+		return orphans, nil
+	}
+	revs, err := m.options.Store.Read(revisionPrefix, store.ReadPrefix())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range revs {
+		if ns, name, version, ok := parseKey(r.Key, revisionPrefix); ok && owned(ns, name, version) {
+			recs = append(recs, r)
+		}
+	}
+	inUse := make(map[string]bool)
+	for _, r := range recs {
+		// services and revisions are both written with the service under the same key
+		var v struct {
+			Service *runtime.Service `json:"service"`
+		}
+		if err := json.Unmarshal(r.Value, &v); err != nil || v.Service == nil {
+			continue
+		}
+		inUse[filepath.Base(v.Service.Source)] = true
+	}
+	paths, err := upload.Unused(m.options.UploadDir, inUse, gcSourceAge)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		orphans = append(orphans, &Orphan{Kind: OrphanSource, Key: p})
+	}
+
+	return orphans, nil
+}
+
+// namespaceExists returns false if the namespace has been deleted. The kubernetes runtime runs
+// the services of each namespace in a kubernetes namespace, which is deleted with them. The
+// namespaces of other runtimes only exist as the records of their services.
+func (m *manager) namespaceExists(ns string) (bool, error) {
+	if m.Runtime.String() != "kubernetes" || ns == namespace.DefaultNamespace {
+		return true, nil
+	}
+	k, err := newKubernetes()
+	if err != nil {
+		return false, err
+	}
+
+	path := fmt.Sprintf("https://%v/api/v1/namespaces/%v", k.Host, client.SerializeResourceName(ns))
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.Token)
+
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: k.TLS}}
+	rsp, err := c.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer rsp.Body.Close()
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	b, _ := ioutil.ReadAll(rsp.Body)
+	return false, fmt.Errorf("%v %s", rsp.Status, b)
+}
+
+// parseKey returns the namespace, name and version of the service in a key with the prefix, e.g.
+// "revision:foo:go.micro.service.bar:latest:0000000003"
+func parseKey(key, prefix string) (string, string, string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(key, prefix), ":", 4)
+	if len(parts) < 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
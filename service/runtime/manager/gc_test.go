@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/service/runtime/exec"
+)
+
+func TestGC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// foo is running with a replica, bar was deleted but is still running and baz wasn't created
+	// by a manager
+	rt := &testRuntime{readServices: []*runtime.Service{
+		{Name: "go.micro.service.foo", Version: "latest"},
+		{Name: "go.micro.service.foo", Version: replicaVersion("latest", 1)},
+		{Name: "go.micro.service.bar", Version: "latest"},
+		{Name: "go.micro.service.baz", Version: "latest"},
+	}}
+	builds := filepath.Join(dir, "builds")
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), UploadDir(dir), BuildDir(builds)).(*manager)
+
+	ns := "foo"
+	foo := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Source: "foo.tar.gz"}
+	bar := &runtime.Service{Name: "go.micro.service.bar", Version: "latest", Source: "bar.tar.gz"}
+	m.createService(foo, &runtime.CreateOptions{Namespace: ns})
+	m.writeRevision("create", foo, &runtime.CreateOptions{Namespace: ns})
+	m.writeRevision("create", bar, &runtime.CreateOptions{Namespace: ns})
+	m.Audit(&AuditEvent{Action: "create", Namespace: ns, Service: bar.Name, Version: bar.Version})
+	m.cache.Write(&store.Record{Key: statusPrefix + ns + ":" + foo.Name + ":" + replicaVersion("latest", 1)})
+	m.cache.Write(&store.Record{Key: statusPrefix + ns + ":" + bar.Name + ":latest"})
+
+	// sources are only removed once they're old enough, even if they're not used
+	old := time.Now().Add(-gcSourceAge * 2)
+	os.MkdirAll(filepath.Join(dir, "chunks"), 0700)
+	for _, name := range []string{"foo.tar.gz", "bar.tar.gz", "new.tar.gz", filepath.Join("chunks", "abc")} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		if name != "new.tar.gz" {
+			os.Chtimes(filepath.Join(dir, name), old, old)
+		}
+	}
+
+	// builds are removed once no service has been started from them for a while
+	os.MkdirAll(filepath.Join(builds, ns, "bin"), 0700)
+	for _, name := range []string{"abc", "def"} {
+		ioutil.WriteFile(filepath.Join(builds, ns, "bin", name), nil, 0755)
+	}
+	unused := time.Now().Add(-gcBuildAge * 2)
+	os.Chtimes(filepath.Join(builds, ns, "bin", "abc"), unused, unused)
+
+	process := OrphanProcess + " foo:go.micro.service.bar:latest"
+	expected := []string{
+		OrphanBuild + " " + filepath.Join(builds, ns, "bin", "abc"),
+		process,
+		OrphanRecord + " " + revisionKey(ns, bar, 1),
+		OrphanSource + " " + filepath.Join(dir, "bar.tar.gz"),
+		OrphanSource + " " + filepath.Join(dir, "chunks", "abc"),
+		OrphanStatus + " " + statusPrefix + ns + ":" + bar.Name + ":latest",
+	}
+
+	orphans, err := m.GC(true, "")
+	if err != nil {
+		t.Fatalf("Unexpected error finding orphans: %v", err)
+	}
+	if found := orphanKeys(orphans); !equal(found, expected) {
+		t.Fatalf("Expected orphans %v, got %v", expected, found)
+	}
+	if rt.deleteCount != 0 {
+		t.Errorf("Expected nothing to be removed on a dry run")
+	}
+
+	if _, err := m.GC(false, "admin"); err != nil {
+		t.Fatalf("Unexpected error removing orphans: %v", err)
+	}
+	if rt.deleteCount != 1 {
+		t.Errorf("Expected the orphaned process to be deleted, got %v deletes", rt.deleteCount)
+	}
+	events, err := m.AuditLog(ns, bar.Name, time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error reading the audit log: %v", err)
+	}
+	if ev := events[len(events)-1]; ev.Action != "gc" || ev.Account != "admin" || ev.Outcome != AuditSuccess {
+		t.Errorf("Expected the removal to be written to the audit log, got %+v", ev)
+	}
+
+	// the process is still returned by the test runtime, everything else was removed
+	orphans, _ = m.GC(true, "")
+	if found := orphanKeys(orphans); !equal(found, []string{process}) {
+		t.Errorf("Expected orphans %v after removing them, got %v", process, found)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "foo.tar.gz")); err != nil {
+		t.Errorf("Expected the source in use to be kept")
+	}
+	if _, err := os.Stat(filepath.Join(builds, ns, "bin", "def")); err != nil {
+		t.Errorf("Expected the build in use to be kept")
+	}
+	if revs, _ := m.History(ns, &runtime.Service{Name: foo.Name}); len(revs) != 1 {
+		t.Errorf("Expected the revision of the service which exists to be kept")
+	}
+}
+
+func TestGCNamespace(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/foo" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	defer func(f func() (*exec.Kubernetes, error)) { newKubernetes = f }(newKubernetes)
+	newKubernetes = func() (*exec.Kubernetes, error) {
+		return &exec.Kubernetes{
+			Host: strings.TrimPrefix(ts.URL, "https://"),
+			TLS:  ts.Client().Transport.(*http.Transport).TLSClientConfig,
+		}, nil
+	}
+
+	// the bar namespace has been deleted along with the services running in it
+	rt := &kubernetesRuntime{}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore())).(*manager)
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest"}
+	for _, ns := range []string{"foo", "bar"} {
+		m.createService(srv, &runtime.CreateOptions{Namespace: ns})
+		m.writeRevision("create", srv, &runtime.CreateOptions{Namespace: ns})
+	}
+
+	expected := []string{
+		OrphanRecord + " " + revisionKey("bar", srv, 1),
+		OrphanRecord + " " + servicePrefix + "bar:" + srv.Name + ":latest",
+	}
+	orphans, err := m.GC(false, "")
+	if err != nil {
+		t.Fatalf("Unexpected error removing orphans: %v", err)
+	}
+	if found := orphanKeys(orphans); !equal(found, expected) {
+		t.Fatalf("Expected orphans %v, got %v", expected, found)
+	}
+	if srvs, _ := m.readServices("bar", srv); len(srvs) != 0 {
+		t.Errorf("Expected the service of the deleted namespace to be removed")
+	}
+	if srvs, _ := m.readServices("foo", srv); len(srvs) != 1 {
+		t.Errorf("Expected the service of the namespace which exists to be kept")
+	}
+	if rt.readCount != 1 {
+		t.Errorf("Expected only the namespace which exists to be read from the runtime, got %v reads", rt.readCount)
+	}
+}
+
+type kubernetesRuntime struct {
+	testRuntime
+}
+
+func (r *kubernetesRuntime) String() string {
+	return "kubernetes"
+}
+
+func orphanKeys(orphans []*Orphan) []string {
+	keys := make([]string, 0, len(orphans))
+	for _, o := range orphans {
+		keys = append(keys, o.Kind+" "+o.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/micro/v2/internal/namespace"
+)
+
+const (
+	// revisionPrefix is prefixed to the key for revision records
+	revisionPrefix = "revision:"
+)
+
+// historySize is the max number of revisions kept per service
+var historySize = 10
+
+// Revision of a service, written each time the service is created, updated or rolled back
+type Revision struct {
+	// Number of the revision, incremented for each revision of the service
+	Number int64 `json:"number"`
+	// Action which created the revision, e.g. create, update or rollback
+	Action string `json:"action"`
+	// Created is the time the revision was written
+	Created time.Time `json:"created"`
+	// Service as it was at this revision
+	Service *runtime.Service `json:"service"`
+	// Options the service was created with
+	Options *runtime.CreateOptions `json:"options"`
+}
+
+// revisionKey to write the revision to the store under. The number is padded so the keys sort in
+// revision order, e.g: "revision:foo:go.micro.service.bar:latest:0000000003"
+func revisionKey(ns string, srv *runtime.Service, number int64) string {
+	return fmt.Sprintf("%v%v:%v:%v:%010d", revisionPrefix, ns, srv.Name, srv.Version, number)
+}
+
+// History returns the revisions of a service, newest first
+func (m *manager) History(ns string, srv *runtime.Service) ([]*Revision, error) {
+	if len(ns) == 0 {
+		ns = namespace.DefaultNamespace
+	}
+	if len(srv.Version) == 0 {
+		srv.Version = "latest"
+	}
+
+	revs, err := m.readRevisions(ns, srv)
+	if err != nil {
+		return nil, err
+	}
+
+	// reverse the revisions so the newest is first
+	for i, j := 0, len(revs)-1; i < j; i, j = i+1, j-1 {
+		revs[i], revs[j] = revs[j], revs[i]
+	}
+	return revs, nil
+}
+
+// Rollback the service to a previous revision. If the revision number is zero the service will be
+// rolled back to the revision before the current one. The rollback is written as a new revision,
+// and to the audit log with the account once it's applied.
+func (m *manager) Rollback(ns string, srv *runtime.Service, number int64, account string) (*Revision, error) {
+	if len(ns) == 0 {
+		ns = namespace.DefaultNamespace
+	}
+	if len(srv.Version) == 0 {
+		srv.Version = "latest"
+	}
+
+	revs, err := m.readRevisions(ns, srv)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) == 0 {
+		return nil, fmt.Errorf("Service %v:%v has no revisions", srv.Name, srv.Version)
+	}
+
+	// find the revision to rollback to
+	var rev *Revision
+	if number == 0 && len(revs) > 1 {
+		rev = revs[len(revs)-2]
+	}
+	for _, r := range revs {
+		if number > 0 && r.Number == number {
+			rev = r
+		}
+	}
+	if rev == nil && number == 0 {
+		return nil, fmt.Errorf("Service %v:%v has no previous revision", srv.Name, srv.Version)
+	} else if rev == nil {
+		return nil, fmt.Errorf("Revision %v of service %v:%v not found", number, srv.Name, srv.Version)
+	}
+
+	// the service needs to be recreated since an update won't change the options
+	if err := m.createService(rev.Service, rev.Options); err != nil {
+		return nil, err
+	}
+	latest, err := m.writeRevision("rollback", rev.Service, rev.Options)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.publishEvent(runtime.Delete, rev.Service, &runtime.CreateOptions{Namespace: ns}, nil); err != nil {
+		return nil, err
+	}
+	audit := requestAudit("rollback", ns, account, rev.Service)
+	if err := m.publishEvent(runtime.Create, rev.Service, rev.Options, audit); err != nil {
+		return nil, err
+	}
+
+	return latest, nil
+}
+
+// writeRevision writes a new revision of the service to the store and removes any revisions which
+// exceed the history size
+func (m *manager) writeRevision(action string, srv *runtime.Service, opts *runtime.CreateOptions) (*Revision, error) {
+	revs, err := m.readRevisions(opts.Namespace, srv)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := &Revision{
+		Number:  1,
+		Action:  action,
+		Created: time.Now(),
+		Service: srv,
+		Options: opts,
+	}
+	if len(revs) > 0 {
+		rev.Number = revs[len(revs)-1].Number + 1
+	}
+
+	bytes, err := json.Marshal(rev)
+	if err != nil {
+		return nil, err
+	}
+	key := revisionKey(opts.Namespace, srv, rev.Number)
+	if err := m.options.Store.Write(&store.Record{Key: key, Value: bytes}); err != nil {
+		return nil, err
+	}
+
+	// remove the oldest revisions, one is added so the new revision is included
+	for i := 0; i < len(revs)+1-historySize; i++ {
+		if err := m.options.Store.Delete(revisionKey(opts.Namespace, srv, revs[i].Number)); err != nil {
+			return nil, err
+		}
+	}
+
+	return rev, nil
+}
+
+// readRevisions returns the revisions of a service, oldest first
+func (m *manager) readRevisions(namespace string, srv *runtime.Service) ([]*Revision, error) {
+	prefix := revisionPrefix + namespace + ":" + srv.Name + ":" + srv.Version + ":"
+	recs, err := m.options.Store.Read(prefix, store.ReadPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	revs := make([]*Revision, 0, len(recs))
+	for _, r := range recs {
+		var rev *Revision
+		if err := json.Unmarshal(r.Value, &rev); err != nil {
+			return nil, err
+		}
+		revs = append(revs, rev)
+	}
+
+	// the store doesn't guarantee the order of the records
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Number < revs[j].Number })
+	return revs, nil
+}
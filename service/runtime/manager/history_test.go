@@ -0,0 +1,82 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/internal/namespace"
+)
+
+func TestHistory(t *testing.T) {
+	m := New(&testRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	ns := namespace.DefaultNamespace
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Source: "v1"}
+	if err := m.Create(srv, runtime.CreateNamespace(ns)); err != nil {
+		t.Fatalf("Unexpected error when creating service: %v", err)
+	}
+	if err := m.Update(&runtime.Service{Name: srv.Name, Version: srv.Version, Source: "v2"}, runtime.UpdateNamespace(ns)); err != nil {
+		t.Fatalf("Unexpected error when updating service: %v", err)
+	}
+
+	// creating and updating the service should have written two revisions, newest first
+	t.Run("History", func(t *testing.T) {
+		revs, err := m.History(ns, &runtime.Service{Name: srv.Name})
+		if err != nil {
+			t.Fatalf("Unexpected error when reading history: %v", err)
+		}
+		if len(revs) != 2 {
+			t.Fatalf("Expected 2 revisions, got %v", len(revs))
+		}
+		if revs[0].Number != 2 || revs[0].Action != "update" || revs[0].Service.Source != "v2" {
+			t.Errorf("Unexpected latest revision: %+v", revs[0])
+		}
+		if revs[1].Number != 1 || revs[1].Action != "create" || revs[1].Service.Source != "v1" {
+			t.Errorf("Unexpected first revision: %+v", revs[1])
+		}
+	})
+
+	// rolling back with no revision should restore the previous one
+	t.Run("Rollback", func(t *testing.T) {
+		rev, err := m.Rollback(ns, &runtime.Service{Name: srv.Name}, 0, "")
+		if err != nil {
+			t.Fatalf("Unexpected error when rolling back: %v", err)
+		}
+		if rev.Number != 3 || rev.Service.Source != "v1" {
+			t.Errorf("Unexpected rollback revision: %+v", rev)
+		}
+
+		srvs, err := m.readServices(ns, srv)
+		if err != nil {
+			t.Fatalf("Unexpected error when reading services: %v", err)
+		}
+		if len(srvs) != 1 || srvs[0].Service.Source != "v1" {
+			t.Errorf("Expected the service to be rolled back to v1")
+		}
+
+		if _, err := m.Rollback(ns, &runtime.Service{Name: srv.Name}, 10, ""); err == nil {
+			t.Errorf("Expected an error when rolling back to a missing revision")
+		}
+	})
+
+	// only the most recent revisions should be kept
+	t.Run("Bounded", func(t *testing.T) {
+		for i := 0; i < historySize; i++ {
+			if err := m.Update(&runtime.Service{Name: srv.Name, Version: srv.Version}, runtime.UpdateNamespace(ns)); err != nil {
+				t.Fatalf("Unexpected error when updating service: %v", err)
+			}
+		}
+
+		revs, err := m.History(ns, &runtime.Service{Name: srv.Name})
+		if err != nil {
+			t.Fatalf("Unexpected error when reading history: %v", err)
+		}
+		if len(revs) != historySize {
+			t.Fatalf("Expected %v revisions, got %v", historySize, len(revs))
+		}
+		if revs[0].Number != int64(historySize)+3 {
+			t.Errorf("Expected the latest revision to be %v, got %v", historySize+3, revs[0].Number)
+		}
+	})
+}
@@ -0,0 +1,566 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/micro/v2/internal/cron"
+	"github.com/micro/micro/v2/service/store/conditional"
+)
+
+const (
+	// JobType is the type of services which are run on a schedule rather than continuously
+	JobType = "job"
+	// JobScheduleKey is the metadata key for the cron schedule of a job, e.g. "*/5 * * * *"
+	JobScheduleKey = "schedule"
+	// JobConcurrencyKey is the metadata key for the concurrency policy of a job
+	JobConcurrencyKey = "concurrency"
+	// RunsKey is the service metadata key containing the json encoded runs of a job returned on
+	// Runtime.Read
+	RunsKey = "runs"
+	// NextRunKey is the service metadata key for the time the job is next due, returned on
+	// Runtime.Read
+	NextRunKey = "next_run"
+
+	// ConcurrencyAllow starts a run even if the previous one is still running
+	ConcurrencyAllow = "allow"
+	// ConcurrencyForbid skips a run if the previous one is still running
+	ConcurrencyForbid = "forbid"
+	// ConcurrencyReplace stops the previous run if it's still running and starts a new one
+	ConcurrencyReplace = "replace"
+
+	// Run statuses
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunSkipped   = "skipped"
+	RunReplaced  = "replaced"
+
+	// runSuffix separates the version of a job from the id of the run, each run is created in the
+	// runtime as a service with a suffixed version e.g. "latest-run-20200701120500"
+	runSuffix = "-run-"
+
+	// jobPrefix is prefixed to the key for the time a job was last scheduled
+	jobPrefix = "job:"
+	// jobLeasePrefix is prefixed to the key for the lease of the manager scheduling a job
+	jobLeasePrefix = "joblease:"
+	// runPrefix is prefixed to the key for run records
+	runPrefix = "run:"
+	// runLogsPrefix is prefixed to the key for the logs of finished runs
+	runLogsPrefix = "runlogs:"
+)
+
+var (
+	// jobFrequency is how often the manager checks for jobs which are due to run
+	jobFrequency = time.Second * 10
+	// jobHistory is the max number of runs kept per job
+	jobHistory = 10
+	// runLogLines is the max number of log lines kept per run
+	runLogLines = 1000
+	// runLogTimeout is the max duration to wait for the logs of a run
+	runLogTimeout = time.Second * 5
+	// jobLeaseTTL is how long a manager schedules a job for without renewing its lease, after
+	// which another manager takes over
+	jobLeaseTTL = jobFrequency * 3
+)
+
+// Run of a job
+type Run struct {
+	// ID of the run, the time it was scheduled for
+	ID string `json:"id"`
+	// Scheduled is the time the run was due
+	Scheduled time.Time `json:"scheduled"`
+	// Started is the time the run was started
+	Started time.Time `json:"started"`
+	// Finished is the time the run finished, it's zero while the run is in progress
+	Finished time.Time `json:"finished"`
+	// Status of the run, e.g. running, succeeded or failed
+	Status string `json:"status"`
+	// ExitCode of the run, if it's known
+	ExitCode *int `json:"exit_code,omitempty"`
+	// Error the run failed with
+	Error string `json:"error,omitempty"`
+	// Manager which started the run, only it can see the run in its runtime
+	Manager string `json:"manager,omitempty"`
+}
+
+// jobLease is held by the manager scheduling a job, every manager syncs every job but only the one
+// holding the lease starts and finishes its runs
+type jobLease struct {
+	Manager string    `json:"manager"`
+	Expires time.Time `json:"expires"`
+}
+
+// RunVersion returns the version a run of a job is created with in the runtime
+func RunVersion(version, id string) string {
+	return version + runSuffix + id
+}
+
+// parseJob validates the schedule and concurrency policy of a job
+func parseJob(md map[string]string) (*cron.Schedule, string, error) {
+	schedule, err := cron.Parse(md[JobScheduleKey])
+	if err != nil {
+		return nil, "", err
+	}
+
+	policy := md[JobConcurrencyKey]
+	switch policy {
+	case "":
+		policy = ConcurrencyAllow
+	case ConcurrencyAllow, ConcurrencyForbid, ConcurrencyReplace:
+	default:
+		return nil, "", fmt.Errorf("Invalid concurrency policy %v, must be allow, forbid or replace", policy)
+	}
+
+	return schedule, policy, nil
+}
+
+// isJob returns true if the options are those of a job
+func isJob(opts *runtime.CreateOptions) bool {
+	return opts != nil && opts.Type == JobType
+}
+
+// jobKey returns the suffix of the keys of a job, e.g. "foo:go.micro.service.bar:latest"
+func jobKey(ns string, srv *runtime.Service) string {
+	return ns + ":" + srv.Name + ":" + srv.Version
+}
+
+// Runs returns the runs of a job, newest first
+func (m *manager) Runs(ns string, srv *runtime.Service) ([]*Run, error) {
+	runs, err := m.readRuns(ns, srv)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs, nil
+}
+
+// RunLogs returns the logs of a run of a job. The logs of runs in progress are read from the
+// runtime, those of finished runs are kept in the store.
+func (m *manager) RunLogs(ns string, srv *runtime.Service, id string) ([]string, error) {
+	recs, err := m.options.Store.Read(runPrefix + jobKey(ns, srv) + ":" + id)
+	if err == store.ErrNotFound {
+		return nil, fmt.Errorf("Run %v of job %v:%v not found", id, srv.Name, srv.Version)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var run *Run
+	if err := json.Unmarshal(recs[0].Value, &run); err != nil {
+		return nil, err
+	}
+	if run.Status == RunRunning {
+		return m.runtimeLogs(ns, &runtime.Service{Name: srv.Name, Version: RunVersion(srv.Version, id)}), nil
+	}
+
+	recs, err = m.options.Store.Read(runLogsPrefix + jobKey(ns, srv) + ":" + id)
+	if err == store.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(recs[0].Value), "\n"), nil
+}
+
+// addRuns adds the time of the next run and the recent runs to the metadata of a job. The status of
+// the job is that of its latest run.
+func (m *manager) addRuns(ns string, srv *runtime.Service) error {
+	runs, err := m.Runs(ns, srv)
+	if err != nil {
+		return err
+	}
+
+	if schedule, _, err := parseJob(srv.Metadata); err == nil {
+		last := m.lastScheduled(ns, srv)
+		if last.IsZero() {
+			last = time.Now()
+		}
+		if next := schedule.Next(last); !next.IsZero() {
+			srv.Metadata[NextRunKey] = next.Format(time.RFC3339)
+		}
+	}
+
+	if len(runs) == 0 {
+		srv.Metadata["status"] = "scheduled"
+		return nil
+	}
+	srv.Metadata["status"] = runs[0].Status
+	srv.Metadata["error"] = runs[0].Error
+
+	bytes, err := json.Marshal(runs)
+	if err != nil {
+		return err
+	}
+	srv.Metadata[RunsKey] = string(bytes)
+	return nil
+}
+
+// readRuns returns the runs of a job, oldest first
+func (m *manager) readRuns(ns string, srv *runtime.Service) ([]*Run, error) {
+	recs, err := m.options.Store.Read(runPrefix+jobKey(ns, srv)+":", store.ReadPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*Run, 0, len(recs))
+	for _, r := range recs {
+		var run *Run
+		if err := json.Unmarshal(r.Value, &run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Scheduled.Before(runs[j].Scheduled) })
+	return runs, nil
+}
+
+// writeRun to the store
+func (m *manager) writeRun(ns string, srv *runtime.Service, run *Run) error {
+	bytes, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if err := m.options.Store.Write(&store.Record{Key: runPrefix + jobKey(ns, srv) + ":" + run.ID, Value: bytes}); err != nil {
+		return err
+	}
+	m.notifyStatus(ns)
+	return nil
+}
+
+// lastScheduled returns the time the job was last scheduled, or the zero time if it hasn't been
+func (m *manager) lastScheduled(ns string, srv *runtime.Service) time.Time {
+	recs, err := m.options.Store.Read(jobPrefix + jobKey(ns, srv))
+	if err != nil || len(recs) == 0 {
+		return time.Time{}
+	}
+
+	var t time.Time
+	if err := t.UnmarshalText(recs[0].Value); err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// writeLastScheduled records the time the job was last scheduled
+func (m *manager) writeLastScheduled(ns string, srv *runtime.Service, t time.Time) error {
+	bytes, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return m.options.Store.Write(&store.Record{Key: jobPrefix + jobKey(ns, srv), Value: bytes})
+}
+
+// watchJobs calls syncJobs periodically and should be run in a seperate go routine
+func (m *manager) watchJobs() {
+	ticker := time.NewTicker(jobFrequency)
+
+	for {
+		m.syncJobs()
+		<-ticker.C
+	}
+}
+
+// syncJobs updates the runs of every job in the store and starts those which are due
+func (m *manager) syncJobs() {
+	namespaces, err := m.listNamespaces()
+	if err != nil {
+		logger.Warnf("Error listing namespaces: %v", err)
+		return
+	}
+
+	for _, ns := range namespaces {
+		srvs, err := m.readServices(ns, &runtime.Service{})
+		if err != nil {
+			logger.Warnf("Error reading services from the %v namespace: %v", ns, err)
+			return
+		}
+
+		for _, srv := range srvs {
+			if !isJob(srv.Options) {
+				continue
+			}
+			if err := m.syncJob(ns, srv, time.Now()); err != nil {
+				logger.Warnf("Error running job %v:%v: %v", srv.Service.Name, srv.Service.Version, err)
+			}
+		}
+	}
+}
+
+// claimJob takes or renews the lease on scheduling the job, returning false if another manager
+// holds it. The lease is written only if it hasn't changed since it was read, so if managers race
+// to take it only the first one's write succeeds.
+func (m *manager) claimJob(ns string, srv *runtime.Service, now time.Time) (bool, error) {
+	key := jobLeasePrefix + jobKey(ns, srv)
+	c := conditional.Condition{Absent: true}
+	recs, err := m.options.Store.Read(key)
+	if err != nil && err != store.ErrNotFound {
+		return false, err
+	}
+	if len(recs) > 0 {
+		var l *jobLease
+		if err := json.Unmarshal(recs[0].Value, &l); err != nil {
+			return false, err
+		}
+		if l.Manager != m.id && l.Expires.After(now) {
+			return false, nil
+		}
+		c = conditional.Condition{Version: conditional.Version(recs[0])}
+	}
+
+	bytes, err := json.Marshal(&jobLease{Manager: m.id, Expires: now.Add(jobLeaseTTL)})
+	if err != nil {
+		return false, err
+	}
+	err = m.versioned.WriteIf(&store.Record{Key: key, Value: bytes}, c)
+	if err == conditional.ErrConflict {
+		return false, nil
+	} else if err == conditional.ErrUnsupported {
+		// the store can't check the version so the lease only holds if the writes don't race
+		err = m.options.Store.Write(&store.Record{Key: key, Value: bytes})
+	}
+	return err == nil, err
+}
+
+// syncJob finishes the runs of the job which have exited and starts a new run if one is due, if
+// the manager holds the lease on the job
+func (m *manager) syncJob(ns string, srv *service, now time.Time) error {
+	schedule, policy, err := parseJob(srv.Service.Metadata)
+	if err != nil {
+		return err
+	}
+
+	if claimed, err := m.claimJob(ns, srv.Service, now); err != nil || !claimed {
+		return err
+	}
+
+	runs, err := m.readRuns(ns, srv.Service)
+	if err != nil {
+		return err
+	}
+
+	// update the runs in progress from the runtime
+	var active []*Run
+	if err := m.finishRuns(ns, srv.Service, runs); err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if run.Status == RunRunning {
+			active = append(active, run)
+		}
+	}
+
+	// the first time the job is seen it's scheduled from now
+	last := m.lastScheduled(ns, srv.Service)
+	if last.IsZero() {
+		return m.writeLastScheduled(ns, srv.Service, now)
+	}
+
+	// find the latest time the job was due, runs missed whilst the manager was offline are skipped
+	due := schedule.Next(last)
+	if due.IsZero() || due.After(now) {
+		return nil
+	}
+	for next := schedule.Next(due); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		due = next
+	}
+
+	// record the time before starting the run so it isn't started twice
+	if err := m.writeLastScheduled(ns, srv.Service, due); err != nil {
+		return err
+	}
+
+	run := &Run{ID: due.UTC().Format("20060102150405"), Scheduled: due, Started: now, Status: RunRunning, Manager: m.id}
+
+	switch {
+	case len(active) > 0 && policy == ConcurrencyForbid:
+		run.Status = RunSkipped
+		run.Finished = now
+		run.Error = fmt.Sprintf("run %v is still running", active[len(active)-1].ID)
+		return m.writeRun(ns, srv.Service, run)
+	case len(active) > 0 && policy == ConcurrencyReplace:
+		for _, a := range active {
+			m.stopRun(ns, srv.Service, a, RunReplaced, now)
+		}
+	}
+
+	logger.Infof("Starting run %v of job %v:%v in namespace %v", run.ID, srv.Service.Name, srv.Service.Version, ns)
+
+	r := &runtime.Service{
+		Name:     srv.Service.Name,
+		Version:  RunVersion(srv.Service.Version, run.ID),
+		Source:   srv.Service.Source,
+		Metadata: make(map[string]string),
+	}
+	if err := m.runtimeCreate(ns, r, srv.Options); err != nil {
+		run.Status = RunFailed
+		run.Finished = now
+		run.Error = err.Error()
+	}
+	if err := m.writeRun(ns, srv.Service, run); err != nil {
+		return err
+	}
+
+	return m.trimRuns(ns, srv.Service)
+}
+
+// finishRuns updates the runs in progress which have exited in the runtime, keeping their logs.
+// Runs started by another manager are only finished once the lease on the job has passed to this
+// manager, those which aren't in its runtime stopped with the manager which started them.
+func (m *manager) finishRuns(ns string, srv *runtime.Service, runs []*Run) error {
+	running, err := m.Runtime.Read(runtime.ReadNamespace(ns), runtime.ReadService(srv.Name))
+	if err != nil {
+		return err
+	}
+	versions := make(map[string]*runtime.Service, len(running))
+	for _, r := range running {
+		versions[r.Version] = r
+	}
+
+	for _, run := range runs {
+		if run.Status != RunRunning {
+			continue
+		}
+
+		r, ok := versions[RunVersion(srv.Version, run.ID)]
+		if !ok && len(run.Manager) > 0 && run.Manager != m.id {
+			run.Status = RunFailed
+			run.Finished = time.Now()
+			run.Error = "the manager running it stopped"
+		} else if !ok {
+			run.Status = RunFailed
+			run.Finished = time.Now()
+			run.Error = "run not found in the runtime"
+		} else if status := r.Metadata["status"]; exited(status) {
+			run.Finished = time.Now()
+			run.Error = r.Metadata["error"]
+			run.ExitCode = exitCode(status, run.Error)
+			if run.ExitCode != nil && *run.ExitCode == 0 {
+				run.Status = RunSucceeded
+			} else {
+				run.Status = RunFailed
+			}
+			m.keepLogs(ns, srv, r, run)
+		} else {
+			continue
+		}
+
+		if err := m.writeRun(ns, srv, run); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stopRun removes a run in progress from the runtime
+func (m *manager) stopRun(ns string, srv *runtime.Service, run *Run, status string, now time.Time) {
+	r := &runtime.Service{Name: srv.Name, Version: RunVersion(srv.Version, run.ID)}
+	m.keepLogs(ns, srv, r, run)
+	if err := m.Runtime.Delete(r, runtime.DeleteNamespace(ns)); err != nil {
+		logger.Warnf("Error stopping run %v of job %v:%v: %v", run.ID, srv.Name, srv.Version, err)
+	}
+
+	run.Status = status
+	run.Finished = now
+	if err := m.writeRun(ns, srv, run); err != nil {
+		logger.Warnf("Error writing run %v of job %v:%v: %v", run.ID, srv.Name, srv.Version, err)
+	}
+}
+
+// keepLogs copies the logs of a run from the runtime to the store
+func (m *manager) keepLogs(ns string, srv, r *runtime.Service, run *Run) {
+	lines := m.runtimeLogs(ns, r)
+	key := runLogsPrefix + jobKey(ns, srv) + ":" + run.ID
+	if err := m.options.Store.Write(&store.Record{Key: key, Value: []byte(strings.Join(lines, "\n"))}); err != nil {
+		logger.Warnf("Error writing the logs of run %v of job %v:%v: %v", run.ID, srv.Name, srv.Version, err)
+	}
+}
+
+// runtimeLogs reads the logs of a service from the runtime
+func (m *manager) runtimeLogs(ns string, srv *runtime.Service) []string {
+	stream, err := m.Runtime.Logs(srv, runtime.LogsCount(int64(runLogLines)), runtime.LogsNamespace(ns))
+	if err != nil {
+		logger.Warnf("Error reading the logs of service %v:%v: %v", srv.Name, srv.Version, err)
+		return nil
+	}
+	defer stream.Stop()
+
+	var lines []string
+	timeout := time.After(runLogTimeout)
+	for {
+		select {
+		case rec, ok := <-stream.Chan():
+			if !ok {
+				return lines
+			}
+			lines = append(lines, rec.Message)
+		case <-timeout:
+			return lines
+		}
+	}
+}
+
+// trimRuns removes the oldest runs which exceed the history size, along with their logs and the
+// services left in the runtime
+func (m *manager) trimRuns(ns string, srv *runtime.Service) error {
+	runs, err := m.readRuns(ns, srv)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(runs)-jobHistory; i++ {
+		if runs[i].Status == RunRunning {
+			continue
+		}
+		if err := m.deleteRun(ns, srv, runs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteRun removes a run from the runtime and the store
+func (m *manager) deleteRun(ns string, srv *runtime.Service, run *Run) error {
+	r := &runtime.Service{Name: srv.Name, Version: RunVersion(srv.Version, run.ID)}
+	if run.Status != RunSkipped {
+		if err := m.Runtime.Delete(r, runtime.DeleteNamespace(ns)); err != nil {
+			logger.Warnf("Error deleting run %v of job %v:%v: %v", run.ID, srv.Name, srv.Version, err)
+		}
+	}
+
+	key := jobKey(ns, srv) + ":" + run.ID
+	if err := m.options.Store.Delete(runLogsPrefix + key); err != nil && err != store.ErrNotFound {
+		return err
+	}
+	return m.options.Store.Delete(runPrefix + key)
+}
+
+// deleteJob removes the runs of a job when it's deleted
+func (m *manager) deleteJob(ns string, srv *runtime.Service) error {
+	runs, err := m.readRuns(ns, srv)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if err := m.deleteRun(ns, srv, run); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range []string{jobPrefix + jobKey(ns, srv), jobLeasePrefix + jobKey(ns, srv)} {
+		if err := m.options.Store.Delete(key); err != nil && err != store.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
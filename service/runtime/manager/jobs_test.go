@@ -0,0 +1,223 @@
+package manager
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/internal/namespace"
+)
+
+// jobRuntime returns the same logs for every service
+type jobRuntime struct {
+	replicaRuntime
+	logs []string
+}
+
+func (r *jobRuntime) Logs(srv *runtime.Service, opts ...runtime.LogsOption) (runtime.LogStream, error) {
+	ch := make(chan runtime.LogRecord, len(r.logs))
+	for _, l := range r.logs {
+		ch <- runtime.LogRecord{Message: l}
+	}
+	close(ch)
+	return &testLogStream{ch}, nil
+}
+
+type testLogStream struct {
+	ch chan runtime.LogRecord
+}
+
+func (s *testLogStream) Error() error                 { return nil }
+func (s *testLogStream) Chan() chan runtime.LogRecord { return s.ch }
+func (s *testLogStream) Stop() error                  { return nil }
+
+func TestJobs(t *testing.T) {
+	rt := &jobRuntime{
+		replicaRuntime: replicaRuntime{services: make(map[string]*runtime.Service)},
+		logs:           []string{"starting", "done"},
+	}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	ns := namespace.DefaultNamespace
+	srv := &service{
+		Service: &runtime.Service{Name: "go.micro.job.foo", Version: "latest", Metadata: map[string]string{
+			JobScheduleKey:    "* * * * *",
+			JobConcurrencyKey: ConcurrencyForbid,
+		}},
+		Options: &runtime.CreateOptions{Namespace: ns, Type: JobType},
+	}
+	now := time.Date(2020, 7, 1, 12, 0, 30, 0, time.UTC)
+
+	// the job is scheduled from the first time it's seen
+	if err := m.syncJob(ns, srv, now); err != nil {
+		t.Fatalf("Unexpected error syncing job: %v", err)
+	}
+	if len(rt.services) != 0 {
+		t.Fatalf("Expected no runs to be started, got %v", len(rt.services))
+	}
+
+	// a run is started when the job is due
+	if err := m.syncJob(ns, srv, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Unexpected error syncing job: %v", err)
+	}
+	first, ok := rt.services[RunVersion("latest", "20200701120100")]
+	if !ok {
+		t.Fatalf("Expected the run to be created in the runtime, got %v services", len(rt.services))
+	}
+
+	// the next run is skipped since the first is still running
+	if err := m.syncJob(ns, srv, now.Add(time.Minute*2)); err != nil {
+		t.Fatalf("Unexpected error syncing job: %v", err)
+	}
+	runs, err := m.Runs(ns, srv.Service)
+	if err != nil {
+		t.Fatalf("Unexpected error reading runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Status != RunSkipped || runs[1].Status != RunRunning {
+		t.Fatalf("Expected a skipped run after the running one, got %+v", runs)
+	}
+
+	// once the first run exits it's finished with its exit code and logs
+	first.Metadata = map[string]string{"status": "error", "error": "exit status 2"}
+	if err := m.syncJob(ns, srv, now.Add(time.Minute*3)); err != nil {
+		t.Fatalf("Unexpected error syncing job: %v", err)
+	}
+	runs, err = m.Runs(ns, srv.Service)
+	if err != nil {
+		t.Fatalf("Unexpected error reading runs: %v", err)
+	}
+	if len(runs) != 3 || runs[0].Status != RunRunning {
+		t.Fatalf("Expected a new run to be started, got %+v", runs)
+	}
+	if r := runs[2]; r.Status != RunFailed || r.ExitCode == nil || *r.ExitCode != 2 {
+		t.Errorf("Expected the first run to fail with exit code 2, got %+v", r)
+	}
+	logs, err := m.RunLogs(ns, srv.Service, "20200701120100")
+	if err != nil {
+		t.Fatalf("Unexpected error reading logs: %v", err)
+	}
+	if len(logs) != 2 || logs[1] != "done" {
+		t.Errorf("Expected the logs of the run to be kept, got %v", logs)
+	}
+
+	// deleting the job removes its runs
+	if err := m.deleteJob(ns, srv.Service); err != nil {
+		t.Fatalf("Unexpected error deleting job: %v", err)
+	}
+	if runs, _ := m.Runs(ns, srv.Service); len(runs) != 0 {
+		t.Errorf("Expected no runs after deleting the job, got %v", len(runs))
+	}
+	if len(rt.services) != 0 {
+		t.Errorf("Expected no runs in the runtime, got %v", len(rt.services))
+	}
+}
+
+func TestParseJob(t *testing.T) {
+	if _, _, err := parseJob(map[string]string{}); err == nil {
+		t.Errorf("Expected an error for a job without a schedule")
+	}
+	if _, _, err := parseJob(map[string]string{JobScheduleKey: "@hourly", JobConcurrencyKey: "queue"}); err == nil {
+		t.Errorf("Expected an error for an invalid concurrency policy")
+	}
+	_, policy, err := parseJob(map[string]string{JobScheduleKey: "*/5 * * * *"})
+	if err != nil || policy != ConcurrencyAllow {
+		t.Errorf("Expected the allow policy by default, got %v: %v", policy, err)
+	}
+}
+
+func TestJobLease(t *testing.T) {
+	str := memory.NewStore()
+	brk := newTestBroker(t)
+
+	var runtimes []*jobRuntime
+	var managers []*manager
+	for i := 0; i < 2; i++ {
+		rt := &jobRuntime{replicaRuntime: replicaRuntime{services: make(map[string]*runtime.Service)}}
+		runtimes = append(runtimes, rt)
+		managers = append(managers, New(rt, Store(str), CacheStore(memory.NewStore()), Broker(brk)).(*manager))
+	}
+
+	ns := namespace.DefaultNamespace
+	srv := &service{
+		Service: &runtime.Service{Name: "go.micro.job.foo", Version: "latest", Metadata: map[string]string{
+			JobScheduleKey:    "* * * * *",
+			JobConcurrencyKey: ConcurrencyForbid,
+		}},
+		Options: &runtime.CreateOptions{Namespace: ns, Type: JobType},
+	}
+	now := time.Date(2020, 7, 1, 12, 0, 30, 0, time.UTC)
+
+	// the first manager to sync the job takes the lease and starts its runs
+	for _, m := range managers {
+		if err := m.syncJob(ns, srv, now); err != nil {
+			t.Fatalf("Unexpected error syncing job: %v", err)
+		}
+	}
+	for _, m := range managers {
+		if err := m.syncJob(ns, srv, now.Add(time.Minute)); err != nil {
+			t.Fatalf("Unexpected error syncing job: %v", err)
+		}
+	}
+	if len(runtimes[0].services) != 1 || len(runtimes[1].services) != 0 {
+		t.Fatalf("Expected one run started by the first manager, got %v and %v", len(runtimes[0].services), len(runtimes[1].services))
+	}
+	runs, err := managers[1].Runs(ns, srv.Service)
+	if err != nil {
+		t.Fatalf("Unexpected error reading runs: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != RunRunning {
+		t.Fatalf("Expected the run to be left running by the second manager, got %+v", runs)
+	}
+
+	// once the lease expires the second manager takes over, failing the run it can't see
+	later := now.Add(time.Minute + jobLeaseTTL + time.Second)
+	if err := managers[1].syncJob(ns, srv, later); err != nil {
+		t.Fatalf("Unexpected error syncing job: %v", err)
+	}
+	runs, err = managers[1].Runs(ns, srv.Service)
+	if err != nil {
+		t.Fatalf("Unexpected error reading runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Manager != managers[1].id || runs[1].Status != RunFailed {
+		t.Fatalf("Expected the second manager to fail the first run and start the next, got %+v", runs)
+	}
+	if err := managers[0].syncJob(ns, srv, later); err != nil {
+		t.Fatalf("Unexpected error syncing job: %v", err)
+	}
+	if runs, _ := managers[0].Runs(ns, srv.Service); len(runs) != 2 {
+		t.Errorf("Expected the first manager not to start runs without the lease, got %v runs", len(runs))
+	}
+}
+
+func TestClaimJob(t *testing.T) {
+	str := memory.NewStore()
+	var managers []*manager
+	for i := 0; i < 10; i++ {
+		managers = append(managers, New(&testRuntime{}, Store(str), CacheStore(memory.NewStore())).(*manager))
+	}
+
+	// only one of the managers racing to take the lease gets it
+	srv := &runtime.Service{Name: "go.micro.job.foo", Version: "latest"}
+	now := time.Now()
+	var wg sync.WaitGroup
+	var claimed int32
+	for _, m := range managers {
+		wg.Add(1)
+		go func(m *manager) {
+			defer wg.Done()
+			ok, err := m.claimJob(namespace.DefaultNamespace, srv, now)
+			if err != nil {
+				t.Errorf("Unexpected error claiming the job: %v", err)
+			} else if ok {
+				atomic.AddInt32(&claimed, 1)
+			}
+		}(m)
+	}
+	wg.Wait()
+	if claimed != 1 {
+		t.Errorf("Expected one manager to claim the job, got %v", claimed)
+	}
+}
@@ -0,0 +1,238 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/util/kubernetes/client"
+	"github.com/micro/micro/v2/service/runtime/build"
+	"github.com/micro/micro/v2/service/runtime/cells"
+	"github.com/micro/micro/v2/service/runtime/exec"
+	"github.com/micro/micro/v2/service/runtime/limits"
+	"github.com/micro/micro/v2/service/runtime/secrets"
+)
+
+// newKubernetes returns a client of the kubernetes api the services are run on
+var newKubernetes = exec.NewKubernetes
+
+// runtimeCreate creates the service in the managed runtime. The kubernetes runtime creates the
+// deployment of a service without resources or volumes, so its limits and build cache are set on
+// the container afterwards.
+func (m *manager) runtimeCreate(ns string, srv *runtime.Service, options *runtime.CreateOptions) error {
+	opts, err := m.createOptions(ns, srv, options)
+	if err != nil {
+		return err
+	}
+	if err := m.Runtime.Create(srv, opts...); err != nil {
+		return err
+	}
+	if m.Runtime.String() != "kubernetes" {
+		return nil
+	}
+
+	spec := podSpec(srv)
+	if l, err := limits.FromMetadata(srv.Metadata); err == nil && l != nil {
+		// invalid limits were logged when creating the options
+		spec.setResources(l)
+	}
+	if len(m.options.BuildDir) > 0 && len(options.Command) == 0 && len(options.Args) == 0 {
+		spec.setBuildCache(build.NewCache(m.options.BuildDir), ns, srv)
+	}
+	if spec.empty() {
+		return nil
+	}
+
+	k, err := newKubernetes()
+	if err != nil {
+		return fmt.Errorf("Error patching the deployment of the service: %v", err)
+	}
+	if err := patchDeployment(k, ns, srv, spec); err != nil {
+		return fmt.Errorf("Error patching the deployment of the service: %v", err)
+	}
+	return nil
+}
+
+// podPatch is a strategic merge patch of the pod spec of a kubernetes service, it's merged with
+// the containers, volumes and env vars set by the runtime by their names
+type podPatch struct {
+	container map[string]interface{}
+	volumes   []interface{}
+}
+
+// podSpec returns an empty patch of the container of the service, which is named as it is by the
+// runtime
+func podSpec(srv *runtime.Service) *podPatch {
+	return &podPatch{container: map[string]interface{}{"name": client.Format(srv.Name)}}
+}
+
+// setResources sets the limits as the resource requests and limits of the container
+func (p *podPatch) setResources(l *limits.Limits) {
+	if resources := l.Resources(); len(resources) > 0 {
+		p.container["resources"] = map[string]interface{}{"limits": resources, "requests": resources}
+	}
+}
+
+// setBuildCache mounts the directory of the namespace's build cache from the node into the
+// container at the same path, so the paths in the env and command of the cache are valid in it.
+// The cell images run the command of the cache in place of the one detected for the service. The
+// env is set here rather than by the runtime, which splits the values of env vars at their first
+// '=' which the command contains.
+func (p *podPatch) setBuildCache(builds *build.Cache, ns string, srv *runtime.Service) {
+	cached := builds.Command(ns, srv.Name, baseVersion(srv.Version), srv.Metadata[cells.CellKey], srv.Metadata[cells.CommandKey])
+	if len(cached) == 0 {
+		return
+	}
+
+	dir := builds.Dir(ns)
+	p.volumes = append(p.volumes, map[string]interface{}{
+		"name":     "builds",
+		"hostPath": map[string]interface{}{"path": dir, "type": "DirectoryOrCreate"},
+	})
+	p.container["volumeMounts"] = []interface{}{
+		map[string]interface{}{"name": "builds", "mountPath": dir},
+	}
+
+	var env []interface{}
+	for _, v := range append(builds.Env(ns), cells.EnvKey+"="+cached) {
+		parts := strings.SplitN(v, "=", 2)
+		env = append(env, map[string]interface{}{"name": parts[0], "value": parts[1]})
+	}
+	p.container["env"] = env
+}
+
+// empty returns whether the patch doesn't change the pod
+func (p *podPatch) empty() bool {
+	return len(p.container) == 1 && len(p.volumes) == 0
+}
+
+// patchDeployment patches the pod spec of the deployment of a kubernetes service, which restarts
+// its pods with it
+func patchDeployment(k *exec.Kubernetes, ns string, srv *runtime.Service, p *podPatch) error {
+	spec := map[string]interface{}{"containers": []interface{}{p.container}}
+	if len(p.volumes) > 0 {
+		spec["volumes"] = p.volumes
+	}
+	return patchKubernetes(k, ns, srv, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": spec},
+		},
+	})
+}
+
+// patchKubernetes applies the strategic merge patch to the deployment of a kubernetes service,
+// which is named as it is by the runtime
+func patchKubernetes(k *exec.Kubernetes, ns string, srv *runtime.Service, patch map[string]interface{}) error {
+	deployment := client.Format(srv.Name)
+	if len(srv.Version) > 0 {
+		deployment += "-" + client.Format(srv.Version)
+	}
+	if len(ns) == 0 {
+		ns = client.DefaultNamespace
+	}
+
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("https://%v/apis/apps/v1/namespaces/%v/deployments/%v", k.Host, client.SerializeResourceName(ns), deployment)
+	req, err := http.NewRequest(http.MethodPatch, path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.Token)
+	req.Header.Set("Content-Type", "application/strategic-merge-patch+json")
+
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: k.TLS}}
+	rsp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(rsp.Body)
+		return fmt.Errorf("Error patching deployment %v: %v %s", deployment, rsp.Status, b)
+	}
+	return nil
+}
+
+// createOptions returns the options to create the service with in the managed runtime. Resource
+// limits set in the service metadata are enforced by wrapping the command of local services and
+// set as the resources of kubernetes services by runtimeCreate. Secrets are resolved into env vars
+// here so their values are never written to the store with the service, the service isn't created
+// if they can't be resolved since it would be started without them. Services detected as written
+// in languages other than go are run with the command of their cell, unless a command was set, and
+// local services are built using the build cache of the namespace. Kubernetes services mount the
+// cache in runtimeCreate.
+func (m *manager) createOptions(ns string, srv *runtime.Service, options *runtime.CreateOptions) ([]runtime.CreateOption, error) {
+	command, args := options.Command, options.Args
+	env := m.runtimeEnv(options)
+
+	cellCommand := srv.Metadata[cells.CommandKey]
+
+	// the builds of local services are cached per namespace, the env set by the caller takes
+	// precedence over the caches. Replicas and runs share the build status of their service.
+	if m.Runtime.String() == "local" && len(m.options.BuildDir) > 0 {
+		builds := build.NewCache(m.options.BuildDir)
+		env = append(builds.Env(ns), env...)
+		if len(command) == 0 && len(args) == 0 {
+			cached := builds.Command(ns, srv.Name, baseVersion(srv.Version), srv.Metadata[cells.CellKey], cellCommand)
+			if len(cached) > 0 {
+				command, args = []string{"sh"}, []string{"-c", cached}
+			}
+		}
+	}
+
+	// the cell images run the command passed in their env, local services are run with it directly
+	if len(cellCommand) > 0 && len(command) == 0 {
+		if m.Runtime.String() == "local" {
+			command, args = []string{"sh"}, []string{"-c", cellCommand}
+		} else {
+			env = append(env, cells.EnvKey+"="+cellCommand)
+		}
+	}
+
+	l, err := limits.FromMetadata(srv.Metadata)
+	if err != nil {
+		logger.Warnf("Error parsing the limits of service %v:%v: %v", srv.Name, srv.Version, err)
+	} else if l != nil && m.Runtime.String() != "local" && m.Runtime.String() != "kubernetes" {
+		logger.Warnf("Resource limits of service %v:%v are not supported by the %v runtime", srv.Name, srv.Version, m.Runtime.String())
+	} else if l != nil && m.Runtime.String() == "local" {
+		name := ns + "/" + srv.Name + "/" + srv.Version
+		if wrapped, wrappedArgs, err := limits.Command(l, name, command, args); err != nil {
+			logger.Warnf("Error applying the limits of service %v:%v: %v", srv.Name, srv.Version, err)
+		} else {
+			command, args = wrapped, wrappedArgs
+		}
+	}
+
+	if refs, _ := secrets.ParseRefs(srv.Metadata); len(refs) > 0 && m.options.Secrets == nil {
+		return nil, fmt.Errorf("Secrets of service %v:%v can't be resolved, no secrets are configured", srv.Name, srv.Version)
+	} else if len(refs) > 0 {
+		vars, err := m.options.Secrets.Resolve(ns, srv.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("Error resolving the secrets of service %v:%v: %v", srv.Name, srv.Version, err)
+		}
+		env = append(env, vars...)
+	}
+
+	// local processes are found by their env when a command is exec'd in the service
+	if m.Runtime.String() == "local" {
+		env = append(env, exec.EnvKey+"="+exec.ID(ns, srv))
+	}
+
+	return []runtime.CreateOption{
+		runtime.CreateImage(options.Image),
+		runtime.CreateType(options.Type),
+		runtime.CreateNamespace(ns),
+		runtime.WithArgs(args...),
+		runtime.WithCommand(command...),
+		runtime.WithEnv(env),
+	}, nil
+}
@@ -0,0 +1,261 @@
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/internal/namespace"
+	"github.com/micro/micro/v2/service/runtime/build"
+	"github.com/micro/micro/v2/service/runtime/cells"
+	"github.com/micro/micro/v2/service/runtime/exec"
+	"github.com/micro/micro/v2/service/runtime/limits"
+	"github.com/micro/micro/v2/service/runtime/secrets"
+)
+
+func TestLimits(t *testing.T) {
+	m := New(&testRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+	ns := namespace.DefaultNamespace
+
+	t.Run("Invalid", func(t *testing.T) {
+		srv := &runtime.Service{Name: "go.micro.service.foo", Metadata: map[string]string{limits.MemoryKey: "lots"}}
+		if err := m.Create(srv, runtime.CreateNamespace(ns)); err == nil {
+			t.Errorf("Expected an error when creating a service with invalid limits")
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		srv := &runtime.Service{Name: "go.micro.service.bar", Metadata: map[string]string{limits.CPUKey: "1"}}
+		if err := m.Create(srv, runtime.CreateNamespace(ns)); err != nil {
+			t.Fatalf("Unexpected error when creating service: %v", err)
+		}
+
+		// the limits in the update should be merged with those of the service
+		update := &runtime.Service{Name: srv.Name, Metadata: map[string]string{limits.MemoryKey: "256Mi"}}
+		if err := m.Update(update, runtime.UpdateNamespace(ns)); err != nil {
+			t.Fatalf("Unexpected error when updating service: %v", err)
+		}
+
+		srvs, err := m.readServices(ns, srv)
+		if err != nil {
+			t.Fatalf("Unexpected error when reading services: %v", err)
+		}
+		if len(srvs) != 1 {
+			t.Fatalf("Expected 1 service, got %v", len(srvs))
+		}
+		if md := srvs[0].Service.Metadata; md[limits.CPUKey] != "1" || md[limits.MemoryKey] != "256Mi" {
+			t.Errorf("Unexpected limits in the metadata: %v", md)
+		}
+	})
+}
+
+func TestPatchDeployment(t *testing.T) {
+	var path, contentType string
+	var patch map[string]interface{}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&patch)
+	}))
+	defer ts.Close()
+
+	k := &exec.Kubernetes{
+		Host: strings.TrimPrefix(ts.URL, "https://"),
+		TLS:  ts.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest"}
+	builds := build.NewCache("/var/lib/micro/builds")
+	spec := podSpec(srv)
+	spec.setResources(&limits.Limits{CPU: 0.5, Memory: 1024, Disk: 1024})
+	spec.setBuildCache(builds, "bar", srv)
+	if err := patchDeployment(k, "bar", srv, spec); err != nil {
+		t.Fatalf("Unexpected error patching the deployment: %v", err)
+	}
+
+	if path != "/apis/apps/v1/namespaces/bar/deployments/go-micro-service-foo-latest" {
+		t.Errorf("Unexpected deployment patched %v", path)
+	}
+	if contentType != "application/strategic-merge-patch+json" {
+		t.Errorf("Unexpected patch type %v", contentType)
+	}
+	pod := patch["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	container := pod["containers"].([]interface{})[0].(map[string]interface{})
+	resources := map[string]interface{}{"cpu": "500m", "memory": "1024", "ephemeral-storage": "1024"}
+	expected := map[string]interface{}{"limits": resources, "requests": resources}
+	if container["name"] != "go-micro-service-foo" || !reflect.DeepEqual(container["resources"], expected) {
+		t.Errorf("Unexpected container patch %v", container)
+	}
+
+	// the cache of the namespace is mounted from the node at the path the cache's command uses
+	dir := builds.Dir("bar")
+	volumes := []interface{}{map[string]interface{}{
+		"name":     "builds",
+		"hostPath": map[string]interface{}{"path": dir, "type": "DirectoryOrCreate"},
+	}}
+	if !reflect.DeepEqual(pod["volumes"], volumes) {
+		t.Errorf("Unexpected volumes %v", pod["volumes"])
+	}
+	mounts := []interface{}{map[string]interface{}{"name": "builds", "mountPath": dir}}
+	if !reflect.DeepEqual(container["volumeMounts"], mounts) {
+		t.Errorf("Unexpected volume mounts %v", container["volumeMounts"])
+	}
+	env := map[string]string{}
+	for _, v := range container["env"].([]interface{}) {
+		env[v.(map[string]interface{})["name"].(string)] = v.(map[string]interface{})["value"].(string)
+	}
+	if cmd := builds.Command("bar", srv.Name, srv.Version, "", ""); env[cells.EnvKey] != cmd {
+		t.Errorf("Expected the command of the cache in the env, got %q", env[cells.EnvKey])
+	}
+	if env["GOCACHE"] != dir+"/go-build" {
+		t.Errorf("Expected the go cache in the env, got %q", env["GOCACHE"])
+	}
+}
+
+func TestSecretsUnresolved(t *testing.T) {
+	sec, err := secrets.New(memory.NewStore(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := &testRuntime{}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), Secrets(sec)).(*manager)
+	ns := namespace.DefaultNamespace
+
+	// the service isn't created without its secrets and the error is in its status
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{secrets.MetadataKey: "DB_PASSWORD=db/password"}}
+	m.processEvent(&event{Event: &runtime.Event{
+		ID:        "foo",
+		Type:      runtime.Create,
+		Timestamp: time.Now(),
+		Service:   srv,
+		Options:   &runtime.CreateOptions{Namespace: ns},
+	}})
+	if rt.createCount != 0 {
+		t.Errorf("Expected the service not to be created")
+	}
+	statuses, err := m.listStatuses(ns)
+	if err != nil {
+		t.Fatalf("Unexpected error listing statuses: %v", err)
+	}
+	if s := statuses[srv.Name+":"+srv.Version]; s == nil || s.Status != "error" || !strings.Contains(s.Error, "DB_PASSWORD") {
+		t.Errorf("Expected the error resolving the secret in the status, got %+v", s)
+	}
+
+	// once the secret is set the service is created
+	if err := sec.Set(ns, "db/password", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Metadata = map[string]string{secrets.MetadataKey: "DB_PASSWORD=db/password"}
+	m.processEvent(&event{Event: &runtime.Event{
+		ID:        "bar",
+		Type:      runtime.Create,
+		Timestamp: time.Now(),
+		Service:   srv,
+		Options:   &runtime.CreateOptions{Namespace: ns},
+	}})
+	if rt.createCount != 1 {
+		t.Errorf("Expected the service to be created once its secrets are set")
+	}
+}
+
+// localRuntime is a test runtime which the manager creates options for as it does for the local one
+type localRuntime struct {
+	testRuntime
+}
+
+func (r *localRuntime) String() string {
+	return "local"
+}
+
+func TestCreateOptionsCell(t *testing.T) {
+	m := New(&localRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore())).(*manager)
+
+	// the local runtime joins the command into the path it executes, so the script must be an arg
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{
+		cells.CellKey:    cells.Node,
+		cells.CommandKey: "npm install && exec npm start",
+	}}
+	opts, err := m.createOptions(namespace.DefaultNamespace, srv, &runtime.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the options: %v", err)
+	}
+	var options runtime.CreateOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	if !reflect.DeepEqual(options.Command, []string{"sh"}) || !reflect.DeepEqual(options.Args, []string{"-c", "npm install && exec npm start"}) {
+		t.Errorf("Expected the cell command in the args of sh, got %v %v", options.Command, options.Args)
+	}
+}
+
+func TestBuildCacheLocal(t *testing.T) {
+	if _, err := osexec.LookPath("go"); err != nil {
+		t.Skip("go is required to build the service")
+	}
+	src, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	builds, err := ioutil.TempDir("", "builds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(builds)
+
+	// the service writes the file passed in its env once it's started
+	main := "package main\n\nimport (\n\t\"io/ioutil\"\n\t\"os\"\n\t\"time\"\n)\n\nfunc main() {\n\tioutil.WriteFile(os.Getenv(\"STARTED\"), nil, 0644)\n\ttime.Sleep(time.Minute)\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(src, "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "go.mod"), []byte("module foo\n\ngo 1.13\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	started := filepath.Join(src, "started")
+
+	// the go build cache of the test is used so the standard library isn't built again
+	gocache, err := osexec.Command("go", "env", "GOCACHE").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := &runtime.CreateOptions{Env: []string{"STARTED=" + started, "GOCACHE=" + strings.TrimSpace(string(gocache))}}
+
+	rt := runtime.NewRuntime()
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), BuildDir(builds)).(*manager)
+	ns := namespace.DefaultNamespace
+	cache := build.NewCache(builds)
+
+	for _, status := range []string{build.Miss, build.Hit} {
+		os.Remove(started)
+		srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Source: src}
+		if err := m.runtimeCreate(ns, srv, options); err != nil {
+			t.Fatalf("Unexpected error creating the service: %v", err)
+		}
+
+		deadline := time.Now().Add(time.Minute)
+		for _, err := os.Stat(started); err != nil; _, err = os.Stat(started) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the service to be started from the build cache")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if s := cache.Status(ns, srv.Name, srv.Version); s != status {
+			t.Errorf("Expected build status %v, got %v", status, s)
+		}
+		if err := rt.Delete(srv, runtime.DeleteNamespace(ns)); err != nil {
+			t.Fatalf("Unexpected error deleting the service: %v", err)
+		}
+	}
+}
@@ -1,6 +1,15 @@
 package manager
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/micro/go-micro/v2/broker"
 	"github.com/micro/go-micro/v2/config/cmd"
 	"github.com/micro/go-micro/v2/logger"
 	"github.com/micro/go-micro/v2/runtime"
@@ -9,6 +18,12 @@ import (
 	filest "github.com/micro/go-micro/v2/store/file"
 	"github.com/micro/go-micro/v2/store/memory"
 	"github.com/micro/micro/v2/internal/namespace"
+	"github.com/micro/micro/v2/service/runtime/build"
+	"github.com/micro/micro/v2/service/runtime/cells"
+	"github.com/micro/micro/v2/service/runtime/exec"
+	"github.com/micro/micro/v2/service/runtime/limits"
+	"github.com/micro/micro/v2/service/runtime/secrets"
+	"github.com/micro/micro/v2/service/store/conditional"
 )
 
 // Init initializes the runtime
@@ -27,6 +42,11 @@ func (m *manager) Create(srv *runtime.Service, opts ...runtime.CreateOption) err
 		options.Namespace = namespace.DefaultNamespace
 	}
 
+	// the outcome is written to the audit log when the service is created in the runtime, the
+	// context of the request isn't kept with the options
+	audit := requestAudit("create", options.Namespace, accountFromContext(options.Context), srv)
+	options.Context = nil
+
 	// set defaults
 	if srv.Metadata == nil {
 		srv.Metadata = make(map[string]string)
@@ -35,13 +55,42 @@ func (m *manager) Create(srv *runtime.Service, opts ...runtime.CreateOption) err
 		srv.Version = "latest"
 	}
 
+	// validate the resource limits and probes
+	if _, err := limits.FromMetadata(srv.Metadata); err != nil {
+		return err
+	}
+	if _, err := parseProbeConfig(srv.Metadata); err != nil {
+		return err
+	}
+	if _, err := parseReplicas(srv.Metadata); err != nil {
+		return err
+	}
+	if _, err := secrets.ParseRefs(srv.Metadata); err != nil {
+		return err
+	}
+	if options.Type == JobType {
+		// a run is created as a deployment by the kubernetes runtime, which is restarted when it
+		// exits rather than finishing
+		if m.Runtime.String() == "kubernetes" {
+			return fmt.Errorf("Jobs aren't supported by the kubernetes runtime")
+		}
+		if _, _, err := parseJob(srv.Metadata); err != nil {
+			return err
+		}
+	}
+
 	// write the object to the store
 	if err := m.createService(srv, &options); err != nil {
 		return err
 	}
 
+	// keep a record of the revision so the service can be rolled back
+	if _, err := m.writeRevision("create", srv, &options); err != nil {
+		return err
+	}
+
 	// publish the event, this will apply it aysnc to the runtime
-	return m.publishEvent(runtime.Create, srv, &options)
+	return m.publishEvent(runtime.Create, srv, &options, audit)
 }
 
 // Read returns the service which matches the criteria provided
@@ -77,6 +126,66 @@ func (m *manager) Read(opts ...runtime.ReadOption) ([]*runtime.Service, error) {
 		}
 		srv.Service.Metadata["status"] = md.Status
 		srv.Service.Metadata["error"] = md.Error
+		srv.Service.Metadata["restarts"] = strconv.Itoa(md.Restarts)
+		if md.ExitCode != nil {
+			srv.Service.Metadata["exit_code"] = strconv.Itoa(*md.ExitCode)
+		}
+		if len(md.Transitions) > 0 {
+			bytes, err := json.Marshal(md.Transitions)
+			if err != nil {
+				return nil, err
+			}
+			srv.Service.Metadata[TransitionsKey] = string(bytes)
+		}
+
+		// count the instances registered by running services
+		if md.Status == "running" {
+			if nodes, err := m.rolloutNodes(srv.Service.Name, rolloutTag(srv.Service.Version)); err == nil {
+				srv.Service.Metadata["instances"] = strconv.Itoa(len(nodes))
+			}
+		}
+	}
+
+	// add the recent runs of jobs
+	for _, srv := range srvs {
+		if !isJob(srv.Options) {
+			continue
+		}
+		if err := m.addRuns(options.Namespace, srv.Service); err != nil {
+			return nil, err
+		}
+	}
+
+	// add whether local services were started from a cached build
+	if m.Runtime.String() == "local" && len(m.options.BuildDir) > 0 {
+		builds := build.NewCache(m.options.BuildDir)
+		for _, srv := range ret {
+			if s := builds.Status(options.Namespace, srv.Name, srv.Version); len(s) > 0 {
+				srv.Metadata[BuildCacheKey] = s
+			}
+		}
+	}
+
+	// add the progress of the latest rollout and the result of the probes, if there are any
+	for _, srv := range ret {
+		r, err := m.readRollout(options.Namespace, srv)
+		if err != nil {
+			return nil, err
+		} else if r != nil {
+			srv.Metadata["rollout"] = r.String()
+		}
+
+		st, _, err := m.readProbeStatus(options.Namespace, srv)
+		if err != nil {
+			return nil, err
+		} else if st != nil {
+			restarts, _ := strconv.Atoi(srv.Metadata["restarts"])
+			srv.Metadata["restarts"] = strconv.Itoa(restarts + st.Restarts)
+			srv.Metadata["last_failure"] = st.LastFailure
+			if c, _ := parseProbeConfig(srv.Metadata); c != nil && c.Readiness != nil {
+				srv.Metadata["ready"] = strconv.FormatBool(st.Ready)
+			}
+		}
 	}
 
 	return ret, nil
@@ -98,8 +207,110 @@ func (m *manager) Update(srv *runtime.Service, opts ...runtime.UpdateOption) err
 		srv.Version = "latest"
 	}
 
+	// the outcome is written to the audit log when the update is applied
+	audit := requestAudit("update", options.Namespace, accountFromContext(options.Context), srv)
+
+	// check if the update should be rolled out gradually rather than applied in place
+	ro, err := parseRollout(srv)
+	if err != nil {
+		return err
+	}
+
+	// update the source of the service in the store and keep a record of the revision so the
+	// update can be rolled back
+	srvs, err := m.readServices(options.Namespace, srv)
+	if err != nil {
+		return err
+	}
+	if ro != nil {
+		if len(srvs) != 1 {
+			return fmt.Errorf("Service %v:%v not found", srv.Name, srv.Version)
+		}
+		if len(ro.Source) == 0 {
+			ro.Source = srvs[0].Service.Source
+		}
+		// the progress of the rollout is returned in the status of the service
+		if err := m.startRollout(options.Namespace, srvs[0], ro); err != nil {
+			return err
+		}
+		m.auditOutcome(audit, AuditSuccess, nil)
+		return nil
+	}
+	if len(srvs) == 1 {
+		s := srvs[0]
+		if len(srv.Source) > 0 {
+			s.Service.Source = srv.Source
+		}
+		if _, err := limits.FromMetadata(srv.Metadata); err != nil {
+			return err
+		}
+		if _, err := parseProbeConfig(srv.Metadata); err != nil {
+			return err
+		}
+		replicas, err := parseReplicas(srv.Metadata)
+		if err != nil {
+			return err
+		}
+		if _, err := secrets.ParseRefs(srv.Metadata); err != nil {
+			return err
+		}
+		if isJob(s.Options) {
+			// the job is run with the new source and schedule when it's next due
+			updateMetadata(s.Service, srv, []string{JobScheduleKey, JobConcurrencyKey, secrets.MetadataKey})
+			if _, _, err := parseJob(s.Service.Metadata); err != nil {
+				return err
+			}
+			if err := m.createService(s.Service, s.Options); err != nil {
+				return err
+			}
+			if _, err := m.writeRevision("update", s.Service, s.Options); err != nil {
+				return err
+			}
+			m.auditOutcome(audit, AuditSuccess, nil)
+			return nil
+		}
+		updateMetadata(s.Service, srv, ProbeKeys)
+		scaled := updateMetadata(s.Service, srv, []string{ReplicasKey})
+		changed := updateMetadata(s.Service, srv, limits.Keys)
+		if updateMetadata(s.Service, srv, []string{secrets.MetadataKey, cells.CellKey, cells.CommandKey}) {
+			changed = true
+		}
+
+		// scaling the service doesn't restart it, even if the number of replicas is unchanged. The
+		// replicas are reconciled with the runtime managed here and other managers reconcile theirs
+		// when they next sync.
+		_, scaleOnly := srv.Metadata[ReplicasKey]
+		scaleOnly = scaleOnly && len(srv.Source) == 0 && len(srv.Metadata) == 1
+		if !scaleOnly || scaled {
+			if err := m.createService(s.Service, s.Options); err != nil {
+				return err
+			}
+			if _, err := m.writeRevision("update", s.Service, s.Options); err != nil {
+				return err
+			}
+		}
+		if scaled || scaleOnly {
+			if err := m.scaleReplicas(options.Namespace, s.Service, s.Options, replicas); err != nil {
+				return err
+			}
+		}
+		if scaleOnly {
+			m.auditOutcome(audit, AuditSuccess, nil)
+			return nil
+		}
+
+		// the limits, secrets and cell command are set when the service is created so it needs to be
+		// recreated
+		if changed {
+			if err := m.publishEvent(runtime.Delete, s.Service, &runtime.CreateOptions{Namespace: options.Namespace}, nil); err != nil {
+				return err
+			}
+			return m.publishEvent(runtime.Create, s.Service, s.Options, audit)
+		}
+	}
+
 	// publish the update event which will trigger an update in the runtime
-	return m.publishEvent(runtime.Update, srv, &runtime.CreateOptions{Namespace: options.Namespace})
+	return m.publishEvent(runtime.Update, srv, &runtime.CreateOptions{Namespace: options.Namespace}, audit)
 }
 
 // Remove a service
@@ -118,13 +329,20 @@ func (m *manager) Delete(srv *runtime.Service, opts ...runtime.DeleteOption) err
 		srv.Version = "latest"
 	}
 
+	// the type is passed in the event so the runs of jobs are deleted rather than the service
+	evOpts := &runtime.CreateOptions{Namespace: options.Namespace}
+	if srvs, err := m.readServices(options.Namespace, srv); err == nil && len(srvs) == 1 && srvs[0].Options != nil {
+		evOpts.Type = srvs[0].Options.Type
+	}
+
 	// delete from the store
 	if err := m.deleteService(options.Namespace, srv); err != nil {
 		return err
 	}
 
 	// publish the event which will trigger a delete in the runtime
-	return m.publishEvent(runtime.Delete, srv, &runtime.CreateOptions{Namespace: options.Namespace})
+	audit := requestAudit("delete", options.Namespace, accountFromContext(options.Context), srv)
+	return m.publishEvent(runtime.Delete, srv, evOpts, audit)
 }
 
 // Starts the manager
@@ -138,13 +356,39 @@ func (m *manager) Start() error {
 	if err := m.Runtime.Start(); err != nil {
 		return err
 	}
+	if len(m.options.BuildDir) > 0 && m.Runtime.String() != "local" && m.Runtime.String() != "kubernetes" {
+		logger.Infof("The builds of services aren't cached by the %v runtime", m.Runtime.String())
+	}
 
-	// watch events written to the store
+	// subscribe to events published by other managers
+	if err := m.options.Broker.Connect(); err != nil {
+		return err
+	}
+	if err := m.subscribeEvents(); err != nil {
+		return err
+	}
+
+	// catch up on any events missed on the broker
 	go m.watchEvents()
 
 	// periodically load the status of services from the runtime
 	go m.watchStatus()
 
+	// periodically probe the health of services, restarting them if they fail
+	go m.watchProbes()
+
+	// periodically reconcile the replicas of services with the runtime
+	go m.watchReplicas()
+
+	// periodically start the runs of jobs which are due
+	go m.watchJobs()
+
+	// periodically roll back the rollouts left in progress by managers which stopped
+	go m.watchRollouts()
+
+	// periodically remove the state left behind by services which no longer exist
+	go m.watchGC()
+
 	// todo: compare the store to the runtime incase we missed any events
 
 	// Resurrect services that were running previously
@@ -174,18 +418,22 @@ func (m *manager) resurrectServices() {
 		}
 
 		for _, srv := range srvs {
+			// jobs are only run when they're due
+			if isJob(srv.Options) {
+				continue
+			}
 			if _, ok := running[srv.Service.Name+":"+srv.Service.Version+":"+srv.Service.Source]; ok {
 				// already running, don't need to start again
 				continue
 			}
-			m.Runtime.Create(srv.Service,
-				runtime.CreateImage(srv.Options.Image),
-				runtime.CreateType(srv.Options.Type),
-				runtime.CreateNamespace(ns),
-				runtime.WithArgs(srv.Options.Args...),
-				runtime.WithCommand(srv.Options.Command...),
-				runtime.WithEnv(m.runtimeEnv(srv.Options)),
-			)
+			if err := m.runtimeCreate(ns, srv.Service, srv.Options); err != nil {
+				logger.Warnf("Error starting service %v:%v in namespace %v: %v", srv.Service.Name, srv.Service.Version, ns, err)
+				m.cacheStatus(ns, &runtime.Service{
+					Name:     srv.Service.Name,
+					Version:  srv.Service.Version,
+					Metadata: map[string]string{"status": "error", "error": err.Error()},
+				})
+			}
 		}
 	}
 }
@@ -197,6 +445,17 @@ func (m *manager) Stop() error {
 	}
 	m.running = false
 
+	m.Lock()
+	sub := m.subscriber
+	m.subscriber = nil
+	m.Unlock()
+
+	if sub != nil {
+		if err := sub.Unsubscribe(); err != nil {
+			logger.Warnf("Error unsubscribing from events: %v", err)
+		}
+	}
+
 	return m.Runtime.Stop()
 }
 
@@ -208,6 +467,10 @@ func (m *manager) String() string {
 type manager struct {
 	// runtime being managed
 	runtime.Runtime
+	// id of the manager, which identifies the runs of jobs it started and the leases it holds
+	id string
+	// host the manager is running on
+	host string
 	// options passed by the caller
 	options Options
 	// running is true after Start is called
@@ -219,10 +482,48 @@ type manager struct {
 	// fileCache is a cache store used to store any information we don't want to write to the
 	// global store but want to persist across restarts, e.g. events consumed
 	fileCache store.Store
+	// versioned is the global store wrapped so leases can be written only if they haven't changed
+	// since they were read
+	versioned conditional.Store
+
+	sync.Mutex
+	// queues of events waiting to be processed, keyed by namespace:name:version
+	queues map[string]*eventQueue
+	// subscriber to the events published over the broker
+	subscriber broker.Subscriber
+	// statusWatchers are signalled when the status of a service in their namespace may have changed
+	statusWatchers map[chan struct{}]string
+}
+
+// Manager is a runtime which persists the services it manages, allowing them to be rolled back to
+// a previous revision
+type Manager interface {
+	runtime.Runtime
+	// History returns the revisions of a service, newest first
+	History(namespace string, srv *runtime.Service) ([]*Revision, error)
+	// Rollback a service to a revision, or the previous revision if zero. The account which
+	// requested it is written to the audit log with the outcome.
+	Rollback(namespace string, srv *runtime.Service, revision int64, account string) (*Revision, error)
+	// Runs returns the runs of a job, newest first
+	Runs(namespace string, srv *runtime.Service) ([]*Run, error)
+	// RunLogs returns the logs of a run of a job
+	RunLogs(namespace string, srv *runtime.Service, id string) ([]string, error)
+	// Exec runs a command in a running service, returning its exit code
+	Exec(namespace string, srv *runtime.Service, command []string, opts exec.Options) (int, error)
+	// Audit appends an event to the audit log
+	Audit(ev *AuditEvent) error
+	// AuditLog returns the events in the audit log written after the time, oldest first
+	AuditLog(namespace, service string, since time.Time) ([]*AuditEvent, error)
+	// GC removes the state of services which no longer exist, returning what was removed or
+	// would be if it's a dry run
+	GC(dryRun bool, account string) ([]*Orphan, error)
+	// WatchStatus returns a channel which receives when the status of a service in the namespace
+	// may have changed, and a func which stops the watch
+	WatchStatus(namespace string) (<-chan struct{}, func())
 }
 
 // New returns a manager for the runtime
-func New(r runtime.Runtime, opts ...Option) runtime.Runtime {
+func New(r runtime.Runtime, opts ...Option) Manager {
 	// parse the options
 	var options Options
 	for _, o := range opts {
@@ -236,11 +537,27 @@ func New(r runtime.Runtime, opts ...Option) runtime.Runtime {
 	if options.CacheStore == nil {
 		options.CacheStore = filest.NewStore()
 	}
+	if options.Broker == nil {
+		options.Broker = *cmd.DefaultCmd.Options().Broker
+	}
+	if options.Client == nil {
+		options.Client = *cmd.DefaultCmd.Options().Client
+	}
+	if options.Registry == nil {
+		options.Registry = *cmd.DefaultCmd.Options().Registry
+	}
+
+	host, _ := os.Hostname()
 
 	return &manager{
-		Runtime:   r,
-		options:   options,
-		cache:     memory.NewStore(),
-		fileCache: cachest.NewStore(options.CacheStore),
+		Runtime:        r,
+		id:             uuid.New().String(),
+		host:           host,
+		options:        options,
+		cache:          memory.NewStore(),
+		fileCache:      cachest.NewStore(options.CacheStore),
+		versioned:      conditional.Wrap(options.Store),
+		queues:         make(map[string]*eventQueue),
+		statusWatchers: make(map[chan struct{}]string),
 	}
 }
@@ -1,6 +1,12 @@
 package manager
 
-import "github.com/micro/go-micro/v2/store"
+import (
+	"github.com/micro/go-micro/v2/broker"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/micro/v2/service/runtime/secrets"
+)
 
 // Options for the runtime manager
 type Options struct {
@@ -11,6 +17,19 @@ type Options struct {
 	Store store.Store
 	// CacheStore for local rather than global storage
 	CacheStore store.Store
+	// Broker to publish and subscribe to events
+	Broker broker.Broker
+	// Client to check the health of services during a rollout
+	Client client.Client
+	// Registry to lookup the instances started by a rollout
+	Registry registry.Registry
+	// Secrets to resolve into env vars when a service is started
+	Secrets *secrets.Secrets
+	// UploadDir is the directory uploaded sources are kept in, unused sources are removed from it
+	UploadDir string
+	// BuildDir is the directory the builds of services are cached in. Kubernetes services mount it
+	// from the node they run on.
+	BuildDir string
 }
 
 // Option sets an option
@@ -36,3 +55,46 @@ func CacheStore(s store.Store) Option {
 		o.CacheStore = s
 	}
 }
+
+// Broker to publish and subscribe to events
+func Broker(b broker.Broker) Option {
+	return func(o *Options) {
+		o.Broker = b
+	}
+}
+
+// Client to check the health of services during a rollout
+func Client(c client.Client) Option {
+	return func(o *Options) {
+		o.Client = c
+	}
+}
+
+// Registry to lookup the instances started by a rollout
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+// Secrets to resolve into env vars when a service is started
+func Secrets(s *secrets.Secrets) Option {
+	return func(o *Options) {
+		o.Secrets = s
+	}
+}
+
+// UploadDir is the directory uploaded sources are kept in
+func UploadDir(dir string) Option {
+	return func(o *Options) {
+		o.UploadDir = dir
+	}
+}
+
+// BuildDir is the directory the builds of services are cached in. Kubernetes services mount it
+// from the node they run on.
+func BuildDir(dir string) Option {
+	return func(o *Options) {
+		o.BuildDir = dir
+	}
+}
@@ -0,0 +1,439 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/util/addr"
+	"github.com/micro/micro/v2/internal/rollout"
+	"github.com/micro/micro/v2/service/runtime/exec"
+	"github.com/micro/micro/v2/service/store/conditional"
+)
+
+const (
+	// ProbeLivenessKey is the metadata key for the probe which restarts the service when it fails
+	ProbeLivenessKey = "probe.liveness"
+	// ProbeReadinessKey is the metadata key for the probe which marks the service as not ready
+	// when it fails
+	ProbeReadinessKey = "probe.readiness"
+	// ProbeFailuresKey is the metadata key for the number of consecutive liveness failures
+	// before the service is restarted
+	ProbeFailuresKey = "probe.failures"
+	// ProbeIntervalKey is the metadata key for the duration between probes
+	ProbeIntervalKey = "probe.interval"
+	// ProbeDelayKey is the metadata key for the duration to wait after the service starts before
+	// probing it
+	ProbeDelayKey = "probe.delay"
+
+	// probePrefix is prefixed to the key for probe statuses written to the store
+	probePrefix = "probe:"
+	// probeRestartPrefix is prefixed to the id of the events queued to restart a service, the
+	// probe status isn't reset by them so the backoff continues
+	probeRestartPrefix = "restart-"
+)
+
+// ProbeKeys are the service metadata keys which configure probes
+var ProbeKeys = []string{ProbeLivenessKey, ProbeReadinessKey, ProbeFailuresKey, ProbeIntervalKey, ProbeDelayKey}
+
+var (
+	// probeFrequency is how often the manager checks for services which are due to be probed
+	probeFrequency = time.Second * 5
+	// probeTimeout is the max duration of a single probe
+	probeTimeout = time.Second * 5
+	// probeBackoff is the backoff after the first restart, it doubles for each consecutive restart
+	probeBackoff = time.Second * 10
+	// maxProbeBackoff is the max backoff between restarts
+	maxProbeBackoff = time.Minute * 5
+	// probeLeaseTTL is how long a manager probes a service for without renewing its lease, after
+	// which another manager takes over the probes
+	probeLeaseTTL = probeFrequency * 3
+
+	defaultProbeFailures = 3
+	defaultProbeInterval = time.Second * 10
+	defaultProbeDelay    = time.Second * 30
+)
+
+// Probe types
+const (
+	probeRPC  = "rpc"
+	probeHTTP = "http"
+	probeExec = "exec"
+)
+
+// probe checks the health of a service using either an RPC call to Debug.Health, a HTTP get or a
+// command exec'd in the service. Each instance of a service is probed by one manager, which holds
+// the lease on its probe status.
+type probe struct {
+	Type string
+	// Target is the url or command line probed
+	Target string
+}
+
+// parseProbe parses a probe, the formats are "rpc", "http://host:port/path", ":port/path" which is
+// a http probe on localhost, and "exec:command args"
+func parseProbe(v string) (*probe, error) {
+	switch {
+	case v == probeRPC:
+		return &probe{Type: probeRPC}, nil
+	case strings.HasPrefix(v, "http://"), strings.HasPrefix(v, "https://"):
+		return &probe{Type: probeHTTP, Target: v}, nil
+	case strings.HasPrefix(v, ":"):
+		return &probe{Type: probeHTTP, Target: "http://localhost" + v}, nil
+	case strings.HasPrefix(v, probeExec+":") && len(strings.TrimSpace(v[len(probeExec)+1:])) > 0:
+		return &probe{Type: probeExec, Target: strings.TrimSpace(v[len(probeExec)+1:])}, nil
+	default:
+		return nil, fmt.Errorf("Invalid probe %v, must be rpc, a http url or exec:command", v)
+	}
+}
+
+// probeConfig of a service
+type probeConfig struct {
+	Liveness  *probe
+	Readiness *probe
+	Failures  int
+	Interval  time.Duration
+	Delay     time.Duration
+}
+
+// parseProbeConfig parses the probes set in the metadata of a service, returning nil if there
+// are none
+func parseProbeConfig(md map[string]string) (*probeConfig, error) {
+	c := &probeConfig{
+		Failures: defaultProbeFailures,
+		Interval: defaultProbeInterval,
+		Delay:    defaultProbeDelay,
+	}
+
+	var err error
+	if v := md[ProbeLivenessKey]; len(v) > 0 {
+		if c.Liveness, err = parseProbe(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := md[ProbeReadinessKey]; len(v) > 0 {
+		if c.Readiness, err = parseProbe(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := md[ProbeFailuresKey]; len(v) > 0 {
+		if c.Failures, err = strconv.Atoi(v); err != nil || c.Failures < 1 {
+			return nil, fmt.Errorf("Invalid probe failures %v, must be at least 1", v)
+		}
+	}
+	if v := md[ProbeIntervalKey]; len(v) > 0 {
+		if c.Interval, err = time.ParseDuration(v); err != nil || c.Interval <= 0 {
+			return nil, fmt.Errorf("Invalid probe interval %v", v)
+		}
+	}
+	if v := md[ProbeDelayKey]; len(v) > 0 {
+		if c.Delay, err = time.ParseDuration(v); err != nil || c.Delay < 0 {
+			return nil, fmt.Errorf("Invalid probe delay %v", v)
+		}
+	}
+
+	if c.Liveness == nil && c.Readiness == nil {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// probeStatus of a service, it's written to the store so the manager which takes over the probes
+// continues the backoff, and returned in the service metadata on Runtime.Read
+type probeStatus struct {
+	// Failures is the number of consecutive liveness failures
+	Failures int
+	// Backoff is the number of consecutive restarts without a liveness probe passing
+	Backoff int
+	// Restarts is the total number of restarts
+	Restarts int
+	// Ready is false if the last readiness probe failed
+	Ready bool
+	// LastFailure is the reason the last probe failed
+	LastFailure string
+	// Next is when the service is next due to be probed
+	Next time.Time
+	// Manager is the id of the manager which holds the lease on probing the service
+	Manager string
+	// Expires is when the lease expires if the manager doesn't renew it
+	Expires time.Time
+}
+
+// probeKey to write the probe status to the store under. The local runtime runs an instance of the
+// service on the host of each manager, so the status of each host's instance is kept separately.
+func (m *manager) probeKey(ns string, srv *runtime.Service) string {
+	key := probePrefix + ns + ":" + srv.Name + ":" + srv.Version
+	if m.Runtime.String() == "local" {
+		key += ":" + m.host
+	}
+	return key
+}
+
+// readProbeStatus returns the probe status of a service, or nil if it hasn't been probed, and the
+// condition to write it on so it isn't written if it's changed since
+func (m *manager) readProbeStatus(ns string, srv *runtime.Service) (*probeStatus, conditional.Condition, error) {
+	recs, err := m.options.Store.Read(m.probeKey(ns, srv))
+	if err == store.ErrNotFound || err == nil && len(recs) == 0 {
+		return nil, conditional.Condition{Absent: true}, nil
+	} else if err != nil {
+		return nil, conditional.Condition{}, err
+	}
+
+	var s *probeStatus
+	if err := json.Unmarshal(recs[0].Value, &s); err != nil {
+		return nil, conditional.Condition{}, err
+	}
+	return s, conditional.Condition{Version: conditional.Version(recs[0])}, nil
+}
+
+// writeProbeStatus of a service to the store if the condition is met, the manager writing it
+// takes the lease
+func (m *manager) writeProbeStatus(ns string, srv *runtime.Service, s *probeStatus, c conditional.Condition) error {
+	s.Manager = m.id
+	s.Expires = time.Now().Add(probeLeaseTTL)
+	bytes, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	r := &store.Record{Key: m.probeKey(ns, srv), Value: bytes}
+	err = m.versioned.WriteIf(r, c)
+	if err == conditional.ErrUnsupported {
+		// the store can't check the version so the lease only holds if the writes don't race
+		return m.options.Store.Write(r)
+	}
+	return err
+}
+
+// resetProbeStatus removes the probe status of a service, e.g. when it's recreated
+func (m *manager) resetProbeStatus(ns string, srv *runtime.Service) {
+	if err := m.options.Store.Delete(m.probeKey(ns, srv)); err != nil && err != store.ErrNotFound {
+		logger.Warnf("Error resetting the probe status of service %v:%v: %v", srv.Name, srv.Version, err)
+	}
+}
+
+// watchProbes calls runProbes periodically and should be run in a seperate go routine
+func (m *manager) watchProbes() {
+	ticker := time.NewTicker(probeFrequency)
+
+	for {
+		m.runProbes()
+		<-ticker.C
+	}
+}
+
+// runProbes probes the services which are due to be probed
+func (m *manager) runProbes() {
+	namespaces, err := m.listNamespaces()
+	if err != nil {
+		logger.Warnf("Error listing namespaces: %v", err)
+		return
+	}
+
+	for _, ns := range namespaces {
+		srvs, err := m.readServices(ns, &runtime.Service{})
+		if err != nil {
+			logger.Warnf("Error reading services from the %v namespace: %v", ns, err)
+			return
+		}
+
+		for _, srv := range srvs {
+			c, err := parseProbeConfig(srv.Service.Metadata)
+			if err != nil {
+				logger.Warnf("Error parsing the probes of service %v:%v: %v", srv.Service.Name, srv.Service.Version, err)
+				continue
+			} else if c == nil {
+				continue
+			}
+
+			if err := m.probeService(ns, srv, c); err != nil {
+				logger.Warnf("Error probing service %v:%v: %v", srv.Service.Name, srv.Service.Version, err)
+			}
+		}
+	}
+}
+
+// probeService runs the probes of the service if it's due, restarting it once the liveness probe
+// has failed too many times. Each consecutive restart doubles the delay before it's probed again.
+// The service is only probed by the manager holding the lease on its probe status, which is
+// renewed each time it's checked. Another manager takes the lease once it expires.
+func (m *manager) probeService(ns string, srv *service, c *probeConfig) error {
+	now := time.Now()
+
+	st, cond, err := m.readProbeStatus(ns, srv.Service)
+	if err != nil {
+		return err
+	}
+	if st != nil && st.Manager != m.id && now.Before(st.Expires) {
+		return nil
+	}
+
+	// the service has just started so wait for the delay before probing it
+	if st == nil {
+		st = &probeStatus{Next: now.Add(c.Delay)}
+	}
+	if now.Before(st.Next) {
+		_, err := m.renewProbeStatus(ns, srv.Service, st, cond)
+		return err
+	}
+	st.Next = now.Add(c.Interval)
+
+	if c.Readiness != nil {
+		err := m.runProbe(ns, srv, c.Readiness)
+		st.Ready = err == nil
+		if err != nil {
+			st.LastFailure = "readiness: " + err.Error()
+		}
+	}
+
+	if c.Liveness != nil {
+		if err := m.runProbe(ns, srv, c.Liveness); err == nil {
+			st.Failures = 0
+			st.Backoff = 0
+		} else {
+			st.Failures++
+			st.LastFailure = "liveness: " + err.Error()
+		}
+	}
+
+	restart := st.Failures >= c.Failures
+	if restart {
+		st.Failures = 0
+		st.Restarts++
+		st.Backoff++
+		st.Next = now.Add(c.Delay + backoff(st.Backoff))
+	}
+
+	// the status is written before restarting the service, so it isn't restarted if the lease was
+	// taken by another manager while probing it
+	if held, err := m.renewProbeStatus(ns, srv.Service, st, cond); err != nil || !held || !restart {
+		return err
+	}
+	logger.Warnf("Restarting service %v:%v after %v failed liveness probes: %v", srv.Service.Name, srv.Service.Version, c.Failures, st.LastFailure)
+	m.queueRestart(ns, srv)
+	return nil
+}
+
+// renewProbeStatus writes the probe status, renewing the lease, and returns false if another
+// manager took the lease since the status was read, in which case it stops probing the service
+func (m *manager) renewProbeStatus(ns string, srv *runtime.Service, st *probeStatus, c conditional.Condition) (bool, error) {
+	if err := m.writeProbeStatus(ns, srv, st, c); err == conditional.ErrConflict {
+		logger.Debugf("The probes of service %v:%v are being run by another manager", srv.Name, srv.Version)
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// queueRestart queues the events which restart the instance of the service probed by this manager.
+// They're only queued locally since the other instances of the service are probed separately.
+func (m *manager) queueRestart(ns string, srv *service) {
+	now := time.Now()
+	m.queueEvent(&event{Event: &runtime.Event{
+		ID:        probeRestartPrefix + uuid.New().String(),
+		Type:      runtime.Delete,
+		Timestamp: now,
+		Service:   srv.Service,
+		Options:   &runtime.CreateOptions{Namespace: ns},
+	}})
+	m.queueEvent(&event{Event: &runtime.Event{
+		ID:        probeRestartPrefix + uuid.New().String(),
+		Type:      runtime.Create,
+		Timestamp: now.Add(time.Nanosecond),
+		Service:   srv.Service,
+		Options:   srv.Options,
+	}})
+}
+
+// backoff returns the delay before probing a service after a number of consecutive restarts
+func backoff(restarts int) time.Duration {
+	d := probeBackoff
+	for i := 1; i < restarts && d < maxProbeBackoff; i++ {
+		d *= 2
+	}
+	if d > maxProbeBackoff {
+		return maxProbeBackoff
+	}
+	return d
+}
+
+// runProbe returns an error if the probe fails
+func (m *manager) runProbe(ns string, srv *service, p *probe) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	switch p.Type {
+	case probeRPC:
+		nodes, err := m.probeNodes(srv.Service)
+		if err != nil {
+			return err
+		}
+		return m.checkNodes(srv.Service.Name, nodes)
+	case probeHTTP:
+		req, err := http.NewRequest(http.MethodGet, p.Target, nil)
+		if err != nil {
+			return err
+		}
+		rsp, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		rsp.Body.Close()
+		if rsp.StatusCode < 200 || rsp.StatusCode >= 400 {
+			return fmt.Errorf("%v returned %v", p.Target, rsp.Status)
+		}
+		return nil
+	case probeExec:
+		// the command is run in the service, the same as micro exec
+		var out bytes.Buffer
+		opts := exec.Options{Context: ctx, Stdout: &out, Stderr: &out}
+		code, err := m.execService(ns, srv, strings.Fields(p.Target), opts)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("exit status %v", code)
+		}
+		if err != nil && out.Len() > 0 {
+			return fmt.Errorf("%v: %v", err, strings.TrimSpace(out.String()))
+		}
+		return err
+	default:
+		return fmt.Errorf("Unknown probe type %v", p.Type)
+	}
+}
+
+// probeNodes returns the registered nodes of the service to probe. The local runtime runs an
+// instance of the service for every manager, so only the nodes on this host are probed.
+func (m *manager) probeNodes(srv *runtime.Service) ([]*registry.Node, error) {
+	nodes, err := m.rolloutNodes(srv.Name, rolloutTag(srv.Version))
+	if err != nil || m.Runtime.String() != "local" {
+		return nodes, err
+	}
+
+	var local []*registry.Node
+	for _, node := range nodes {
+		if addr.IsLocal(node.Address) {
+			local = append(local, node)
+		}
+	}
+	return local, nil
+}
+
+// rolloutTag returns the tag of the nodes registered by a version of a service, the versions
+// started by rollouts are suffixed with their tag
+func rolloutTag(version string) string {
+	for _, tag := range []string{rollout.Canary, rollout.Green} {
+		if strings.HasSuffix(version, "-"+tag) {
+			return tag
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package manager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/internal/namespace"
+)
+
+func TestParseProbe(t *testing.T) {
+	tt := []struct {
+		Probe  string
+		Type   string
+		Target string
+		Error  bool
+	}{
+		{Probe: "rpc", Type: probeRPC},
+		{Probe: "http://localhost:8080/health", Type: probeHTTP, Target: "http://localhost:8080/health"},
+		{Probe: ":8080/health", Type: probeHTTP, Target: "http://localhost:8080/health"},
+		{Probe: "exec: ./check.sh --quick", Type: probeExec, Target: "./check.sh --quick"},
+		{Probe: "exec:", Error: true},
+		{Probe: "tcp://localhost:8080", Error: true},
+	}
+
+	for _, tc := range tt {
+		p, err := parseProbe(tc.Probe)
+		if tc.Error {
+			if err == nil {
+				t.Errorf("Expected an error for probe %v", tc.Probe)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for probe %v: %v", tc.Probe, err)
+			continue
+		}
+		if p.Type != tc.Type || p.Target != tc.Target {
+			t.Errorf("Unexpected probe for %v: %+v", tc.Probe, p)
+		}
+	}
+}
+
+func TestProbeRestart(t *testing.T) {
+	rt := &testRuntime{events: make(chan *runtime.Service, 2)}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore())).(*manager)
+
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer live.Close()
+	ready := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ready.Close()
+
+	ns := namespace.DefaultNamespace
+	srv := &service{
+		Service: &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{
+			ProbeLivenessKey:  live.URL,
+			ProbeReadinessKey: ready.URL,
+			ProbeFailuresKey:  "2",
+			ProbeIntervalKey:  "1ns",
+			ProbeDelayKey:     "0s",
+		}},
+		Options: &runtime.CreateOptions{Namespace: ns},
+	}
+	c, err := parseProbeConfig(srv.Service.Metadata)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing the probes: %v", err)
+	}
+
+	// the first call starts the delay, the next two fail the liveness probe which should restart
+	// the service
+	for i := 0; i < 3; i++ {
+		if err := m.probeService(ns, srv, c); err != nil {
+			t.Fatalf("Unexpected error probing the service: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the restart is applied by the event queue of the service
+	for i := 0; i < 2; i++ {
+		select {
+		case <-rt.events:
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for the service to be restarted")
+		}
+	}
+	if rt.deleteCount != 1 || rt.createCount != 1 {
+		t.Errorf("Expected the service to be restarted once, got %v deletes and %v creates", rt.deleteCount, rt.createCount)
+	}
+
+	st, _, err := m.readProbeStatus(ns, srv.Service)
+	if err != nil {
+		t.Fatalf("Unexpected error reading the probe status: %v", err)
+	}
+	if st.Restarts != 1 || !st.Ready || len(st.LastFailure) == 0 {
+		t.Errorf("Unexpected probe status: %+v", st)
+	}
+
+	// the service shouldn't be probed again until the backoff has passed
+	if !st.Next.After(time.Now().Add(probeBackoff / 2)) {
+		t.Errorf("Expected the next probe to be after the backoff, got %v", st.Next)
+	}
+
+	if backoff(1) != probeBackoff || backoff(2) != probeBackoff*2 || backoff(100) != maxProbeBackoff {
+		t.Errorf("Unexpected backoff")
+	}
+}
+
+func TestProbeLease(t *testing.T) {
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer live.Close()
+
+	// the managers share the store, as they would with the kubernetes runtime
+	s := memory.NewStore()
+	m1 := New(&testRuntime{}, Store(s), CacheStore(memory.NewStore())).(*manager)
+	m2 := New(&testRuntime{}, Store(s), CacheStore(memory.NewStore())).(*manager)
+
+	ns := namespace.DefaultNamespace
+	srv := &service{
+		Service: &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{
+			ProbeLivenessKey: live.URL,
+			ProbeIntervalKey: "1ns",
+			ProbeDelayKey:    "0s",
+		}},
+		Options: &runtime.CreateOptions{Namespace: ns},
+	}
+	c, err := parseProbeConfig(srv.Service.Metadata)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing the probes: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		for _, m := range []*manager{m1, m2} {
+			if err := m.probeService(ns, srv, c); err != nil {
+				t.Fatalf("Unexpected error probing the service: %v", err)
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	st, _, err := m2.readProbeStatus(ns, srv.Service)
+	if err != nil {
+		t.Fatalf("Unexpected error reading the probe status: %v", err)
+	}
+	if st.Manager != m1.id {
+		t.Errorf("Expected the first manager to hold the lease, got %v", st.Manager)
+	}
+
+	// the lease passes to the other manager once it expires
+	st.Expires = time.Now().Add(-time.Second)
+	bytes, _ := json.Marshal(st)
+	if err := s.Write(&store.Record{Key: m1.probeKey(ns, srv.Service), Value: bytes}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.probeService(ns, srv, c); err != nil {
+		t.Fatalf("Unexpected error probing the service: %v", err)
+	}
+	if st, _, _ = m1.readProbeStatus(ns, srv.Service); st.Manager != m2.id {
+		t.Errorf("Expected the second manager to take the expired lease, got %v", st.Manager)
+	}
+}
@@ -0,0 +1,195 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/runtime"
+)
+
+const (
+	// ReplicasKey is the service metadata key for the number of replicas of the service to run
+	ReplicasKey = "replicas"
+
+	// replicaSuffix separates the version of a service from the index of the replica. The first
+	// replica is the service itself, the others are created in the runtime with suffixed versions
+	// e.g. "latest-replica-1", so each one is a separate process which registers its own node.
+	// Kubernetes services are scaled by the replicas of their deployment instead.
+	replicaSuffix = "-replica-"
+)
+
+// replicaPollFrequency is how often the manager reconciles the replicas running in the runtime with
+// those in the store
+var replicaPollFrequency = time.Second * 30
+
+// parseReplicas returns the number of replicas set in the metadata, defaulting to one
+func parseReplicas(md map[string]string) (int, error) {
+	v, ok := md[ReplicasKey]
+	if !ok || len(v) == 0 {
+		return 1, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("Invalid replicas %v, must be at least 1", v)
+	}
+	return n, nil
+}
+
+// replicaVersion returns the version the replica is created with in the runtime
+func replicaVersion(version string, index int) string {
+	return version + replicaSuffix + strconv.Itoa(index)
+}
+
+// replicaIndex returns the index of the replica if the runtime version is a replica of the version
+func replicaIndex(version, runtimeVersion string) (int, bool) {
+	prefix := version + replicaSuffix
+	if !strings.HasPrefix(runtimeVersion, prefix) {
+		return 0, false
+	}
+	i, err := strconv.Atoi(strings.TrimPrefix(runtimeVersion, prefix))
+	if err != nil || i < 1 {
+		return 0, false
+	}
+	return i, true
+}
+
+// replica returns a copy of the service to create in the runtime as the replica
+func replica(srv *runtime.Service, index int) *runtime.Service {
+	md := make(map[string]string, len(srv.Metadata))
+	for k, v := range srv.Metadata {
+		md[k] = v
+	}
+
+	return &runtime.Service{
+		Name:     srv.Name,
+		Version:  replicaVersion(srv.Version, index),
+		Source:   srv.Source,
+		Metadata: md,
+	}
+}
+
+// watchReplicas calls syncReplicas periodically and should be run in a seperate go routine
+func (m *manager) watchReplicas() {
+	ticker := time.NewTicker(replicaPollFrequency)
+
+	for {
+		m.syncReplicas()
+		<-ticker.C
+	}
+}
+
+// syncReplicas reconciles the replicas of every service in the store, e.g. after they've been
+// scaled or a replica has been removed from the runtime
+func (m *manager) syncReplicas() {
+	namespaces, err := m.listNamespaces()
+	if err != nil {
+		logger.Warnf("Error listing namespaces: %v", err)
+		return
+	}
+
+	for _, ns := range namespaces {
+		srvs, err := m.readServices(ns, &runtime.Service{})
+		if err != nil {
+			logger.Warnf("Error reading services from the %v namespace: %v", ns, err)
+			return
+		}
+
+		for _, srv := range srvs {
+			n, err := parseReplicas(srv.Service.Metadata)
+			if err != nil {
+				logger.Warnf("Error parsing the replicas of service %v:%v: %v", srv.Service.Name, srv.Service.Version, err)
+				continue
+			}
+			if err := m.scaleReplicas(ns, srv.Service, srv.Options, n); err != nil {
+				logger.Warnf("Error scaling service %v:%v: %v", srv.Service.Name, srv.Service.Version, err)
+			}
+		}
+	}
+}
+
+// scaleReplicas creates or deletes replicas of the service in the runtime so n are running,
+// including the service itself. Kubernetes runs the replicas of the deployment of the service.
+func (m *manager) scaleReplicas(ns string, srv *runtime.Service, opts *runtime.CreateOptions, n int) error {
+	running, err := m.Runtime.Read(runtime.ReadNamespace(ns), runtime.ReadService(srv.Name))
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[int]*runtime.Service)
+	for _, r := range running {
+		if i, ok := replicaIndex(srv.Version, r.Version); ok {
+			existing[i] = r
+		}
+	}
+
+	// the replicas of kubernetes services are pods of the same deployment, any created as separate
+	// deployments are removed
+	kubernetes := m.Runtime.String() == "kubernetes"
+	if kubernetes && n > 0 {
+		if err := m.scaleDeployment(ns, srv, n); err != nil {
+			return err
+		}
+	}
+
+	for i := 1; i < n && !kubernetes; i++ {
+		if _, ok := existing[i]; ok {
+			continue
+		}
+		logger.Infof("Creating replica %v of service %v:%v in namespace %v", i, srv.Name, srv.Version, ns)
+		r := replica(srv, i)
+		if err := m.runtimeCreate(ns, r, opts); err != nil {
+			return err
+		}
+	}
+
+	for i, r := range existing {
+		if i < n && !kubernetes {
+			continue
+		}
+		logger.Infof("Deleting replica %v of service %v:%v in namespace %v", i, srv.Name, srv.Version, ns)
+		if err := m.Runtime.Delete(r, runtime.DeleteNamespace(ns)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scaleDeployment sets the replicas of the deployment of a kubernetes service, the runtime keeps
+// them when it updates the deployment
+func (m *manager) scaleDeployment(ns string, srv *runtime.Service, n int) error {
+	k, err := newKubernetes()
+	if err != nil {
+		return fmt.Errorf("Error scaling the deployment of the service: %v", err)
+	}
+	if err := patchKubernetes(k, ns, srv, map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": n},
+	}); err != nil {
+		return fmt.Errorf("Error scaling the deployment of the service: %v", err)
+	}
+	return nil
+}
+
+// updateReplicas updates the replicas of the service running in the runtime
+func (m *manager) updateReplicas(ns string, srv *runtime.Service) error {
+	running, err := m.Runtime.Read(runtime.ReadNamespace(ns), runtime.ReadService(srv.Name))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range running {
+		i, ok := replicaIndex(srv.Version, r.Version)
+		if !ok {
+			continue
+		}
+		if err := m.Runtime.Update(replica(srv, i), runtime.UpdateNamespace(ns)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/internal/namespace"
+	"github.com/micro/micro/v2/service/runtime/exec"
+)
+
+// replicaRuntime keeps the services created in it so they can be read back
+type replicaRuntime struct {
+	services map[string]*runtime.Service
+	runtime.Runtime
+}
+
+func (r *replicaRuntime) Create(srv *runtime.Service, opts ...runtime.CreateOption) error {
+	r.services[srv.Version] = srv
+	return nil
+}
+
+func (r *replicaRuntime) Update(srv *runtime.Service, opts ...runtime.UpdateOption) error {
+	r.services[srv.Version] = srv
+	return nil
+}
+
+func (r *replicaRuntime) Delete(srv *runtime.Service, opts ...runtime.DeleteOption) error {
+	delete(r.services, srv.Version)
+	return nil
+}
+
+func (r *replicaRuntime) Read(opts ...runtime.ReadOption) ([]*runtime.Service, error) {
+	var srvs []*runtime.Service
+	for _, s := range r.services {
+		srvs = append(srvs, s)
+	}
+	return srvs, nil
+}
+
+func (r *replicaRuntime) String() string {
+	return "test"
+}
+
+func TestReplicas(t *testing.T) {
+	rt := &replicaRuntime{services: make(map[string]*runtime.Service)}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	ns := namespace.DefaultNamespace
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{ReplicasKey: "3"}}
+	opts := &runtime.CreateOptions{Namespace: ns}
+
+	// creating the service should create the replicas alongside it
+	rt.Create(srv)
+	if err := m.processReplicas(ns, &runtime.Event{Type: runtime.Create, Service: srv, Options: opts}); err != nil {
+		t.Fatalf("Unexpected error creating replicas: %v", err)
+	}
+	if len(rt.services) != 3 {
+		t.Fatalf("Expected 3 services in the runtime, got %v", len(rt.services))
+	}
+	if _, ok := rt.services["latest-replica-2"]; !ok {
+		t.Errorf("Expected replica 2 to be created")
+	}
+
+	// scaling down should remove the replicas with the highest index
+	if err := m.scaleReplicas(ns, srv, opts, 2); err != nil {
+		t.Fatalf("Unexpected error scaling replicas: %v", err)
+	}
+	if _, ok := rt.services["latest-replica-2"]; ok || len(rt.services) != 2 {
+		t.Errorf("Expected replica 2 to be deleted, got %v services", len(rt.services))
+	}
+
+	// deleting the service should remove its replicas
+	delete(rt.services, srv.Version)
+	if err := m.processReplicas(ns, &runtime.Event{Type: runtime.Delete, Service: srv, Options: opts}); err != nil {
+		t.Fatalf("Unexpected error deleting replicas: %v", err)
+	}
+	if len(rt.services) != 0 {
+		t.Errorf("Expected no services in the runtime, got %v", len(rt.services))
+	}
+
+	if _, err := parseReplicas(map[string]string{ReplicasKey: "0"}); err == nil {
+		t.Errorf("Expected an error for zero replicas")
+	}
+}
+
+func TestScaleUnchanged(t *testing.T) {
+	str := memory.NewStore()
+	rt := &replicaRuntime{services: make(map[string]*runtime.Service)}
+	m := New(rt, Store(str), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{ReplicasKey: "2"}}
+	if err := m.Create(srv); err != nil {
+		t.Fatalf("Unexpected error creating the service: %v", err)
+	}
+	events := func() int {
+		recs, _ := str.Read(eventPrefix, store.ReadPrefix())
+		return len(recs)
+	}
+	created := events()
+
+	// scaling to the replicas the service already has shouldn't restart it
+	scale := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{ReplicasKey: "2"}}
+	if err := m.Update(scale); err != nil {
+		t.Fatalf("Unexpected error scaling the service: %v", err)
+	}
+	if n := events() - created; n != 0 {
+		t.Errorf("Expected no events publishing scaling to the same replicas, got %v", n)
+	}
+}
+
+func TestReplicasKubernetes(t *testing.T) {
+	var patches []string
+	var replicas int
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var patch struct {
+			Spec struct {
+				Replicas int `json:"replicas"`
+			} `json:"spec"`
+		}
+		json.NewDecoder(r.Body).Decode(&patch)
+		patches = append(patches, r.Method+" "+r.URL.Path)
+		replicas = patch.Spec.Replicas
+	}))
+	defer ts.Close()
+	defer func(f func() (*exec.Kubernetes, error)) { newKubernetes = f }(newKubernetes)
+	newKubernetes = func() (*exec.Kubernetes, error) {
+		return &exec.Kubernetes{
+			Host: strings.TrimPrefix(ts.URL, "https://"),
+			TLS:  ts.Client().Transport.(*http.Transport).TLSClientConfig,
+		}, nil
+	}
+
+	// the replicas of kubernetes services are run by their deployment, not created as services
+	rt := &kubernetesReplicaRuntime{replicaRuntime{services: make(map[string]*runtime.Service)}}
+	m := New(rt, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+	ns := namespace.DefaultNamespace
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{ReplicasKey: "3"}}
+	rt.Create(srv)
+	rt.Create(replica(srv, 1))
+
+	if err := m.processReplicas(ns, &runtime.Event{Type: runtime.Create, Service: srv, Options: &runtime.CreateOptions{Namespace: ns}}); err != nil {
+		t.Fatalf("Unexpected error creating replicas: %v", err)
+	}
+	expected := "PATCH /apis/apps/v1/namespaces/micro/deployments/go-micro-service-foo-latest"
+	if len(patches) != 1 || patches[0] != expected || replicas != 3 {
+		t.Fatalf("Expected the deployment to be scaled to 3 replicas, got %v replicas from %v", replicas, patches)
+	}
+	if _, ok := rt.services["latest-replica-1"]; ok || len(rt.services) != 1 {
+		t.Errorf("Expected the replica created as a service to be deleted, got %v services", len(rt.services))
+	}
+}
+
+type kubernetesReplicaRuntime struct {
+	replicaRuntime
+}
+
+func (r *kubernetesReplicaRuntime) String() string {
+	return "kubernetes"
+}
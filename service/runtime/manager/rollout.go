@@ -0,0 +1,543 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/v2/client"
+	debug "github.com/micro/go-micro/v2/debug/service/proto"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/micro/v2/internal/rollout"
+	stats "github.com/micro/micro/v2/service/debug/stats/proto"
+)
+
+const (
+	// StrategyCanary runs the new version alongside the old one with a percentage of the traffic
+	StrategyCanary = "canary"
+	// StrategyBlueGreen starts the new version and switches to it once it's healthy
+	StrategyBlueGreen = "bluegreen"
+
+	// RolloutStrategyKey is the metadata key used to select a rollout strategy on update
+	RolloutStrategyKey = "rollout.strategy"
+	// RolloutTrafficKey is the metadata key for the percentage of traffic sent to a canary
+	RolloutTrafficKey = "rollout.traffic"
+	// RolloutDurationKey is the metadata key for how long a canary runs before it's promoted
+	RolloutDurationKey = "rollout.duration"
+	// RolloutMaxErrorRateKey is the metadata key for the error rate which triggers a rollback
+	RolloutMaxErrorRateKey = "rollout.max_error_rate"
+
+	// rolloutPrefix is prefixed to the key for rollout records
+	rolloutPrefix = "rollout:"
+)
+
+var (
+	// rolloutCheckInterval is how often the health and error rate of a rollout is checked
+	rolloutCheckInterval = time.Second * 10
+	// rolloutHealthTimeout is how long new instances have to become healthy
+	rolloutHealthTimeout = time.Minute * 2
+	// defaultRolloutDuration is how long a canary runs before it's promoted
+	defaultRolloutDuration = time.Minute * 5
+	// rolloutStaleAfter is how long a rollout in progress can go without being updated before it's
+	// assumed the manager running it stopped
+	rolloutStaleAfter = rolloutCheckInterval * 3
+	// defaultMaxErrorRate is the error rate which triggers a rollback
+	defaultMaxErrorRate = 0.05
+	// defaultTraffic is the percentage of traffic sent to a canary
+	defaultTraffic = 10
+	// debugService is the name of the service which collects stats
+	debugService = "go.micro.debug"
+)
+
+// Rollout phases
+const (
+	phaseStarting   = "starting"
+	phaseMonitoring = "monitoring"
+	phaseSwitching  = "switching"
+	phaseComplete   = "complete"
+	phaseRolledBack = "rolled back"
+)
+
+// Rollout of an update to a service
+type Rollout struct {
+	// Strategy used, canary or bluegreen
+	Strategy string `json:"strategy"`
+	// Traffic is the percentage of traffic sent to a canary
+	Traffic int `json:"traffic"`
+	// Duration a canary runs before it's promoted
+	Duration time.Duration `json:"duration"`
+	// MaxErrorRate which triggers a rollback, between 0 and 1
+	MaxErrorRate float64 `json:"max_error_rate"`
+	// Source being rolled out
+	Source string `json:"source"`
+	// Phase of the rollout
+	Phase string `json:"phase"`
+	// Message describing the progress, or why the rollout was rolled back
+	Message string `json:"message"`
+	// Started is when the rollout started
+	Started time.Time `json:"started"`
+	// Updated is when the rollout was last written by the manager running it, which rewrites it
+	// periodically while it's in progress
+	Updated time.Time `json:"updated"`
+	// Previous is the source of the service before the rollout, which is restored if the
+	// rollout is abandoned after switching the service to the new source
+	Previous string `json:"previous"`
+}
+
+// inProgress returns true if the rollout hasn't completed or been rolled back
+func (r *Rollout) inProgress() bool {
+	return r.Phase != phaseComplete && r.Phase != phaseRolledBack
+}
+
+// String describes the progress of the rollout, it's used in the service metadata
+func (r *Rollout) String() string {
+	s := r.Strategy
+	if r.Strategy == StrategyCanary {
+		s += fmt.Sprintf(" %d%%", r.Traffic)
+	}
+	s += " " + r.Phase
+	if len(r.Message) > 0 {
+		s += ": " + r.Message
+	}
+	return s
+}
+
+// parseRollout returns the rollout requested in the metadata of the service passed to update, or
+// nil if the update should be applied in place. The rollout keys are removed from the metadata.
+func parseRollout(srv *runtime.Service) (*Rollout, error) {
+	md := srv.Metadata
+	strategy := md[RolloutStrategyKey]
+
+	defer func() {
+		delete(md, RolloutStrategyKey)
+		delete(md, RolloutTrafficKey)
+		delete(md, RolloutDurationKey)
+		delete(md, RolloutMaxErrorRateKey)
+	}()
+
+	switch strategy {
+	case "":
+		return nil, nil
+	case StrategyCanary, StrategyBlueGreen:
+	default:
+		return nil, fmt.Errorf("Unknown rollout strategy %v", strategy)
+	}
+
+	r := &Rollout{
+		Strategy:     strategy,
+		Traffic:      defaultTraffic,
+		Duration:     defaultRolloutDuration,
+		MaxErrorRate: defaultMaxErrorRate,
+		Source:       srv.Source,
+		Phase:        phaseStarting,
+		Started:      time.Now(),
+	}
+
+	if v := md[RolloutTrafficKey]; len(v) > 0 {
+		t, err := strconv.Atoi(v)
+		if err != nil || t < 1 || t > 99 {
+			return nil, fmt.Errorf("Invalid traffic %v, must be a percentage between 1 and 99", v)
+		}
+		r.Traffic = t
+	}
+	if v := md[RolloutDurationKey]; len(v) > 0 {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("Invalid duration %v", v)
+		}
+		r.Duration = d
+	}
+	if v := md[RolloutMaxErrorRateKey]; len(v) > 0 {
+		e, err := strconv.ParseFloat(v, 64)
+		if err != nil || e < 0 || e > 1 {
+			return nil, fmt.Errorf("Invalid max error rate %v, must be between 0 and 1", v)
+		}
+		r.MaxErrorRate = e
+	}
+
+	return r, nil
+}
+
+// rolloutKey to write the rollout to the store under
+func rolloutKey(ns string, srv *runtime.Service) string {
+	return rolloutPrefix + ns + ":" + srv.Name + ":" + srv.Version
+}
+
+// writeRollout persists the progress of a rollout in the global store so it can be read by any
+// manager
+func (m *manager) writeRollout(ns string, srv *runtime.Service, r *Rollout) error {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return m.options.Store.Write(&store.Record{Key: rolloutKey(ns, srv), Value: bytes})
+}
+
+// readRollout returns the latest rollout of a service, or nil if there hasn't been one
+func (m *manager) readRollout(ns string, srv *runtime.Service) (*Rollout, error) {
+	recs, err := m.options.Store.Read(rolloutKey(ns, srv))
+	if err == store.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var r *Rollout
+	if err := json.Unmarshal(recs[0].Value, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// startRollout validates a rollout can be started for the service and then runs it in the
+// background
+func (m *manager) startRollout(ns string, srv *service, r *Rollout) error {
+	if curr, err := m.readRollout(ns, srv.Service); err != nil {
+		return err
+	} else if curr != nil && curr.inProgress() {
+		return fmt.Errorf("Service %v:%v already has a rollout in progress", srv.Service.Name, srv.Service.Version)
+	}
+
+	r.Previous = srv.Service.Source
+	r.Updated = time.Now()
+	if err := m.writeRollout(ns, srv.Service, r); err != nil {
+		return err
+	}
+
+	go m.runRollout(ns, srv, r)
+	return nil
+}
+
+// rolloutSuffix returns the suffix of the version the new version is run with during a rollout
+func rolloutSuffix(r *Rollout) string {
+	if r.Strategy == StrategyBlueGreen {
+		return rollout.Green
+	}
+	return rollout.Canary
+}
+
+// runRollout runs the rollout to completion, rolling back if the new version isn't healthy
+func (m *manager) runRollout(ns string, srv *service, r *Rollout) {
+	// the new version runs alongside the current one using a suffixed version
+	suffix := rolloutSuffix(r)
+	next := &runtime.Service{
+		Name:     srv.Service.Name,
+		Version:  srv.Service.Version + "-" + suffix,
+		Source:   r.Source,
+		Metadata: map[string]string{},
+	}
+
+	// tag the nodes registered by the new version so they can be found and routed to
+	opts := *srv.Options
+	opts.Env = append(append([]string{}, opts.Env...), fmt.Sprintf("MICRO_SERVER_METADATA=%v=%v,%v=%v",
+		rollout.MetadataKey, suffix, rollout.TrafficKey, r.Traffic))
+
+	// the rollout is rewritten periodically so other managers know it's still running
+	var mtx sync.Mutex
+	write := func() {
+		r.Updated = time.Now()
+		if err := m.writeRollout(ns, srv.Service, r); err != nil {
+			logger.Warnf("Error writing rollout for service %v:%v: %v", srv.Service.Name, srv.Service.Version, err)
+		}
+	}
+	update := func(phase, msg string) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		r.Phase = phase
+		r.Message = msg
+		write()
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(rolloutCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mtx.Lock()
+				write()
+				mtx.Unlock()
+			}
+		}
+	}()
+
+	// remove the new version, leaving the current one in place
+	rollback := func(reason string) {
+		logger.Warnf("Rolling back %v rollout of service %v:%v: %v", r.Strategy, srv.Service.Name, srv.Service.Version, reason)
+		m.removeService(ns, next)
+		update(phaseRolledBack, reason)
+	}
+
+	logger.Infof("Starting %v rollout of service %v:%v", r.Strategy, srv.Service.Name, srv.Service.Version)
+	if err := m.createService(next, &opts); err != nil {
+		update(phaseRolledBack, err.Error())
+		return
+	}
+	if err := m.publishEvent(runtime.Create, next, &opts, nil); err != nil {
+		rollback(err.Error())
+		return
+	}
+
+	// wait for the new version to become healthy
+	if err := m.waitHealthy(next.Name, suffix, nil); err != nil {
+		rollback(err.Error())
+		return
+	}
+
+	// canaries are monitored for the duration of the rollout, any errors trigger a rollback
+	if r.Strategy == StrategyCanary {
+		deadline := time.Now().Add(r.Duration)
+		for time.Now().Before(deadline) {
+			update(phaseMonitoring, fmt.Sprintf("%v remaining", time.Until(deadline).Truncate(time.Second)))
+			time.Sleep(rolloutCheckInterval)
+
+			if err := m.checkHealth(next.Name, suffix, nil); err != nil {
+				rollback(err.Error())
+				return
+			}
+			if rate, err := m.errorRate(next.Name, suffix); err != nil {
+				logger.Warnf("Error reading the error rate of service %v: %v", next.Name, err)
+			} else if rate > r.MaxErrorRate {
+				rollback(fmt.Sprintf("error rate %.2f exceeded %.2f", rate, r.MaxErrorRate))
+				return
+			}
+		}
+	}
+
+	// switch the current version over to the new source. Whilst it restarts the new version
+	// continues to serve traffic. The nodes of the current version may still be registered until
+	// it's restarted, so only the nodes registered since are checked.
+	update(phaseSwitching, "")
+	old, err := m.rolloutNodes(srv.Service.Name, "")
+	if err != nil {
+		rollback(err.Error())
+		return
+	}
+	replaced := make(map[string]bool, len(old))
+	for _, node := range old {
+		replaced[node.Id] = true
+	}
+	if err := m.switchSource(ns, srv, r.Source); err != nil {
+		rollback(err.Error())
+		return
+	}
+	if err := m.waitHealthy(srv.Service.Name, "", replaced); err != nil {
+		// restore the previous source before removing the new version
+		if err := m.switchSource(ns, srv, r.Previous); err != nil {
+			logger.Warnf("Error restoring service %v:%v: %v", srv.Service.Name, srv.Service.Version, err)
+		}
+		rollback(err.Error())
+		return
+	}
+
+	m.removeService(ns, next)
+	update(phaseComplete, "")
+	logger.Infof("Completed %v rollout of service %v:%v", r.Strategy, srv.Service.Name, srv.Service.Version)
+}
+
+// watchRollouts calls abandonRollouts periodically and should be run in a seperate go routine
+func (m *manager) watchRollouts() {
+	ticker := time.NewTicker(rolloutCheckInterval)
+
+	for {
+		m.abandonRollouts(time.Now())
+		<-ticker.C
+	}
+}
+
+// abandonRollouts rolls back the rollouts in progress which haven't been updated recently, since
+// the manager running them must have stopped, e.g. restarted part way through. Otherwise the
+// service would be stuck with a rollout in progress which blocks any other.
+func (m *manager) abandonRollouts(now time.Time) {
+	recs, err := m.options.Store.Read(rolloutPrefix, store.ReadPrefix())
+	if err != nil {
+		logger.Warnf("Error reading rollouts: %v", err)
+		return
+	}
+
+	for _, rec := range recs {
+		ns, name, version, ok := parseKey(rec.Key, rolloutPrefix)
+		if !ok {
+			continue
+		}
+		var r *Rollout
+		if err := json.Unmarshal(rec.Value, &r); err != nil {
+			logger.Warnf("Error decoding rollout %v: %v", rec.Key, err)
+			continue
+		}
+		if !r.inProgress() || now.Sub(r.Updated) < rolloutStaleAfter {
+			continue
+		}
+
+		logger.Warnf("Rolling back %v rollout of service %v:%v, the manager running it stopped", r.Strategy, name, version)
+		srvs, err := m.readServices(ns, &runtime.Service{Name: name, Version: version})
+		if err != nil {
+			logger.Warnf("Error reading service %v:%v: %v", name, version, err)
+			continue
+		}
+		if len(srvs) == 1 && r.Phase == phaseSwitching && len(r.Previous) > 0 && srvs[0].Service.Source != r.Previous {
+			if err := m.switchSource(ns, srvs[0], r.Previous); err != nil {
+				logger.Warnf("Error restoring service %v:%v: %v", name, version, err)
+				continue
+			}
+		}
+		m.removeService(ns, &runtime.Service{Name: name, Version: version + "-" + rolloutSuffix(r)})
+
+		r.Phase = phaseRolledBack
+		r.Message = "the manager running the rollout stopped"
+		r.Updated = now
+		if err := m.writeRollout(ns, &runtime.Service{Name: name, Version: version}, r); err != nil {
+			logger.Warnf("Error writing rollout for service %v:%v: %v", name, version, err)
+		}
+	}
+}
+
+// switchSource recreates the service with a new source, writing a revision so it can be rolled
+// back
+func (m *manager) switchSource(ns string, srv *service, source string) error {
+	srv.Service.Source = source
+	if err := m.createService(srv.Service, srv.Options); err != nil {
+		return err
+	}
+	if _, err := m.writeRevision("update", srv.Service, srv.Options); err != nil {
+		return err
+	}
+	if err := m.publishEvent(runtime.Delete, srv.Service, &runtime.CreateOptions{Namespace: ns}, nil); err != nil {
+		return err
+	}
+	return m.publishEvent(runtime.Create, srv.Service, srv.Options, nil)
+}
+
+// removeService deletes a service from the store and the runtime
+func (m *manager) removeService(ns string, srv *runtime.Service) {
+	if err := m.deleteService(ns, srv); err != nil {
+		logger.Warnf("Error deleting service %v:%v: %v", srv.Name, srv.Version, err)
+	}
+	if err := m.publishEvent(runtime.Delete, srv, &runtime.CreateOptions{Namespace: ns}, nil); err != nil {
+		logger.Warnf("Error deleting service %v:%v: %v", srv.Name, srv.Version, err)
+	}
+}
+
+// rolloutNodes returns the registered nodes of the service with the rollout tag provided. A blank
+// tag returns the nodes which weren't started by a rollout.
+func (m *manager) rolloutNodes(name, tag string) ([]*registry.Node, error) {
+	srvs, err := m.options.Registry.GetService(name)
+	if err != nil && err != registry.ErrNotFound {
+		return nil, err
+	}
+
+	var nodes []*registry.Node
+	for _, srv := range srvs {
+		for _, node := range srv.Nodes {
+			if node.Metadata[rollout.MetadataKey] == tag {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// waitHealthy waits for the nodes of a service with the rollout tag to register and be healthy,
+// ignoring the nodes excluded
+func (m *manager) waitHealthy(name, tag string, exclude map[string]bool) error {
+	deadline := time.Now().Add(rolloutHealthTimeout)
+
+	var err error
+	for time.Now().Before(deadline) {
+		if err = m.checkHealth(name, tag, exclude); err == nil {
+			return nil
+		}
+		time.Sleep(rolloutCheckInterval)
+	}
+
+	return fmt.Errorf("not healthy after %v: %v", rolloutHealthTimeout, err)
+}
+
+// checkHealth calls Debug.Health on the nodes of a service with the rollout tag, other than those
+// excluded
+func (m *manager) checkHealth(name, tag string, exclude map[string]bool) error {
+	nodes, err := m.rolloutNodes(name, tag)
+	if err != nil {
+		return err
+	}
+
+	var check []*registry.Node
+	for _, node := range nodes {
+		if !exclude[node.Id] {
+			check = append(check, node)
+		}
+	}
+	return m.checkNodes(name, check)
+}
+
+// checkNodes calls Debug.Health on each of the nodes of a service
+func (m *manager) checkNodes(name string, nodes []*registry.Node) error {
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes registered")
+	}
+
+	for _, node := range nodes {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		req := m.options.Client.NewRequest(name, "Debug.Health", &debug.HealthRequest{})
+		rsp := &debug.HealthResponse{}
+		err := m.options.Client.Call(ctx, req, rsp, client.WithAddress(node.Address))
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("health check of node %v failed: %v", node.Id, err)
+		}
+		if rsp.Status != "ok" {
+			return fmt.Errorf("node %v is %v", node.Id, rsp.Status)
+		}
+	}
+
+	return nil
+}
+
+// errorRate returns the ratio of errors to requests for the nodes of a service with the rollout
+// tag, using the stats collected by the debug service
+func (m *manager) errorRate(name, tag string) (float64, error) {
+	nodes, err := m.rolloutNodes(name, tag)
+	if err != nil {
+		return 0, err
+	}
+	ids := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		ids[node.Id] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req := m.options.Client.NewRequest(debugService, "Stats.Read", &stats.ReadRequest{
+		Service: &stats.Service{Name: name},
+	})
+	rsp := &stats.ReadResponse{}
+	if err := m.options.Client.Call(ctx, req, rsp); err != nil {
+		return 0, err
+	}
+
+	// the counters are totals since the node started, which for new nodes covers the rollout
+	var requests, errors uint64
+	for _, s := range rsp.Stats {
+		if s.Service == nil || s.Service.Node == nil || !ids[s.Service.Node.Id] {
+			continue
+		}
+		requests += s.Requests
+		errors += s.Errors
+	}
+
+	if requests == 0 {
+		return 0, nil
+	}
+	return float64(errors) / float64(requests), nil
+}
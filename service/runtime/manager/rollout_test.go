@@ -0,0 +1,141 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/runtime"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/internal/namespace"
+)
+
+func TestParseRollout(t *testing.T) {
+	t.Run("InPlace", func(t *testing.T) {
+		r, err := parseRollout(&runtime.Service{Name: "go.micro.service.foo"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if r != nil {
+			t.Errorf("Expected no rollout, got %+v", r)
+		}
+	})
+
+	t.Run("Canary", func(t *testing.T) {
+		srv := &runtime.Service{
+			Name:   "go.micro.service.foo",
+			Source: "v2",
+			Metadata: map[string]string{
+				RolloutStrategyKey:     StrategyCanary,
+				RolloutTrafficKey:      "25",
+				RolloutDurationKey:     "1m",
+				RolloutMaxErrorRateKey: "0.1",
+				"owner":                "john",
+			},
+		}
+		r, err := parseRollout(srv)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if r.Strategy != StrategyCanary || r.Traffic != 25 || r.Duration != time.Minute || r.MaxErrorRate != 0.1 || r.Source != "v2" {
+			t.Errorf("Unexpected rollout: %+v", r)
+		}
+		if len(srv.Metadata) != 1 {
+			t.Errorf("Expected the rollout keys to be removed from the metadata, got %v", srv.Metadata)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cases := []map[string]string{
+			{RolloutStrategyKey: "rolling"},
+			{RolloutStrategyKey: StrategyCanary, RolloutTrafficKey: "100"},
+			{RolloutStrategyKey: StrategyCanary, RolloutDurationKey: "soon"},
+			{RolloutStrategyKey: StrategyBlueGreen, RolloutMaxErrorRateKey: "2"},
+		}
+		for _, md := range cases {
+			if _, err := parseRollout(&runtime.Service{Metadata: md}); err == nil {
+				t.Errorf("Expected an error for metadata %v", md)
+			}
+		}
+	})
+}
+
+func TestRolloutInProgress(t *testing.T) {
+	m := New(&testRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	ns := namespace.DefaultNamespace
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Source: "v1"}
+	if err := m.Create(srv, runtime.CreateNamespace(ns)); err != nil {
+		t.Fatalf("Unexpected error when creating service: %v", err)
+	}
+
+	// a rollout which is still running should block another from starting
+	if err := m.writeRollout(ns, srv, &Rollout{Strategy: StrategyCanary, Phase: phaseMonitoring, Updated: time.Now()}); err != nil {
+		t.Fatalf("Unexpected error when writing rollout: %v", err)
+	}
+	err := m.Update(&runtime.Service{
+		Name:     srv.Name,
+		Source:   "v2",
+		Metadata: map[string]string{RolloutStrategyKey: StrategyBlueGreen},
+	}, runtime.UpdateNamespace(ns))
+	if err == nil {
+		t.Fatalf("Expected an error when a rollout is in progress")
+	}
+
+	// the progress should be returned in the metadata
+	srvs, err := m.Read(runtime.ReadService(srv.Name), runtime.ReadNamespace(ns))
+	if err != nil {
+		t.Fatalf("Unexpected error when reading services: %v", err)
+	}
+	if len(srvs) != 1 || srvs[0].Metadata["rollout"] != "canary 0% monitoring" {
+		t.Errorf("Expected the rollout progress in the metadata, got %+v", srvs)
+	}
+}
+
+func TestRolloutAbandoned(t *testing.T) {
+	m := New(&testRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore()), Broker(newTestBroker(t))).(*manager)
+
+	ns := namespace.DefaultNamespace
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Source: "v2"}
+	if err := m.Create(srv, runtime.CreateNamespace(ns)); err != nil {
+		t.Fatalf("Unexpected error when creating service: %v", err)
+	}
+
+	// the manager running the rollout stopped after switching the service to the new source
+	r := &Rollout{Strategy: StrategyBlueGreen, Source: "v2", Previous: "v1", Phase: phaseSwitching, Updated: time.Now()}
+	if err := m.writeRollout(ns, srv, r); err != nil {
+		t.Fatalf("Unexpected error when writing rollout: %v", err)
+	}
+
+	// a rollout which was updated recently is still running
+	m.abandonRollouts(time.Now())
+	if r, err := m.readRollout(ns, srv); err != nil {
+		t.Fatalf("Unexpected error when reading rollout: %v", err)
+	} else if r.Phase != phaseSwitching {
+		t.Errorf("Expected the rollout to still be switching, got %v", r.Phase)
+	}
+
+	// once it's stale it should be rolled back, restoring the previous source
+	m.abandonRollouts(time.Now().Add(rolloutStaleAfter))
+	if r, err := m.readRollout(ns, srv); err != nil {
+		t.Fatalf("Unexpected error when reading rollout: %v", err)
+	} else if r.Phase != phaseRolledBack {
+		t.Errorf("Expected the rollout to be rolled back, got %v", r.Phase)
+	}
+	srvs, err := m.Read(runtime.ReadService(srv.Name), runtime.ReadNamespace(ns))
+	if err != nil {
+		t.Fatalf("Unexpected error when reading services: %v", err)
+	}
+	if len(srvs) != 1 || srvs[0].Source != "v1" {
+		t.Errorf("Expected the previous source to be restored, got %+v", srvs)
+	}
+
+	// another rollout can now be started
+	err = m.Update(&runtime.Service{
+		Name:     srv.Name,
+		Source:   "v3",
+		Metadata: map[string]string{RolloutStrategyKey: StrategyBlueGreen},
+	}, runtime.UpdateNamespace(ns))
+	if err != nil {
+		t.Errorf("Unexpected error when starting a rollout: %v", err)
+	}
+}
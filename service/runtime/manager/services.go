@@ -111,3 +111,35 @@ func unique(stringSlice []string) []string {
 	}
 	return list
 }
+
+// baseVersion returns the version of the service a replica or run was created from
+func baseVersion(version string) string {
+	for _, suffix := range []string{replicaSuffix, runSuffix} {
+		if i := strings.LastIndex(version, suffix); i > 0 {
+			return version[:i]
+		}
+	}
+	return version
+}
+
+// updateMetadata copies the keys set in the metadata of an update to the stored service, a blank
+// value removes the key. It returns true if any of the keys changed.
+func updateMetadata(srv, update *runtime.Service, keys []string) bool {
+	var changed bool
+	for _, k := range keys {
+		v, ok := update.Metadata[k]
+		if !ok || srv.Metadata[k] == v {
+			continue
+		}
+		if srv.Metadata == nil {
+			srv.Metadata = make(map[string]string)
+		}
+		if len(v) == 0 {
+			delete(srv.Metadata, k)
+		} else {
+			srv.Metadata[k] = v
+		}
+		changed = true
+	}
+	return changed
+}
@@ -3,6 +3,8 @@ package manager
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,10 +16,38 @@ import (
 // statusPrefix is prefixed to every status key written to the memory store
 const statusPrefix = "status:"
 
+// TransitionsKey is the service metadata key containing the json encoded transitions returned
+// on Runtime.Read
+const TransitionsKey = "transitions"
+
+// BuildCacheKey is the service metadata key containing whether a local service was started from a
+// cached build, hit or miss, returned on Runtime.Read. It isn't set for kubernetes services since
+// their build status is written on the node they run on.
+const BuildCacheKey = "build_cache"
+
+// transitionSize is the max number of transitions kept per service
+var transitionSize = 10
+
+// exitStatus matches the exit code in the error of a process which exited
+var exitStatus = regexp.MustCompile(`exit (?:status|code):? (-?\d+)`)
+
+// Transition is a change in the status of a service
+type Transition struct {
+	Time   time.Time `json:"time"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+}
+
 // serviceStatus contains the runtime specific information for a service
 type serviceStatus struct {
 	Status string
 	Error  string
+	// Restarts is the number of times the runtime started the service again after it exited
+	Restarts int
+	// ExitCode is the exit code of the service the last time it exited, nil if it hasn't
+	ExitCode *int
+	// Transitions are the most recent changes in status, oldest first
+	Transitions []*Transition
 }
 
 // statusPollFrequency is the max frequency the manager will check for new statuses in the runtime
@@ -70,12 +100,77 @@ func (m *manager) cacheStatus(ns string, srv *runtime.Service) error {
 	key := fmt.Sprintf("%v%v:%v:%v", statusPrefix, ns, srv.Name, srv.Version)
 	val := &serviceStatus{Status: srv.Metadata["status"], Error: srv.Metadata["error"]}
 
+	// carry over the history from the previous status
+	var prev *serviceStatus
+	if recs, err := m.cache.Read(key); err == nil && len(recs) > 0 {
+		if err := json.Unmarshal(recs[0].Value, &prev); err != nil {
+			return err
+		}
+		val.Restarts = prev.Restarts
+		val.ExitCode = prev.ExitCode
+		val.Transitions = prev.Transitions
+	}
+
+	// record the transition if the status changed
+	if len(val.Status) > 0 && (prev == nil || prev.Status != val.Status) {
+		val.Transitions = append(val.Transitions, &Transition{Time: time.Now(), Status: val.Status, Error: val.Error})
+		if len(val.Transitions) > transitionSize {
+			val.Transitions = val.Transitions[len(val.Transitions)-transitionSize:]
+		}
+
+		if exited(val.Status) {
+			val.ExitCode = exitCode(val.Status, val.Error)
+		} else if prev != nil && exited(prev.Status) {
+			val.Restarts++
+		}
+	}
+
 	bytes, err := json.Marshal(val)
 	if err != nil {
 		return err
 	}
 
-	return m.cache.Write(&store.Record{Key: key, Value: bytes})
+	if err := m.cache.Write(&store.Record{Key: key, Value: bytes}); err != nil {
+		return err
+	}
+	if prev == nil || prev.Status != val.Status || prev.Error != val.Error {
+		m.notifyStatus(ns)
+	}
+	return nil
+}
+
+// WatchStatus returns a channel which receives when the status of a service in the namespace may
+// have changed, e.g. an event was applied to it or the runtime reported a new status. Changes are
+// coalesced so a receiver which is busy reads the services once they've all been made. The func
+// returned stops the watch.
+func (m *manager) WatchStatus(ns string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	m.Lock()
+	m.statusWatchers[ch] = ns
+	m.Unlock()
+
+	return ch, func() {
+		m.Lock()
+		delete(m.statusWatchers, ch)
+		m.Unlock()
+	}
+}
+
+// notifyStatus signals the watchers of the namespace, without waiting for them to receive
+func (m *manager) notifyStatus(ns string) {
+	m.Lock()
+	defer m.Unlock()
+
+	for ch, watched := range m.statusWatchers {
+		if watched != ns {
+			continue
+		}
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
 }
 
 // listStatuses returns all the statuses for the services in a given namespace with 'name:version'
@@ -104,3 +199,35 @@ func (m *manager) listStatuses(ns string) (map[string]*serviceStatus, error) {
 
 	return statuses, nil
 }
+
+// exited returns true if the status is one a service has when its process has exited
+func exited(status string) bool {
+	switch strings.ToLower(status) {
+	case "error", "done", "stopped", "failed", "succeeded":
+		return true
+	}
+	return false
+}
+
+// exitCode parses the exit code of a service from the error it exited with, returning nil if it
+// can't be determined
+func exitCode(status, err string) *int {
+	if len(err) == 0 {
+		switch strings.ToLower(status) {
+		case "done", "succeeded":
+			code := 0
+			return &code
+		}
+		return nil
+	}
+
+	match := exitStatus.FindStringSubmatch(err)
+	if len(match) < 2 {
+		return nil
+	}
+	code, e := strconv.Atoi(match[1])
+	if e != nil {
+		return nil
+	}
+	return &code
+}
@@ -52,6 +52,10 @@ func (r *testRuntime) Read(opts ...runtime.ReadOption) ([]*runtime.Service, erro
 	return r.readServices, nil
 }
 
+func (r *testRuntime) String() string {
+	return "test"
+}
+
 func TestStatus(t *testing.T) {
 	testServices := []*runtime.Service{
 		&runtime.Service{
@@ -118,3 +122,85 @@ func TestStatus(t *testing.T) {
 		t.Errorf("Incorrect error for %v:%v, expepcted %v but got %v", srv.Name, srv.Version, srv.Metadata["error"], s.Error)
 	}
 }
+
+func TestTransitions(t *testing.T) {
+	m := New(&testRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore())).(*manager)
+	ns := namespace.DefaultNamespace
+
+	// the service crashes and is then started again by the runtime
+	for _, md := range []map[string]string{
+		{"status": "running"},
+		{"status": "running"},
+		{"status": "error", "error": "exit status 2"},
+		{"status": "starting"},
+	} {
+		srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: md}
+		if err := m.cacheStatus(ns, srv); err != nil {
+			t.Fatalf("Unexpected error when caching status: %v", err)
+		}
+	}
+
+	statuses, err := m.listStatuses(ns)
+	if err != nil {
+		t.Fatalf("Unexpected error when listing statuses: %v", err)
+	}
+	s := statuses["go.micro.service.foo:latest"]
+	if s == nil {
+		t.Fatalf("Missing status")
+	}
+	if len(s.Transitions) != 3 {
+		t.Errorf("Expected 3 transitions, got %v", len(s.Transitions))
+	}
+	if s.Restarts != 1 {
+		t.Errorf("Expected 1 restart, got %v", s.Restarts)
+	}
+	if s.ExitCode == nil || *s.ExitCode != 2 {
+		t.Errorf("Expected exit code 2, got %v", s.ExitCode)
+	}
+}
+
+func TestWatchStatus(t *testing.T) {
+	m := New(&testRuntime{}, Store(memory.NewStore()), CacheStore(memory.NewStore())).(*manager)
+	ns := namespace.DefaultNamespace
+
+	changed, stop := m.WatchStatus(ns)
+	other, stopOther := m.WatchStatus("foo")
+	defer stopOther()
+	signalled := func(ch <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// the watchers of the namespace are signalled when a status changes
+	srv := &runtime.Service{Name: "go.micro.service.foo", Version: "latest", Metadata: map[string]string{"status": "running"}}
+	if err := m.cacheStatus(ns, srv); err != nil {
+		t.Fatalf("Unexpected error when caching status: %v", err)
+	}
+	if !signalled(changed) {
+		t.Errorf("Expected the watcher to be signalled when the status changed")
+	}
+	if signalled(other) {
+		t.Errorf("Expected the watcher of another namespace not to be signalled")
+	}
+
+	// the status being read again from the runtime isn't a change
+	if err := m.cacheStatus(ns, srv); err != nil {
+		t.Fatalf("Unexpected error when caching status: %v", err)
+	}
+	if signalled(changed) {
+		t.Errorf("Expected the watcher not to be signalled when the status is unchanged")
+	}
+
+	stop()
+	srv.Metadata = map[string]string{"status": "error", "error": "exit status 1"}
+	if err := m.cacheStatus(ns, srv); err != nil {
+		t.Fatalf("Unexpected error when caching status: %v", err)
+	}
+	if signalled(changed) {
+		t.Errorf("Expected the watcher not to be signalled once it's stopped")
+	}
+}
@@ -1,6 +1,5 @@
-// Package profile is for specific profiles
-// @todo this package is the definition of cruft and
-// should be rewritten in a more elegant way
+// Package profile contains the env vars set when running services. The built in profiles below
+// can be extended by profiles loaded from a file or the config service, see Registry.
 package profile
 
 // Local is a profile for local environments
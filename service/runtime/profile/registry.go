@@ -0,0 +1,233 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/micro/go-micro/v2/client"
+	pb "github.com/micro/go-micro/v2/config/source/service/proto"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ConfigNamespace is the config service namespace profiles are read from
+	ConfigNamespace = "global"
+	// ConfigPath is the path of the profiles in the config service, e.g.
+	// micro config set micro.runtime.profiles '{"staging": {"inherits": "kubernetes"}}'
+	ConfigPath = "micro.runtime.profiles"
+)
+
+var (
+	// validName matches the names profiles can have
+	validName = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+	// validEnv matches the names of env vars
+	validEnv = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Profile is a named set of env vars set when running services. Profiles can inherit the env vars
+// of another profile, overriding or adding to them.
+type Profile struct {
+	// Name of the profile
+	Name string `json:"-" yaml:"-"`
+	// Description of the profile
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Inherits is the name of the profile this one extends
+	Inherits string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+	// Env vars to set, a blank value unsets an inherited var
+	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	// Source the profile was loaded from, e.g. builtin, config or the path of a file
+	Source string `json:"-" yaml:"-"`
+}
+
+// Registry of the available profiles
+type Registry struct {
+	profiles map[string]*Profile
+}
+
+// NewRegistry returns a registry containing the built in profiles
+func NewRegistry() *Registry {
+	r := &Registry{profiles: make(map[string]*Profile)}
+
+	builtin := map[string]struct {
+		description string
+		env         []string
+	}{
+		"local":      {"Services use the defaults for local development", Local()},
+		"server":     {"Services connect to the micro server", Server()},
+		"kubernetes": {"Services connect to the micro services in kubernetes", Kubernetes()},
+		"platform":   {"Services connect to the micro platform", Platform()},
+	}
+	for name, b := range builtin {
+		env := make(map[string]string, len(b.env))
+		for _, e := range b.env {
+			parts := strings.SplitN(e, "=", 2)
+			env[parts[0]] = parts[1]
+		}
+		r.profiles[name] = &Profile{Name: name, Description: b.description, Env: env, Source: "builtin"}
+	}
+
+	return r
+}
+
+// Add the profiles to the registry, replacing any with the same name. The registry is validated
+// once they're added and left unchanged if it's invalid.
+func (r *Registry) Add(source string, profiles map[string]*Profile) error {
+	merged := make(map[string]*Profile, len(r.profiles)+len(profiles))
+	for name, p := range r.profiles {
+		merged[name] = p
+	}
+	for name, p := range profiles {
+		if p == nil {
+			p = &Profile{}
+		}
+		p.Name = name
+		p.Source = source
+		merged[name] = p
+	}
+
+	if err := validate(merged); err != nil {
+		return fmt.Errorf("Invalid profiles in %v: %v", source, err)
+	}
+	r.profiles = merged
+	return nil
+}
+
+// LoadFile adds the profiles in the yaml or json file to the registry. The file contains a map of
+// profiles keyed by name under the profiles key, e.g.
+//
+//	profiles:
+//	  staging:
+//	    inherits: kubernetes
+//	    env:
+//	      MICRO_STORE_ADDRESS: store.staging:8002
+func (r *Registry) LoadFile(path string) error {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file struct {
+		Profiles map[string]*Profile `json:"profiles" yaml:"profiles"`
+	}
+	if err := yaml.UnmarshalStrict(bytes, &file); err != nil {
+		return fmt.Errorf("Error parsing profiles in %v: %v", path, err)
+	}
+	return r.Add(path, file.Profiles)
+}
+
+// LoadConfig adds the profiles set in the config service to the registry, the value at ConfigPath
+// is a json map of profiles keyed by name
+func (r *Registry) LoadConfig(c client.Client) error {
+	rsp, err := pb.NewConfigService("go.micro.config", c).Read(context.TODO(), &pb.ReadRequest{
+		Namespace: ConfigNamespace,
+		Path:      ConfigPath,
+	})
+	if err != nil {
+		return err
+	}
+	if rsp.Change == nil || rsp.Change.ChangeSet == nil {
+		return nil
+	}
+	data := rsp.Change.ChangeSet.Data
+	if len(data) == 0 || data == "null" {
+		return nil
+	}
+
+	var profiles map[string]*Profile
+	if err := json.Unmarshal([]byte(data), &profiles); err != nil {
+		return fmt.Errorf("Error parsing profiles in config: %v", err)
+	}
+	return r.Add("config", profiles)
+}
+
+// Get a profile by name
+func (r *Registry) Get(name string) (*Profile, error) {
+	p, ok := r.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown profile %v, the available profiles are %v", name, strings.Join(r.names(), ", "))
+	}
+	return p, nil
+}
+
+// List the profiles, sorted by name
+func (r *Registry) List() []*Profile {
+	profiles := make([]*Profile, 0, len(r.profiles))
+	for _, n := range r.names() {
+		profiles = append(profiles, r.profiles[n])
+	}
+	return profiles
+}
+
+// Resolve the env vars of a profile, including those it inherits, as KEY=value sorted by key
+func (r *Registry) Resolve(name string) ([]string, error) {
+	p, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the env vars from the root of the inheritance chain down
+	var chain []*Profile
+	for ; p != nil; p = r.profiles[p.Inherits] {
+		chain = append(chain, p)
+	}
+	env := make(map[string]string)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].Env {
+			if len(v) == 0 {
+				delete(env, k)
+			} else {
+				env[k] = v
+			}
+		}
+	}
+
+	vars := make([]string, 0, len(env))
+	for k, v := range env {
+		vars = append(vars, k+"="+v)
+	}
+	sort.Strings(vars)
+	return vars, nil
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.profiles))
+	for n := range r.profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate the names and env vars of the profiles and that they inherit from profiles which exist
+// without any cycles
+func validate(profiles map[string]*Profile) error {
+	for name, p := range profiles {
+		if !validName.MatchString(name) {
+			return fmt.Errorf("invalid profile name %q", name)
+		}
+		for k := range p.Env {
+			if !validEnv.MatchString(k) {
+				return fmt.Errorf("profile %v has an invalid env var %q", name, k)
+			}
+		}
+		if len(p.Inherits) > 0 {
+			if _, ok := profiles[p.Inherits]; !ok {
+				return fmt.Errorf("profile %v inherits unknown profile %v", name, p.Inherits)
+			}
+		}
+
+		seen := map[string]bool{name: true}
+		for parent := profiles[p.Inherits]; parent != nil; parent = profiles[parent.Inherits] {
+			if seen[parent.Name] {
+				return fmt.Errorf("profile %v has an inheritance cycle through %v", name, parent.Name)
+			}
+			seen[parent.Name] = true
+		}
+	}
+	return nil
+}
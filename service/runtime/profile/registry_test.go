@@ -0,0 +1,77 @@
+package profile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "profiles.yaml")
+	ioutil.WriteFile(path, []byte(`
+profiles:
+  staging:
+    description: Staging cluster
+    inherits: kubernetes
+    env:
+      MICRO_STORE_ADDRESS: store.staging:8002
+      MICRO_NETWORK_ADDRESS: ""
+  staging-debug:
+    inherits: staging
+    env:
+      MICRO_LOG_LEVEL: debug
+`), 0644)
+
+	reg := NewRegistry()
+	if err := reg.LoadFile(path); err != nil {
+		t.Fatalf("Unexpected error loading profiles: %v", err)
+	}
+
+	env, err := reg.Resolve("staging-debug")
+	if err != nil {
+		t.Fatalf("Unexpected error resolving profile: %v", err)
+	}
+	vars := strings.Join(env, " ")
+	for _, v := range []string{"MICRO_LOG_LEVEL=debug", "MICRO_STORE_ADDRESS=store.staging:8002", "MICRO_REGISTRY=service"} {
+		if !strings.Contains(vars, v) {
+			t.Errorf("Expected %v in the resolved env, got %v", v, vars)
+		}
+	}
+	if strings.Contains(vars, "MICRO_NETWORK_ADDRESS") {
+		t.Errorf("Expected the blank env var to unset the inherited one, got %v", vars)
+	}
+
+	if len(reg.List()) != 6 {
+		t.Errorf("Expected 6 profiles, got %v", len(reg.List()))
+	}
+	if _, err := reg.Get("production"); err == nil {
+		t.Errorf("Expected an error getting an unknown profile")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]map[string]*Profile{
+		"unknown parent": {"foo": {Inherits: "bar"}},
+		"cycle":          {"foo": {Inherits: "bar"}, "bar": {Inherits: "foo"}},
+		"invalid env":    {"foo": {Env: map[string]string{"FOO BAR": "baz"}}},
+		"invalid name":   {"foo bar": {}},
+	}
+
+	for name, profiles := range tests {
+		reg := NewRegistry()
+		if err := reg.Add("test", profiles); err == nil {
+			t.Errorf("Expected an error adding profiles with an %v", name)
+		}
+		if len(reg.List()) != 4 {
+			t.Errorf("Expected the registry to be unchanged after an invalid add")
+		}
+	}
+}
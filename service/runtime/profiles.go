@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micro/cli/v2"
+	goclient "github.com/micro/go-micro/v2/client"
+	log "github.com/micro/go-micro/v2/logger"
+	cliutil "github.com/micro/micro/v2/client/cli/util"
+	"github.com/micro/micro/v2/internal/client"
+	"github.com/micro/micro/v2/service/runtime/profile"
+)
+
+// ProfilesUsage message for the profiles command
+const ProfilesUsage = "List the runtime profiles: micro runtime profiles [name]"
+
+var (
+	// profileRetries is the number of times the profiles are loaded from the config service when
+	// the profile isn't found, the config service may still be starting
+	profileRetries = 5
+	// profileRetryDelay is the duration between loading the profiles from the config service
+	profileRetryDelay = time.Second * 2
+)
+
+// ProfileFlags are the flags used to select and load profiles
+func ProfileFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "profile",
+			Usage:   "Set the runtime profile to use for services e.g local, kubernetes, platform or one defined in the profile file or config",
+			EnvVars: []string{"MICRO_RUNTIME_PROFILE"},
+		},
+		&cli.StringFlag{
+			Name:    "profile_file",
+			Usage:   "Set the yaml or json file to load runtime profiles from",
+			EnvVars: []string{"MICRO_RUNTIME_PROFILE_FILE"},
+		},
+	}
+}
+
+// LoadProfiles returns the built in profiles along with those in the config service and the
+// profile file. Profiles in the file override those in the config service. The config service is
+// only queried if a client is passed.
+func LoadProfiles(ctx *cli.Context, c goclient.Client) (*profile.Registry, error) {
+	reg := profile.NewRegistry()
+
+	if c != nil {
+		if err := reg.LoadConfig(c); err != nil {
+			log.Debugf("Error loading profiles from config: %v", err)
+		}
+	}
+
+	if path := ctx.String("profile_file"); len(path) > 0 {
+		if err := reg.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return reg, nil
+}
+
+// resolveProfile returns the env vars of the profile passed as a flag. If the profile isn't found
+// the profiles are loaded again since the config service may not have been available.
+func resolveProfile(ctx *cli.Context, c goclient.Client) ([]string, error) {
+	name := ctx.String("profile")
+	if len(name) == 0 {
+		return nil, nil
+	}
+
+	reg, err := LoadProfiles(ctx, c)
+	for i := 0; err == nil && c != nil && i < profileRetries; i++ {
+		if _, e := reg.Get(name); e == nil {
+			break
+		}
+		time.Sleep(profileRetryDelay)
+		reg, err = LoadProfiles(ctx, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reg.Resolve(name)
+}
+
+// ListProfiles prints the available profiles, or the env vars of the profile passed as an arg
+func ListProfiles(ctx *cli.Context) {
+	var c goclient.Client
+	if !cliutil.IsLocal(ctx) {
+		c = client.New(ctx)
+	}
+
+	reg, err := LoadProfiles(ctx, c)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if name := ctx.Args().Get(0); len(name) > 0 {
+		env, err := reg.Resolve(name)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, e := range env {
+			fmt.Println(e)
+		}
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "NAME\tINHERITS\tSOURCE\tDESCRIPTION")
+	for _, p := range reg.List() {
+		inherits := p.Inherits
+		if len(inherits) == 0 {
+			inherits = "n/a"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", p.Name, inherits, p.Source, strings.TrimSpace(p.Description))
+	}
+	writer.Flush()
+}
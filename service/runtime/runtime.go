@@ -10,9 +10,12 @@ import (
 	log "github.com/micro/go-micro/v2/logger"
 	"github.com/micro/go-micro/v2/runtime"
 	pb "github.com/micro/go-micro/v2/runtime/service/proto"
+	"github.com/micro/micro/v2/service/runtime/build"
 	"github.com/micro/micro/v2/service/runtime/handler"
 	"github.com/micro/micro/v2/service/runtime/manager"
-	"github.com/micro/micro/v2/service/runtime/profile"
+	mpb "github.com/micro/micro/v2/service/runtime/manager/proto"
+	spb "github.com/micro/micro/v2/service/runtime/secrets/proto"
+	srcupload "github.com/micro/micro/v2/service/runtime/upload"
 )
 
 var (
@@ -26,19 +29,6 @@ var (
 func Run(ctx *cli.Context, srvOpts ...micro.Option) {
 	log.Init(log.WithFields(map[string]interface{}{"service": "runtime"}))
 
-	// Get the profile
-	var prof []string
-	switch ctx.String("profile") {
-	case "local":
-		prof = profile.Local()
-	case "server":
-		prof = profile.Server()
-	case "kubernetes":
-		prof = profile.Kubernetes()
-	case "platform":
-		prof = profile.Platform()
-	}
-
 	// Init plugins
 	for _, p := range Plugins() {
 		p.Init(ctx)
@@ -68,11 +58,31 @@ func Run(ctx *cli.Context, srvOpts ...micro.Option) {
 	// new service
 	service := micro.NewService(srvOpts...)
 
+	// resolve the profile, which may be defined in the config service or a file
+	prof, err := resolveProfile(ctx, service.Client())
+	if err != nil {
+		log.Errorf("failed to load profile: %s", err)
+		os.Exit(1)
+	}
+
+	// load the key the secrets are encrypted with
+	sec, err := loadSecrets(ctx, service.Options().Store, muRuntime.String())
+	if err != nil {
+		log.Errorf("failed to load secrets: %s", err)
+		os.Exit(1)
+	}
+
 	// create a new runtime manager
 	manager := manager.New(muRuntime,
 		manager.Store(service.Options().Store),
 		manager.Profile(prof),
 		manager.CacheStore(service.Options().Store),
+		manager.Broker(service.Options().Broker),
+		manager.Client(service.Client()),
+		manager.Registry(service.Options().Registry),
+		manager.Secrets(sec),
+		manager.UploadDir(srcupload.DefaultDir),
+		manager.BuildDir(build.DefaultDir),
 	)
 
 	// start the manager
@@ -87,6 +97,18 @@ func Run(ctx *cli.Context, srvOpts ...micro.Option) {
 		Client: micro.NewEvent("go.micro.runtime.events", service.Client()),
 		// using the micro runtime
 		Runtime: manager,
+		// recording who changed what in the audit log
+		Audit: manager,
+	})
+
+	// register the manager handler
+	mpb.RegisterManagerHandler(service.Server(), &handler.Manager{
+		Manager: manager,
+	})
+
+	// register the secrets handler
+	spb.RegisterSecretsHandler(service.Server(), &handler.Secrets{
+		Secrets: sec,
 	})
 
 	// start runtime service
@@ -128,6 +150,54 @@ func Flags() []cli.Flag {
 			Name:  "env_vars",
 			Usage: "Set the environment variables e.g. foo=bar",
 		},
+		&cli.StringFlag{
+			Name:  "cpu",
+			Usage: "Set the cpu limit in cores e.g. 0.5 or 500m",
+		},
+		&cli.StringFlag{
+			Name:  "memory",
+			Usage: "Set the memory limit e.g. 256Mi or 1Gi",
+		},
+		&cli.StringFlag{
+			Name:  "disk",
+			Usage: "Set the disk limit e.g. 10Gi, the ephemeral storage on kubernetes and the max size of a file written locally",
+		},
+		&cli.IntFlag{
+			Name:  "replicas",
+			Usage: "Set the number of replicas of the service to run",
+		},
+		&cli.StringFlag{
+			Name:  "schedule",
+			Usage: "Set the cron schedule of a job run with --type job e.g. \"*/5 * * * *\" or @hourly",
+		},
+		&cli.StringFlag{
+			Name:  "concurrency",
+			Usage: "Set what happens when a job is due whilst the previous run is in progress: allow, forbid or replace",
+		},
+		&cli.StringSliceFlag{
+			Name:  "secret",
+			Usage: "Set an env var to the value of a secret when the service starts e.g. DB_PASSWORD=db/password",
+		},
+		&cli.StringFlag{
+			Name:  "liveness",
+			Usage: "Set the probe which restarts the service when it fails e.g. rpc, :8080/health or exec:./check.sh",
+		},
+		&cli.StringFlag{
+			Name:  "readiness",
+			Usage: "Set the probe which marks the service as not ready when it fails e.g. rpc, :8080/ready or exec:./check.sh",
+		},
+		&cli.IntFlag{
+			Name:  "probe_failures",
+			Usage: "Set the number of consecutive liveness failures before the service is restarted",
+		},
+		&cli.DurationFlag{
+			Name:  "probe_interval",
+			Usage: "Set the duration between probes e.g. 10s",
+		},
+		&cli.DurationFlag{
+			Name:  "probe_delay",
+			Usage: "Set the duration to wait after the service starts before probing it e.g. 30s",
+		},
 	}
 }
 
@@ -136,17 +206,12 @@ func Commands(options ...micro.Option) []*cli.Command {
 		{
 			Name:  "runtime",
 			Usage: "Run the micro runtime",
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				&cli.StringFlag{
 					Name:    "address",
 					Usage:   "Set the registry http address e.g 0.0.0.0:8088",
 					EnvVars: []string{"MICRO_SERVER_ADDRESS"},
 				},
-				&cli.StringFlag{
-					Name:    "profile",
-					Usage:   "Set the runtime profile to use for services e.g local, kubernetes, platform",
-					EnvVars: []string{"MICRO_RUNTIME_PROFILE"},
-				},
 				&cli.StringFlag{
 					Name:    "source",
 					Usage:   "Set the runtime source, e.g. micro/services",
@@ -157,11 +222,68 @@ func Commands(options ...micro.Option) []*cli.Command {
 					Usage:   "Set the max retries per service",
 					EnvVars: []string{"MICRO_RUNTIME_RETRIES"},
 				},
-			},
+				&cli.StringFlag{
+					Name:    "secrets_key",
+					Usage:   "Set the base64 encoded 32 byte key secrets are encrypted with, required for runtimes other than local which default to a key generated in ~/.micro-secrets",
+					EnvVars: []string{"MICRO_RUNTIME_SECRETS_KEY"},
+				},
+			}, ProfileFlags()...),
 			Action: func(ctx *cli.Context) error {
 				Run(ctx, options...)
 				return nil
 			},
+			Subcommands: []*cli.Command{
+				{
+					Name:  "profiles",
+					Usage: ProfilesUsage,
+					Flags: ProfileFlags(),
+					Action: func(ctx *cli.Context) error {
+						ListProfiles(ctx)
+						return nil
+					},
+				},
+				{
+					Name:  "gc",
+					Usage: GCUsage,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "List what would be removed without removing it",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						collectGarbage(ctx)
+						return nil
+					},
+				},
+				{
+					Name:   "limit",
+					Usage:  "Run a command with resource limits applied",
+					Hidden: true,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "name",
+							Usage: "Set the name of the service being limited",
+						},
+						&cli.Float64Flag{
+							Name:  "cpu",
+							Usage: "Set the cpu limit in cores",
+						},
+						&cli.Int64Flag{
+							Name:  "memory",
+							Usage: "Set the memory limit in bytes",
+						},
+						&cli.Int64Flag{
+							Name:  "disk",
+							Usage: "Set the max size of a file written in bytes",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						limitProcess(ctx)
+						return nil
+					},
+				},
+			},
 		},
 		{
 			// In future we'll also have `micro run [x]` hence `micro run service` requiring "service"
@@ -169,12 +291,19 @@ func Commands(options ...micro.Option) []*cli.Command {
 			Usage: RunUsage,
 			Description: `Examples:
 			micro run github.com/micro/examples/helloworld
-			micro run .  # deploy local folder to your local micro server
+			micro run .  # deploy local folder to your local micro server, skipping the paths in .microignore
 			micro run ../path/to/folder # deploy local folder to your local micro server
 			micro run helloworld # deploy latest version, translates to micro run github.com/micro/services/helloworld
 			micro run helloworld@9342934e6180 # deploy certain version
-			micro run helloworld@branchname	# deploy certain branch`,
-			Flags: Flags(),
+			micro run helloworld@branchname	# deploy certain branch
+			micro run cleanup --type job --schedule "*/5 * * * *" # run a job every 5 minutes
+			micro run . --dry-run # print the detected language, entrypoint and image without running`,
+			Flags: append(Flags(),
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Print the build plan detected from the source without running the service",
+				},
+			),
 			Action: func(ctx *cli.Context) error {
 				runService(ctx, options...)
 				return nil
@@ -188,13 +317,54 @@ func Commands(options ...micro.Option) []*cli.Command {
 			micro update .  # deploy local folder to your local micro server
 			micro update ../path/to/folder # deploy local folder to your local micro server
 			micro update helloworld # deploy master branch, translates to micro update github.com/micro/services/helloworld
-			micro update helloworld@branchname	# deploy certain branch`,
-			Flags: Flags(),
+			micro update helloworld@branchname	# deploy certain branch
+			micro update helloworld --strategy canary --traffic 10 --duration 10m # canary the update
+			micro update helloworld --strategy bluegreen # switch once the new version is healthy`,
+			Flags: append(Flags(),
+				&cli.StringFlag{
+					Name:  "strategy",
+					Usage: "Set the rollout strategy, canary or bluegreen. Defaults to updating in place",
+				},
+				&cli.IntFlag{
+					Name:  "traffic",
+					Usage: "Set the percentage of traffic sent to a canary",
+				},
+				&cli.DurationFlag{
+					Name:  "duration",
+					Usage: "Set how long a canary runs before it's promoted e.g. 10m",
+				},
+				&cli.Float64Flag{
+					Name:  "max-error-rate",
+					Usage: "Set the error rate between 0 and 1 which rolls back a canary",
+				},
+			),
 			Action: func(ctx *cli.Context) error {
 				updateService(ctx, options...)
 				return nil
 			},
 		},
+		{
+			Name:  "apply",
+			Usage: ApplyUsage,
+			Description: `Examples:
+			micro apply -f micro.yaml # create, update and delete services to match the manifest
+			micro apply -f micro.yaml --dry-run # print the changes without applying them`,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "file",
+					Aliases: []string{"f"},
+					Usage:   "Set the manifest file declaring the services to run",
+				},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Print the changes which would be made without applying them",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				applyManifest(ctx, options...)
+				return nil
+			},
+		},
 		{
 			Name:  "kill",
 			Usage: KillUsage,
@@ -213,12 +383,142 @@ func Commands(options ...micro.Option) []*cli.Command {
 		{
 			Name:  "status",
 			Usage: GetUsage,
-			Flags: Flags(),
+			Description: `Examples:
+			micro status # list the status of all services
+			micro status helloworld # show the status and recent transitions of the helloworld service
+			micro status --watch # stream changes in the status of services
+			micro status -o json # output the status as json for tooling
+
+			The cache column shows whether a local service was started from a cached build,
+			hit or miss. Kubernetes services cache their builds on each node but don't report it.`,
+			Flags: append(Flags(),
+				&cli.BoolFlag{
+					Name:  "watch",
+					Usage: "Stream changes in the status of services as the runtime makes them, the local environment is polled",
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Set the output format, table, json or yaml",
+				},
+			),
 			Action: func(ctx *cli.Context) error {
 				getService(ctx, options...)
 				return nil
 			},
 		},
+		{
+			Name:  "scale",
+			Usage: ScaleUsage,
+			Description: `Examples:
+			micro scale helloworld --replicas 3 # run 3 replicas of the helloworld service
+			micro scale helloworld@branchname --replicas 1 # scale a certain branch back to 1 replica`,
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "replicas",
+					Usage: "Set the number of replicas of the service to run",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				scaleService(ctx, options...)
+				return nil
+			},
+		},
+		{
+			Name:  "secrets",
+			Usage: SecretsUsage,
+			Description: `Examples:
+			micro secrets set db/password # prompt for the value of the secret
+			micro secrets list
+			micro run helloworld --secret DB_PASSWORD=db/password # set DB_PASSWORD when the service starts`,
+			Subcommands: []*cli.Command{
+				{
+					Name:  "set",
+					Usage: "Set a secret: micro secrets set [name] [value]",
+					Action: func(ctx *cli.Context) error {
+						setSecret(ctx, options...)
+						return nil
+					},
+				},
+				{
+					Name:  "get",
+					Usage: "Get the value of a secret: micro secrets get [name]",
+					Action: func(ctx *cli.Context) error {
+						getSecret(ctx, options...)
+						return nil
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List the names of the secrets",
+					Action: func(ctx *cli.Context) error {
+						listSecrets(ctx, options...)
+						return nil
+					},
+				},
+				{
+					Name:  "delete",
+					Usage: "Delete a secret: micro secrets delete [name]",
+					Action: func(ctx *cli.Context) error {
+						deleteSecret(ctx, options...)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "history",
+			Usage: HistoryUsage,
+			Description: `Examples:
+			micro history helloworld # list the revisions of the helloworld service
+			micro history helloworld@branchname # list the revisions of a certain branch`,
+			Action: func(ctx *cli.Context) error {
+				getHistory(ctx, options...)
+				return nil
+			},
+		},
+		{
+			Name:  "rollback",
+			Usage: RollbackUsage,
+			Description: `Examples:
+			micro rollback helloworld # rollback to the previous revision
+			micro rollback helloworld 3 # rollback to revision 3`,
+			Action: func(ctx *cli.Context) error {
+				rollbackService(ctx, options...)
+				return nil
+			},
+		},
+		{
+			Name:  "events",
+			Usage: EventsUsage,
+			Description: `Examples:
+			micro events # list the events in the audit log
+			micro events --service helloworld --since 1h # list the events of the helloworld service in the last hour
+			micro events -f # follow the audit log`,
+			Flags: eventsFlags(),
+			Action: func(ctx *cli.Context) error {
+				listEvents(ctx, options...)
+				return nil
+			},
+		},
+		{
+			Name:  "exec",
+			Usage: ExecUsage,
+			Description: `Examples:
+			micro exec helloworld -- ls -la # list the files of the helloworld service
+			micro exec --version v1 helloworld -- env # print the env of version v1
+			echo hello | micro exec helloworld -- cat # stream stdin to the command`,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "version",
+					Usage: "Set the version of the service to run the command in",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				execService(ctx, options...)
+				return nil
+			},
+		},
 		{
 			Name:  "logs",
 			Usage: "Get logs for a service",
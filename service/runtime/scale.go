@@ -0,0 +1,55 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/runtime"
+	cliutil "github.com/micro/micro/v2/client/cli/util"
+	"github.com/micro/micro/v2/service/runtime/manager"
+)
+
+const (
+	// ScaleUsage message for the scale command
+	ScaleUsage = "Scale a service: micro scale [source] --replicas N"
+)
+
+func scaleService(ctx *cli.Context, srvOpts ...micro.Option) {
+	if ctx.Args().Len() == 0 || !ctx.IsSet("replicas") {
+		fmt.Println(ScaleUsage)
+		return
+	}
+
+	replicas := ctx.Int("replicas")
+	if replicas < 1 {
+		fmt.Println("Invalid replicas, must be at least 1")
+		os.Exit(1)
+	}
+	if cliutil.IsLocal(ctx) {
+		fmt.Println("Scaling is not supported in the local environment")
+		os.Exit(1)
+	}
+
+	name, version, err := serviceFromArgs(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// a blank source with only the replicas set scales the service without restarting it
+	service := &runtime.Service{
+		Name:     name,
+		Version:  version,
+		Metadata: map[string]string{manager.ReplicasKey: strconv.Itoa(replicas)},
+	}
+
+	if err := runtimeFromContext(ctx).Update(service); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Scaled %v:%v to %v replicas\n", name, version, replicas)
+}
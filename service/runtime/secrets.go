@@ -0,0 +1,171 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/store"
+	cliutil "github.com/micro/micro/v2/client/cli/util"
+	"github.com/micro/micro/v2/internal/client"
+	"github.com/micro/micro/v2/service/runtime/secrets"
+	pb "github.com/micro/micro/v2/service/runtime/secrets/proto"
+)
+
+const (
+	// SecretsUsage message for the secrets command
+	SecretsUsage = "Manage secrets: micro secrets [set|get|list|delete]"
+
+	// secretsKeyFile is the file in the home directory the generated secrets key is kept in
+	secretsKeyFile = ".micro-secrets"
+)
+
+// loadSecrets returns the secrets kept in the store, encrypted with the key passed as a flag or
+// the key generated the first time the runtime was run. A generated key is only known to the
+// host it's kept on, so it's only used by the local runtime: every other runtime shares the
+// store between instances, which must all be passed the same key.
+func loadSecrets(ctx *cli.Context, s store.Store, runtime string) (*secrets.Secrets, error) {
+	var key []byte
+	var err error
+
+	if v := ctx.String("secrets_key"); len(v) > 0 {
+		key, err = secrets.ParseKey(v)
+	} else if runtime != "local" {
+		return nil, fmt.Errorf("the %s runtime requires a secrets key shared by every instance, set it with --secrets_key or MICRO_RUNTIME_SECRETS_KEY", runtime)
+	} else {
+		var usr *user.User
+		if usr, err = user.Current(); err != nil {
+			return nil, err
+		}
+		key, err = secrets.LoadKey(filepath.Join(usr.HomeDir, secretsKeyFile))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets.New(s, key)
+}
+
+// secretsFromContext returns a client for the secrets, which are only available when running
+// against a micro server
+func secretsFromContext(ctx *cli.Context) (pb.SecretsService, error) {
+	if cliutil.IsLocal(ctx) {
+		return nil, fmt.Errorf("Secrets are not supported in the local environment")
+	}
+	return pb.NewSecretsService(Name, client.New(ctx)), nil
+}
+
+// setSecrets adds the secrets passed as flags to the service metadata, only the names of the
+// secrets are kept with the service
+func setSecrets(ctx *cli.Context, md map[string]string) error {
+	if !ctx.IsSet("secret") {
+		return nil
+	}
+	if cliutil.IsLocal(ctx) {
+		return fmt.Errorf("Secrets are not supported in the local environment")
+	}
+
+	var refs []string
+	for _, v := range ctx.StringSlice("secret") {
+		for _, r := range strings.Split(v, ",") {
+			if r = strings.TrimSpace(r); len(r) > 0 {
+				refs = append(refs, r)
+			}
+		}
+	}
+	md[secrets.MetadataKey] = strings.Join(refs, ",")
+
+	_, err := secrets.ParseRefs(md)
+	return err
+}
+
+func setSecret(ctx *cli.Context, srvOpts ...micro.Option) {
+	if ctx.Args().Len() == 0 {
+		fmt.Println("Usage: micro secrets set [name] [value]")
+		return
+	}
+
+	// read the value from stdin if it's not passed so it doesn't end up in the shell history
+	value := ctx.Args().Get(1)
+	if ctx.Args().Len() < 2 {
+		fmt.Print("Enter the value: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && len(line) == 0 {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+
+	s, err := secretsFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if _, err := s.Set(context.TODO(), &pb.SetRequest{Name: ctx.Args().Get(0), Value: value}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func getSecret(ctx *cli.Context, srvOpts ...micro.Option) {
+	if ctx.Args().Len() == 0 {
+		fmt.Println("Usage: micro secrets get [name]")
+		return
+	}
+
+	s, err := secretsFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rsp, err := s.Get(context.TODO(), &pb.GetRequest{Name: ctx.Args().Get(0)})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println(rsp.Value)
+}
+
+func listSecrets(ctx *cli.Context, srvOpts ...micro.Option) {
+	s, err := secretsFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rsp, err := s.List(context.TODO(), &pb.ListRequest{})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for _, n := range rsp.Names {
+		fmt.Println(n)
+	}
+}
+
+func deleteSecret(ctx *cli.Context, srvOpts ...micro.Option) {
+	if ctx.Args().Len() == 0 {
+		fmt.Println("Usage: micro secrets delete [name]")
+		return
+	}
+
+	s, err := secretsFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if _, err := s.Delete(context.TODO(), &pb.DeleteRequest{Name: ctx.Args().Get(0)}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,236 @@
+// Package secrets stores the secrets referenced by runtime services, encrypted with a key per
+// namespace
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/micro/go-micro/v2/store"
+)
+
+const (
+	// MetadataKey is the service metadata key for the secrets to set as env vars when the service
+	// is started, e.g. "DB_PASSWORD=db/password,API_KEY=api/key"
+	MetadataKey = "secrets"
+
+	// prefix is prefixed to the key of every secret written to the store
+	prefix = "secret:"
+	// keySize is the size of the master key in bytes
+	keySize = 32
+)
+
+var (
+	// ErrNotFound is returned when a secret doesn't exist
+	ErrNotFound = errors.New("secret not found")
+
+	// validName matches the names secrets can have, e.g. db/password
+	validName = regexp.MustCompile(`^[a-zA-Z0-9_.\-/]+$`)
+	// validEnv matches the names of the env vars secrets can be set as
+	validEnv = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Secrets encrypts secrets at rest in the store. Each namespace has its own key derived from the
+// master key so the secrets of one namespace can't be decrypted with the key of another.
+type Secrets struct {
+	store store.Store
+	key   []byte
+}
+
+// New returns secrets kept in the store, encrypted with the master key
+func New(s store.Store, key []byte) (*Secrets, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("Secrets key must be %v bytes, got %v", keySize, len(key))
+	}
+	return &Secrets{store: s, key: key}, nil
+}
+
+// Set the value of a secret
+func (s *Secrets) Set(ns, name, value string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	aead, err := s.cipher(ns)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	// the namespace and name are authenticated so the value can't be moved to another secret
+	bytes := aead.Seal(nonce, nonce, []byte(value), []byte(key(ns, name)))
+	return s.store.Write(&store.Record{Key: key(ns, name), Value: bytes})
+}
+
+// Get the value of a secret
+func (s *Secrets) Get(ns, name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	recs, err := s.store.Read(key(ns, name))
+	if err == store.ErrNotFound || (err == nil && len(recs) == 0) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	aead, err := s.cipher(ns)
+	if err != nil {
+		return "", err
+	}
+
+	bytes := recs[0].Value
+	if len(bytes) < aead.NonceSize() {
+		return "", fmt.Errorf("Secret %v is corrupt", name)
+	}
+	value, err := aead.Open(nil, bytes[:aead.NonceSize()], bytes[aead.NonceSize():], []byte(key(ns, name)))
+	if err != nil {
+		return "", fmt.Errorf("Error decrypting secret %v: %v", name, err)
+	}
+	return string(value), nil
+}
+
+// List the names of the secrets in the namespace, the values aren't decrypted
+func (s *Secrets) List(ns string) ([]string, error) {
+	recs, err := s.store.Read(prefix+ns+":", store.ReadPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(recs))
+	for _, r := range recs {
+		names = append(names, strings.TrimPrefix(r.Key, prefix+ns+":"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete a secret
+func (s *Secrets) Delete(ns, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	if _, err := s.store.Read(key(ns, name)); err == store.ErrNotFound {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return s.store.Delete(key(ns, name))
+}
+
+// Resolve the secrets referenced by the service metadata into env vars, e.g. DB_PASSWORD=secret
+func (s *Secrets) Resolve(ns string, md map[string]string) ([]string, error) {
+	refs, err := ParseRefs(md)
+	if err != nil {
+		return nil, err
+	}
+
+	env := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		value, err := s.Get(ns, ref.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("Error resolving %v: %v", ref.Env, err)
+		}
+		env = append(env, ref.Env+"="+value)
+	}
+	return env, nil
+}
+
+// cipher returns the cipher for the namespace, the key is derived from the master key
+func (s *Secrets) cipher(ns string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(ns))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Ref is a reference to a secret which is set as an env var
+type Ref struct {
+	Env    string
+	Secret string
+}
+
+// ParseRefs returns the secrets referenced by the service metadata
+func ParseRefs(md map[string]string) ([]*Ref, error) {
+	v := md[MetadataKey]
+	if len(v) == 0 {
+		return nil, nil
+	}
+
+	var refs []*Ref
+	for _, r := range strings.Split(v, ",") {
+		parts := strings.SplitN(strings.TrimSpace(r), "=", 2)
+		if len(parts) != 2 || !validEnv.MatchString(parts[0]) {
+			return nil, fmt.Errorf("Invalid secret %v, must be formatted ENV=name e.g. DB_PASSWORD=db/password", r)
+		}
+		if err := validateName(parts[1]); err != nil {
+			return nil, err
+		}
+		refs = append(refs, &Ref{Env: parts[0], Secret: parts[1]})
+	}
+	return refs, nil
+}
+
+// ParseKey decodes a base64 encoded master key
+func ParseKey(v string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+	if err != nil {
+		return nil, fmt.Errorf("Secrets key must be base64 encoded: %v", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("Secrets key must be %v bytes, got %v", keySize, len(key))
+	}
+	return key, nil
+}
+
+// LoadKey reads the master key from the file, generating one if the file doesn't exist
+func LoadKey(path string) ([]byte, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err == nil {
+		return ParseKey(string(bytes))
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func key(ns, name string) string {
+	return prefix + ns + ":" + name
+}
+
+func validateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("Invalid secret name %q, must only contain letters, numbers and _.-/", name)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/memory"
+)
+
+func TestSecrets(t *testing.T) {
+	st := memory.NewStore()
+	s, err := New(st, bytes.Repeat([]byte{1}, keySize))
+	if err != nil {
+		t.Fatalf("Unexpected error creating secrets: %v", err)
+	}
+
+	if err := s.Set("foo", "db/password", "hunter2"); err != nil {
+		t.Fatalf("Unexpected error setting secret: %v", err)
+	}
+	if v, err := s.Get("foo", "db/password"); err != nil || v != "hunter2" {
+		t.Errorf("Expected hunter2, got %v: %v", v, err)
+	}
+
+	// the value is encrypted at rest
+	recs, err := st.Read(key("foo", "db/password"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading record: %v", err)
+	}
+	if bytes.Contains(recs[0].Value, []byte("hunter2")) {
+		t.Errorf("Expected the value to be encrypted")
+	}
+
+	// the secret can't be read from another namespace, even if the record is copied
+	st.Write(&store.Record{Key: key("bar", "db/password"), Value: recs[0].Value})
+	if _, err := s.Get("bar", "db/password"); err == nil {
+		t.Errorf("Expected an error decrypting the secret in another namespace")
+	}
+	if _, err := s.Get("foo", "api/key"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	env, err := s.Resolve("foo", map[string]string{MetadataKey: "DB_PASSWORD=db/password"})
+	if err != nil || len(env) != 1 || env[0] != "DB_PASSWORD=hunter2" {
+		t.Errorf("Expected DB_PASSWORD=hunter2, got %v: %v", env, err)
+	}
+
+	if names, err := s.List("foo"); err != nil || len(names) != 1 || names[0] != "db/password" {
+		t.Errorf("Expected to list db/password, got %v: %v", names, err)
+	}
+	if err := s.Delete("foo", "db/password"); err != nil {
+		t.Fatalf("Unexpected error deleting secret: %v", err)
+	}
+	if err := s.Delete("foo", "db/password"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestParseRefs(t *testing.T) {
+	refs, err := ParseRefs(map[string]string{MetadataKey: "DB_PASSWORD=db/password, API_KEY=api/key"})
+	if err != nil || len(refs) != 2 || refs[1].Env != "API_KEY" || refs[1].Secret != "api/key" {
+		t.Errorf("Expected two refs, got %v: %v", refs, err)
+	}
+
+	for _, v := range []string{"DB_PASSWORD", "1DB=db/password", "DB=db password"} {
+		if _, err := ParseRefs(map[string]string{MetadataKey: v}); err == nil {
+			t.Errorf("Expected an error parsing %v", v)
+		}
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key")
+	key, err := LoadKey(path)
+	if err != nil || len(key) != keySize {
+		t.Fatalf("Expected a key to be generated, got %v: %v", len(key), err)
+	}
+	loaded, err := LoadKey(path)
+	if err != nil || !bytes.Equal(key, loaded) {
+		t.Errorf("Expected the generated key to be loaded, got %v", err)
+	}
+}
@@ -2,14 +2,15 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -21,10 +22,13 @@ import (
 	"github.com/micro/go-micro/v2/runtime"
 	"github.com/micro/go-micro/v2/runtime/local/git"
 	srvRuntime "github.com/micro/go-micro/v2/runtime/service"
-	"github.com/micro/go-micro/v2/util/file"
 	cliutil "github.com/micro/micro/v2/client/cli/util"
 	"github.com/micro/micro/v2/internal/client"
-	"github.com/micro/micro/v2/service/runtime/handler"
+	"github.com/micro/micro/v2/service/runtime/cells"
+	"github.com/micro/micro/v2/service/runtime/limits"
+	"github.com/micro/micro/v2/service/runtime/manager"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+	srcupload "github.com/micro/micro/v2/service/runtime/upload"
 )
 
 const (
@@ -101,6 +105,84 @@ func sourceExists(source *git.Source) error {
 	return nil
 }
 
+// resolveSource parses the source passed to a command. Local sources are uploaded to the server
+// and remote sources are checked to exist. It returns the parsed source along with the source
+// which should be passed to the runtime.
+func resolveSource(ctx *cli.Context, arg string) (*git.Source, string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+	source, err := git.ParseSourceLocal(wd, arg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !source.Local {
+		if err := sourceExists(source); err != nil {
+			return nil, "", err
+		}
+		return source, source.RuntimeSource(), nil
+	}
+
+	newSource, err := upload(ctx, source)
+	if err != nil {
+		return nil, "", err
+	}
+	return source, newSource, nil
+}
+
+// detectCell parses the source and detects the cell which builds and runs it. Remote sources
+// aren't inspected, their plan is nil and they're run as go services.
+func detectCell(arg string) (*git.Source, *cells.Plan, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	source, err := git.ParseSourceLocal(wd, arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !source.Local {
+		return source, nil, nil
+	}
+	plan, err := cells.Detect(source.FullPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return source, plan, nil
+}
+
+// printBuildPlan prints the build plan of the service for --dry-run, the command is the one passed
+// with --command and --args if any
+func printBuildPlan(srv *runtime.Service, plan *cells.Plan, image, command string) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	defer writer.Flush()
+
+	version := srv.Version
+	if len(version) == 0 {
+		version = "latest"
+	}
+	fmt.Fprintf(writer, "SERVICE\t%v\n", srv.Name)
+	fmt.Fprintf(writer, "VERSION\t%v\n", version)
+	if plan == nil {
+		fmt.Fprintf(writer, "CELL\t%v (remote sources aren't inspected)\n", cells.Go)
+	} else {
+		fmt.Fprintf(writer, "CELL\t%v\n", plan.Cell)
+		fmt.Fprintf(writer, "DETECTED\t%v\n", plan.Detected)
+		fmt.Fprintf(writer, "ENTRYPOINT\t%v\n", plan.Entrypoint)
+	}
+	if len(command) == 0 && plan != nil {
+		command = plan.Command
+	}
+	if len(command) > 0 {
+		fmt.Fprintf(writer, "COMMAND\t%v\n", command)
+	}
+	if len(image) > 0 {
+		fmt.Fprintf(writer, "IMAGE\t%v\n", image)
+	}
+}
+
 func runService(ctx *cli.Context, srvOpts ...micro.Option) {
 	// Init plugins
 	for _, p := range Plugins() {
@@ -113,36 +195,26 @@ func runService(ctx *cli.Context, srvOpts ...micro.Option) {
 		return
 	}
 
-	wd, err := os.Getwd()
+	// the cell is detected before the source is uploaded so a dry run doesn't upload it
+	source, plan, err := detectCell(ctx.Args().Get(0))
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	source, err := git.ParseSourceLocal(wd, ctx.Args().Get(0))
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	var newSource string
-	if source.Local {
-		newSource, err = upload(ctx, source)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-	} else {
-		err := sourceExists(source)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-	}
 
 	typ := ctx.String("type")
 	image := ctx.String("image")
 	command := strings.TrimSpace(ctx.String("command"))
 	args := strings.TrimSpace(ctx.String("args"))
 
+	var cmdCommand, cmdArgs []string
+	if len(command) > 0 {
+		cmdCommand = strings.Split(command, " ")
+	}
+	if len(args) > 0 {
+		cmdArgs = strings.Split(args, " ")
+	}
+
 	// load the runtime
 	r := runtimeFromContext(ctx)
 
@@ -151,13 +223,32 @@ func runService(ctx *cli.Context, srvOpts ...micro.Option) {
 		retries = ctx.Int("retries")
 	}
 
-	// set the image if not specified
-	if len(image) == 0 {
+	// set the image if not specified, services in languages other than go are run by their cell
+	if len(image) == 0 && plan != nil && plan.Cell != cells.Go {
+		image = plan.Image()
+	} else if len(image) == 0 {
 		formattedName := strings.ReplaceAll(source.Folder, "/", "-")
 		// eg. docker.pkg.github.com/micro/services/users-api
 		image = fmt.Sprintf("%v/%v", Image, formattedName)
 	}
 
+	// services run by the local runtime aren't managed so the command of the cell is set here
+	if len(cmdCommand) == 0 && plan != nil && r.String() == "local" {
+		cmdCommand, cmdArgs = plan.LocalCommand()
+	}
+
+	if ctx.Bool("dry-run") {
+		srv := &runtime.Service{Name: source.RuntimeName(), Version: source.Ref}
+		printBuildPlan(srv, plan, image, strings.TrimSpace(command+" "+args))
+		return
+	}
+
+	_, runtimeSource, err := resolveSource(ctx, ctx.Args().Get(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	// specify the options
 	opts := []runtime.CreateOption{
 		runtime.WithOutput(os.Stdout),
@@ -180,18 +271,14 @@ func runService(ctx *cli.Context, srvOpts ...micro.Option) {
 		opts = append(opts, runtime.WithEnv(environment))
 	}
 
-	if len(command) > 0 {
-		opts = append(opts, runtime.WithCommand(strings.Split(command, " ")...))
+	if len(cmdCommand) > 0 {
+		opts = append(opts, runtime.WithCommand(cmdCommand...))
 	}
 
-	if len(args) > 0 {
-		opts = append(opts, runtime.WithArgs(strings.Split(args, " ")...))
+	if len(cmdArgs) > 0 {
+		opts = append(opts, runtime.WithArgs(cmdArgs...))
 	}
 
-	runtimeSource := source.RuntimeSource()
-	if source.Local {
-		runtimeSource = newSource
-	}
 	// run the service
 	service := &runtime.Service{
 		Name:     source.RuntimeName(),
@@ -200,6 +287,58 @@ func runService(ctx *cli.Context, srvOpts ...micro.Option) {
 		Metadata: make(map[string]string),
 	}
 
+	// add the cell, the runtime manager runs the service with its command
+	if plan != nil {
+		plan.Metadata(service.Metadata)
+	}
+
+	// add the resource limits
+	if err := setLimits(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// add the probes, these are run by the runtime manager
+	if err := setProbes(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// add the secrets, these are resolved by the runtime manager when the service starts
+	if err := setSecrets(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// add the schedule of jobs, these are run by the runtime manager
+	if err := setJob(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if typ == manager.JobType && len(service.Metadata[manager.JobScheduleKey]) == 0 {
+		fmt.Println("Jobs must have a schedule e.g. --schedule \"*/5 * * * *\"")
+		os.Exit(1)
+	}
+
+	// set the replicas, these are run by the runtime manager
+	if ctx.IsSet("replicas") {
+		if cliutil.IsLocal(ctx) {
+			fmt.Println("Replicas are not supported in the local environment")
+			os.Exit(1)
+		}
+		service.Metadata[manager.ReplicasKey] = strconv.Itoa(ctx.Int("replicas"))
+	}
+
+	// services run by the local runtime aren't managed so the limits are applied here
+	if l, _ := limits.FromMetadata(service.Metadata); l != nil && r.String() == "local" {
+		cmdCommand, cmdArgs, err = limits.Command(l, service.Name, cmdCommand, cmdArgs)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts = append(opts, runtime.WithCommand(cmdCommand...), runtime.WithArgs(cmdArgs...))
+	}
+
 	if err := r.Create(service, opts...); err != nil {
 		fmt.Println(err)
 		return
@@ -244,53 +383,27 @@ func killService(ctx *cli.Context, srvOpts ...micro.Option) {
 	}
 }
 
-func grepMain(path string) error {
-	files, err := ioutil.ReadDir(path)
-	if err != nil {
-		return err
-	}
-	for _, f := range files {
-		if !strings.HasSuffix(f.Name(), ".go") {
-			continue
-		}
-		file := filepath.Join(path, f.Name())
-		b, err := ioutil.ReadFile(file)
-		if err != nil {
-			continue
-		}
-		if strings.Contains(string(b), "package main") {
-			return nil
-		}
-	}
-	return fmt.Errorf("Directory does not contain a main package")
-}
-
+// upload the local source to the server. The source is sent in content addressed chunks so only
+// the files which changed since the last upload are sent, paths listed in .microignore are skipped.
 func upload(ctx *cli.Context, source *git.Source) (string, error) {
-	if err := grepMain(source.FullPath); err != nil {
+	if _, err := cells.Detect(source.FullPath); err != nil {
 		return "", err
 	}
 	uploadedFileName := strings.ReplaceAll(source.Folder, string(filepath.Separator), "-") + ".tar.gz"
-	path := filepath.Join(os.TempDir(), uploadedFileName)
 
-	var err error
+	// the whole repo is uploaded to support local dependencies in parents (ie service path is
+	// `repo/a/b/c` and it depends on `repo/a/b`), unchanged files aren't sent again
+	root := source.FullPath
 	if len(source.LocalRepoRoot) > 0 {
-		// @todo currently this uploads the whole repo all the time to support local dependencies
-		// in parents (ie service path is `repo/a/b/c` and it depends on `repo/a/b`).
-		// Optimise this by only uploading things that are needed.
-		err = handler.Compress(source.LocalRepoRoot, path)
-	} else {
-		err = handler.Compress(source.FullPath, path)
+		root = source.LocalRepoRoot
 	}
 
+	stats, err := srcupload.Upload(client.New(ctx), "go.micro.server", uploadedFileName, root)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	err = file.New("go.micro.server", client.New(ctx)).Upload(uploadedFileName, path)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	log.Debugf("Uploaded %v of %v chunks (%v bytes) for %v files", stats.Uploaded, stats.Chunks, stats.Bytes, stats.Files)
 	return uploadedFileName, nil
 }
 
@@ -301,39 +414,66 @@ func updateService(ctx *cli.Context, srvOpts ...micro.Option) {
 		return
 	}
 
-	wd, err := os.Getwd()
+	_, plan, err := detectCell(ctx.Args().Get(0))
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	source, err := git.ParseSourceLocal(wd, ctx.Args().Get(0))
+	source, runtimeSource, err := resolveSource(ctx, ctx.Args().Get(0))
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	var newSource string
-	if source.Local {
-		newSource, err = upload(ctx, source)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-	} else {
-		err := sourceExists(source)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
+
+	service := &runtime.Service{
+		Name:     source.RuntimeName(),
+		Source:   runtimeSource,
+		Version:  source.Ref,
+		Metadata: map[string]string{},
 	}
 
-	runtimeSource := source.RuntimeSource()
-	if source.Local {
-		runtimeSource = newSource
+	// the service is recreated with the command of the cell if the language changed
+	if plan != nil {
+		plan.Metadata(service.Metadata)
 	}
-	service := &runtime.Service{
-		Name:    source.RuntimeName(),
-		Source:  runtimeSource,
-		Version: source.Ref,
+
+	// add the resource limits, the service is recreated if they've changed
+	if err := setLimits(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := setProbes(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := setSecrets(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := setJob(ctx, service.Metadata); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if ctx.IsSet("replicas") {
+		service.Metadata[manager.ReplicasKey] = strconv.Itoa(ctx.Int("replicas"))
+	}
+
+	// the rollout strategy is passed to the runtime manager in the metadata
+	if strategy := ctx.String("strategy"); len(strategy) > 0 {
+		if cliutil.IsLocal(ctx) {
+			fmt.Println("Rollouts are not supported in the local environment")
+			os.Exit(1)
+		}
+		service.Metadata[manager.RolloutStrategyKey] = strategy
+		if ctx.IsSet("traffic") {
+			service.Metadata[manager.RolloutTrafficKey] = strconv.Itoa(ctx.Int("traffic"))
+		}
+		if ctx.IsSet("duration") {
+			service.Metadata[manager.RolloutDurationKey] = ctx.Duration("duration").String()
+		}
+		if ctx.IsSet("max-error-rate") {
+			service.Metadata[manager.RolloutMaxErrorRateKey] = strconv.FormatFloat(ctx.Float64("max-error-rate"), 'f', -1, 64)
+		}
 	}
 
 	if err := runtimeFromContext(ctx).Update(service); err != nil {
@@ -342,6 +482,60 @@ func updateService(ctx *cli.Context, srvOpts ...micro.Option) {
 	}
 }
 
+// setLimits adds the resource limits passed as flags to the service metadata
+func setLimits(ctx *cli.Context, md map[string]string) error {
+	for _, k := range limits.Keys {
+		if ctx.IsSet(k) {
+			md[k] = ctx.String(k)
+		}
+	}
+	_, err := limits.FromMetadata(md)
+	return err
+}
+
+// setJob adds the schedule and concurrency policy of a job passed as flags to the service metadata
+func setJob(ctx *cli.Context, md map[string]string) error {
+	if ctx.IsSet("schedule") {
+		md[manager.JobScheduleKey] = ctx.String("schedule")
+	}
+	if ctx.IsSet("concurrency") {
+		md[manager.JobConcurrencyKey] = ctx.String("concurrency")
+	}
+
+	_, scheduled := md[manager.JobScheduleKey]
+	_, concurrency := md[manager.JobConcurrencyKey]
+	if (scheduled || concurrency) && cliutil.IsLocal(ctx) {
+		return fmt.Errorf("Jobs are not supported in the local environment")
+	}
+	return nil
+}
+
+// setProbes adds the probes passed as flags to the service metadata
+func setProbes(ctx *cli.Context, md map[string]string) error {
+	if ctx.IsSet("liveness") {
+		md[manager.ProbeLivenessKey] = ctx.String("liveness")
+	}
+	if ctx.IsSet("readiness") {
+		md[manager.ProbeReadinessKey] = ctx.String("readiness")
+	}
+	if ctx.IsSet("probe_failures") {
+		md[manager.ProbeFailuresKey] = strconv.Itoa(ctx.Int("probe_failures"))
+	}
+	if ctx.IsSet("probe_interval") {
+		md[manager.ProbeIntervalKey] = ctx.Duration("probe_interval").String()
+	}
+	if ctx.IsSet("probe_delay") {
+		md[manager.ProbeDelayKey] = ctx.Duration("probe_delay").String()
+	}
+
+	for _, k := range manager.ProbeKeys {
+		if _, ok := md[k]; ok && cliutil.IsLocal(ctx) {
+			return fmt.Errorf("Probes are not supported in the local environment")
+		}
+	}
+	return nil
+}
+
 func getService(ctx *cli.Context, srvOpts ...micro.Option) {
 	name := ""
 	version := "latest"
@@ -405,6 +599,24 @@ func getService(ctx *cli.Context, srvOpts ...micro.Option) {
 
 	}
 
+	// stream the changes to the services
+	output := ctx.String("output")
+	if ctx.Bool("watch") {
+		req := &pb.StatusRequest{Type: typ}
+		if !list {
+			req.Service, req.Version = name, version
+		}
+		next, err := statusUpdates(ctx, req, func() ([]*runtime.Service, error) { return r.Read(readOpts...) })
+		if err == nil {
+			err = watchStatuses(output, next)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// read the service
 	services, err := r.Read(readOpts...)
 	if err != nil {
@@ -412,48 +624,23 @@ func getService(ctx *cli.Context, srvOpts ...micro.Option) {
 		return
 	}
 
-	// make sure we return UNKNOWN when empty string is supplied
-	parse := func(m string) string {
-		if len(m) == 0 {
-			return "n/a"
-		}
-		return m
-	}
-
 	// don't do anything if there's no services
-	if len(services) == 0 {
+	if len(services) == 0 && (len(output) == 0 || output == "table") {
 		return
 	}
 
 	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
 
-	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
-	fmt.Fprintln(writer, "NAME\tVERSION\tSOURCE\tSTATUS\tBUILD\tUPDATED\tMETADATA")
+	statuses := make([]*serviceStatus, 0, len(services))
 	for _, service := range services {
-		status := parse(service.Metadata["status"])
-		if status == "error" {
-			status = service.Metadata["error"]
-		}
-
-		// cut the commit down to first 7 characters
-		build := parse(service.Metadata["build"])
-		if len(build) > 7 {
-			build = build[:7]
-		}
-
-		// parse when the service was started
-		updated := parse(timeAgo(service.Metadata["started"]))
+		statuses = append(statuses, toStatus(service))
+	}
 
-		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			service.Name,
-			parse(service.Version),
-			parse(service.Source),
-			strings.ToLower(status),
-			build,
-			updated,
-			fmt.Sprintf("owner=%s,group=%s", parse(service.Metadata["owner"]), parse(service.Metadata["group"])))
+	// the transitions are listed when a single service is requested
+	if err := printStatuses(os.Stdout, output, statuses, !list); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
-	writer.Flush()
 }
 
 const (
@@ -476,6 +663,12 @@ func getLogs(ctx *cli.Context, srvOpts ...micro.Option) {
 		return
 	}
 
+	// the logs of runs of jobs are kept by the runtime manager
+	if ctx.IsSet("run") {
+		getRunLogs(ctx, name)
+		return
+	}
+
 	// get the args
 	options := []runtime.LogsOption{}
 
@@ -528,6 +721,38 @@ func getLogs(ctx *cli.Context, srvOpts ...micro.Option) {
 	}
 }
 
+// getRunLogs prints the logs of a run of a job
+func getRunLogs(ctx *cli.Context, name string) {
+	version := ctx.String("version")
+	if len(version) == 0 {
+		version = "latest"
+	}
+
+	m, err := managerFromContext(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rsp, err := m.RunLogs(context.TODO(), &pb.RunLogsRequest{
+		Service: name,
+		Version: version,
+		Run:     ctx.String("run"),
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	lines := rsp.Lines
+	if n := ctx.Int("lines"); n > 0 && n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	for _, l := range lines {
+		fmt.Println(l)
+	}
+}
+
 // logFlags is shared flags so we don't have to continually re-add
 func logFlags() []cli.Flag {
 	return []cli.Flag{
@@ -554,5 +779,9 @@ func logFlags() []cli.Flag {
 			Aliases: []string{"n"},
 			Usage:   "Set to query the last number of log events",
 		},
+		&cli.StringFlag{
+			Name:  "run",
+			Usage: "Set the id of the run of a job to show the logs for, listed by micro status",
+		},
 	}
 }
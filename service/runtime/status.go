@@ -0,0 +1,386 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2/runtime"
+	cliutil "github.com/micro/micro/v2/client/cli/util"
+	"github.com/micro/micro/v2/service/runtime/manager"
+	pb "github.com/micro/micro/v2/service/runtime/manager/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// statusInterval is how often the services are read when watching their status in the local
+// environment
+var statusInterval = time.Second * 2
+
+// statusKeys are the metadata keys which are broken out into fields of the status
+var statusKeys = []string{"status", "error", "build", manager.BuildCacheKey, "started", "restarts", "instances", "exit_code", manager.TransitionsKey, manager.RunsKey, manager.NextRunKey}
+
+// serviceStatus is the status of a service output by micro status
+type serviceStatus struct {
+	Name        string                `json:"name" yaml:"name"`
+	Version     string                `json:"version" yaml:"version"`
+	Source      string                `json:"source" yaml:"source"`
+	Status      string                `json:"status" yaml:"status"`
+	Error       string                `json:"error,omitempty" yaml:"error,omitempty"`
+	Build       string                `json:"build,omitempty" yaml:"build,omitempty"`
+	BuildCache  string                `json:"build_cache,omitempty" yaml:"build_cache,omitempty"`
+	Started     *time.Time            `json:"started,omitempty" yaml:"started,omitempty"`
+	Uptime      string                `json:"uptime,omitempty" yaml:"uptime,omitempty"`
+	Instances   *int                  `json:"instances,omitempty" yaml:"instances,omitempty"`
+	Restarts    int                   `json:"restarts" yaml:"restarts"`
+	ExitCode    *int                  `json:"exit_code,omitempty" yaml:"exit_code,omitempty"`
+	Transitions []*manager.Transition `json:"transitions,omitempty" yaml:"transitions,omitempty"`
+	NextRun     *time.Time            `json:"next_run,omitempty" yaml:"next_run,omitempty"`
+	Runs        []*manager.Run        `json:"runs,omitempty" yaml:"runs,omitempty"`
+	Metadata    map[string]string     `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// toStatus converts a service read from the runtime to its status
+func toStatus(srv *runtime.Service) *serviceStatus {
+	md := srv.Metadata
+	s := &serviceStatus{
+		Name:       srv.Name,
+		Version:    srv.Version,
+		Source:     srv.Source,
+		Status:     strings.ToLower(md["status"]),
+		Error:      md["error"],
+		Build:      md["build"],
+		BuildCache: md[manager.BuildCacheKey],
+		Metadata:   make(map[string]string),
+	}
+
+	if t, err := time.Parse(time.RFC3339, md["started"]); err == nil {
+		s.Started = &t
+		if s.Status == "running" {
+			s.Uptime = time.Since(t).Truncate(time.Second).String()
+		}
+	}
+	if v, err := strconv.Atoi(md["instances"]); err == nil {
+		s.Instances = &v
+	}
+	if v, err := strconv.Atoi(md["exit_code"]); err == nil {
+		s.ExitCode = &v
+	}
+	s.Restarts, _ = strconv.Atoi(md["restarts"])
+	if v := md[manager.TransitionsKey]; len(v) > 0 {
+		json.Unmarshal([]byte(v), &s.Transitions)
+	}
+	if t, err := time.Parse(time.RFC3339, md[manager.NextRunKey]); err == nil {
+		s.NextRun = &t
+	}
+	if v := md[manager.RunsKey]; len(v) > 0 {
+		json.Unmarshal([]byte(v), &s.Runs)
+	}
+
+	for k, v := range md {
+		if len(v) > 0 && !contains(statusKeys, k) {
+			s.Metadata[k] = v
+		}
+	}
+
+	return s
+}
+
+// contains returns true if the slice contains the value
+func contains(slice []string, v string) bool {
+	for _, s := range slice {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// changed returns true if the status has changed in a way which should be shown when watching
+func (s *serviceStatus) changed(prev *serviceStatus) bool {
+	return prev == nil ||
+		s.Source != prev.Source ||
+		s.Status != prev.Status ||
+		s.Error != prev.Error ||
+		s.Build != prev.Build ||
+		s.BuildCache != prev.BuildCache ||
+		s.Restarts != prev.Restarts ||
+		intValue(s.Instances) != intValue(prev.Instances) ||
+		intValue(s.ExitCode) != intValue(prev.ExitCode)
+}
+
+// intValue returns the string value of an optional int, or n/a if it's not set
+func intValue(i *int) string {
+	if i == nil {
+		return "n/a"
+	}
+	return strconv.Itoa(*i)
+}
+
+// printStatuses writes the statuses in the output format, table, json or yaml. The transitions and
+// the runs of jobs are included in the table if detail is true.
+func printStatuses(w io.Writer, output string, statuses []*serviceStatus, detail bool) error {
+	switch output {
+	case "json":
+		bytes, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(bytes))
+	case "yaml":
+		bytes, err := yaml.Marshal(statuses)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(bytes))
+	case "", "table":
+		printTable(w, statuses)
+		if detail {
+			printTransitions(w, statuses)
+			printRuns(w, statuses)
+		}
+	default:
+		return fmt.Errorf("Unknown output format %v, must be table, json or yaml", output)
+	}
+	return nil
+}
+
+// printTable of the statuses
+func printTable(w io.Writer, statuses []*serviceStatus) {
+	// make sure we return n/a when empty string is supplied
+	parse := func(m string) string {
+		if len(m) == 0 {
+			return "n/a"
+		}
+		return m
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "NAME\tVERSION\tSOURCE\tSTATUS\tINSTANCES\tRESTARTS\tUPTIME\tEXIT\tBUILD\tCACHE\tUPDATED\tMETADATA")
+	for _, s := range statuses {
+		status := parse(s.Status)
+		if status == "error" {
+			status = s.Error
+		}
+
+		// cut the commit down to first 7 characters
+		build := parse(s.Build)
+		if len(build) > 7 {
+			build = build[:7]
+		}
+
+		// parse when the service was started
+		var updated string
+		if s.Started != nil {
+			updated = timeAgo(s.Started.Format(time.RFC3339))
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name,
+			parse(s.Version),
+			parse(s.Source),
+			strings.ToLower(status),
+			intValue(s.Instances),
+			s.Restarts,
+			parse(s.Uptime),
+			intValue(s.ExitCode),
+			build,
+			parse(s.BuildCache),
+			parse(updated),
+			formatMetadata(s.Metadata))
+	}
+	writer.Flush()
+}
+
+// formatMetadata as a comma separated list of key=value pairs, the owner and group are always
+// listed first
+func formatMetadata(md map[string]string) string {
+	parse := func(m string) string {
+		if len(m) == 0 {
+			return "n/a"
+		}
+		return m
+	}
+
+	pairs := []string{"owner=" + parse(md["owner"]), "group=" + parse(md["group"])}
+
+	var keys []string
+	for k := range md {
+		if k != "owner" && k != "group" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+md[k])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// printTransitions of the statuses, newest first
+func printTransitions(w io.Writer, statuses []*serviceStatus) {
+	for _, s := range statuses {
+		if len(s.Transitions) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\nTransitions of %v:%v\n", s.Name, s.Version)
+		writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', tabwriter.AlignRight)
+		fmt.Fprintln(writer, "TIME\tSTATUS\tERROR")
+		for i := len(s.Transitions) - 1; i >= 0; i-- {
+			t := s.Transitions[i]
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", timeAgo(t.Time.Format(time.RFC3339)), strings.ToLower(t.Status), t.Error)
+		}
+		writer.Flush()
+	}
+}
+
+// printRuns of the jobs in the statuses, newest first
+func printRuns(w io.Writer, statuses []*serviceStatus) {
+	for _, s := range statuses {
+		if len(s.Runs) == 0 && s.NextRun == nil {
+			continue
+		}
+
+		fmt.Fprintf(w, "\nRuns of %v:%v", s.Name, s.Version)
+		if s.NextRun != nil {
+			fmt.Fprintf(w, ", next run at %v", s.NextRun.Format(time.RFC3339))
+		}
+		fmt.Fprintln(w)
+
+		writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', tabwriter.AlignRight)
+		fmt.Fprintln(writer, "RUN\tSTARTED\tDURATION\tSTATUS\tEXIT\tERROR")
+		for _, r := range s.Runs {
+			duration := "n/a"
+			if !r.Finished.IsZero() {
+				duration = r.Finished.Sub(r.Started).Truncate(time.Second).String()
+			} else if r.Status == manager.RunRunning {
+				duration = time.Since(r.Started).Truncate(time.Second).String()
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				r.ID,
+				timeAgo(r.Started.Format(time.RFC3339)),
+				duration,
+				r.Status,
+				intValue(r.ExitCode),
+				r.Error)
+		}
+		writer.Flush()
+	}
+}
+
+// statusUpdates returns a func which waits for the statuses of the services to change and returns
+// them. The runtime manager streams them as it changes them, the local runtime has no manager so
+// it's read periodically.
+func statusUpdates(ctx *cli.Context, req *pb.StatusRequest, read func() ([]*runtime.Service, error)) (func() ([]*runtime.Service, error), error) {
+	if cliutil.IsLocal(ctx) {
+		first := true
+		return func() ([]*runtime.Service, error) {
+			if !first {
+				time.Sleep(statusInterval)
+			}
+			first = false
+			return read()
+		}, nil
+	}
+
+	m, err := managerFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := m.Status(context.TODO(), req)
+	if err != nil {
+		return nil, err
+	}
+	return func() ([]*runtime.Service, error) {
+		rsp, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("The runtime closed the stream of statuses")
+		} else if err != nil {
+			return nil, err
+		}
+		srvs := make([]*runtime.Service, 0, len(rsp.Services))
+		for _, s := range rsp.Services {
+			srvs = append(srvs, &runtime.Service{Name: s.Name, Version: s.Version, Source: s.Source, Metadata: s.Metadata})
+		}
+		return srvs, nil
+	}, nil
+}
+
+// watchStatuses prints the services which changed, or were deleted, each time next returns them
+// until the process is interrupted. The table format prints a row per change, the json format
+// prints an object per line and the yaml format prints a document per change.
+func watchStatuses(output string, next func() ([]*runtime.Service, error)) error {
+	prev := make(map[string]*serviceStatus)
+	header := false
+
+	for {
+		srvs, err := next()
+		if err != nil {
+			return err
+		}
+
+		var changes []*serviceStatus
+		curr := make(map[string]*serviceStatus, len(srvs))
+		for _, srv := range srvs {
+			s := toStatus(srv)
+			key := s.Name + ":" + s.Version
+			curr[key] = s
+			if s.changed(prev[key]) {
+				changes = append(changes, s)
+			}
+		}
+		for key, s := range prev {
+			if _, ok := curr[key]; !ok {
+				changes = append(changes, &serviceStatus{Name: s.Name, Version: s.Version, Source: s.Source, Status: "deleted"})
+			}
+		}
+		prev = curr
+
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+
+		for _, s := range changes {
+			switch output {
+			case "json":
+				bytes, err := json.Marshal(s)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(bytes))
+			case "yaml":
+				bytes, err := yaml.Marshal(s)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("---\n%s", string(bytes))
+			default:
+				writer := tabwriter.NewWriter(os.Stdout, 20, 8, 1, ' ', 0)
+				if !header {
+					fmt.Fprintln(writer, "TIME\tNAME\tVERSION\tSTATUS\tINSTANCES\tRESTARTS\tEXIT")
+					header = true
+				}
+				status := s.Status
+				if status == "error" {
+					status = s.Error
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+					time.Now().Format("15:04:05"),
+					s.Name,
+					s.Version,
+					status,
+					intValue(s.Instances),
+					s.Restarts,
+					intValue(s.ExitCode))
+				writer.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/runtime"
+)
+
+func TestToStatus(t *testing.T) {
+	started := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	srv := &runtime.Service{
+		Name:    "go.micro.service.foo",
+		Version: "latest",
+		Source:  "github.com/micro/services/foo",
+		Metadata: map[string]string{
+			"status":      "running",
+			"started":     started,
+			"restarts":    "2",
+			"instances":   "1",
+			"exit_code":   "1",
+			"build_cache": "hit",
+			"transitions": `[{"time":"2020-07-01T00:00:00Z","status":"error","error":"exit status 1"},{"time":"2020-07-01T00:01:00Z","status":"running"}]`,
+			"owner":       "john",
+		},
+	}
+
+	s := toStatus(srv)
+	if s.Status != "running" || s.Restarts != 2 || intValue(s.Instances) != "1" || intValue(s.ExitCode) != "1" {
+		t.Errorf("Unexpected status: %+v", s)
+	}
+	if s.BuildCache != "hit" {
+		t.Errorf("Expected the build to be a cache hit, got %v", s.BuildCache)
+	}
+	if len(s.Uptime) == 0 || s.Started == nil {
+		t.Errorf("Expected the uptime of a running service")
+	}
+	if len(s.Transitions) != 2 || s.Transitions[0].Error != "exit status 1" {
+		t.Errorf("Unexpected transitions: %+v", s.Transitions)
+	}
+	if len(s.Metadata) != 1 || s.Metadata["owner"] != "john" {
+		t.Errorf("Expected only the remaining metadata, got %v", s.Metadata)
+	}
+
+	// the json output should be a list of statuses
+	var buf bytes.Buffer
+	if err := printStatuses(&buf, "json", []*serviceStatus{s}, false); err != nil {
+		t.Fatalf("Unexpected error printing json: %v", err)
+	}
+	var out []*serviceStatus
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Unexpected error decoding json: %v", err)
+	}
+	if len(out) != 1 || out[0].Name != srv.Name {
+		t.Errorf("Unexpected json output: %v", buf.String())
+	}
+
+	if err := printStatuses(&buf, "xml", nil, false); err == nil {
+		t.Errorf("Expected an error for an unknown output format")
+	}
+
+	// only changes which are shown when watching should be detected
+	next := toStatus(srv)
+	if next.changed(s) {
+		t.Errorf("Expected no change")
+	}
+	next.Restarts++
+	if !next.changed(s) {
+		t.Errorf("Expected a change in restarts")
+	}
+}
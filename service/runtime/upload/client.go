@@ -0,0 +1,98 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/micro/go-micro/v2/client"
+	pb "github.com/micro/micro/v2/service/runtime/upload/proto"
+)
+
+// missingBatch is the max number of chunks checked per request
+var missingBatch = 1000
+
+// Stats of an upload
+type Stats struct {
+	// Files in the source, excluding those ignored
+	Files int
+	// Chunks in the source
+	Chunks int
+	// Uploaded is the number of chunks sent to the server
+	Uploaded int
+	// Bytes sent to the server
+	Bytes int64
+}
+
+// Upload the source in the directory to the service, only sending the chunks it doesn't have. The
+// server writes the source to an archive with the name.
+func Upload(c client.Client, service, name, root string) (*Stats, error) {
+	ignore, err := LoadIgnore(root)
+	if err != nil {
+		return nil, err
+	}
+	m, locations, err := NewManifest(root, ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	// the manifest is chunked so large sources don't exceed the max message size
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	manifest := make(map[string][]byte)
+	var manifestChunks []string
+	for i := 0; i < len(bytes); i += ChunkSize {
+		end := i + ChunkSize
+		if end > len(bytes) {
+			end = len(bytes)
+		}
+		hash := Hash(bytes[i:end])
+		manifest[hash] = bytes[i:end]
+		manifestChunks = append(manifestChunks, hash)
+	}
+
+	stats := &Stats{Files: len(m.Files), Chunks: len(locations)}
+	srv := pb.NewUploadService(service, c)
+
+	chunks := make([]string, 0, len(locations)+len(manifest))
+	for c := range locations {
+		chunks = append(chunks, c)
+	}
+	for c := range manifest {
+		chunks = append(chunks, c)
+	}
+
+	for i := 0; i < len(chunks); i += missingBatch {
+		end := i + missingBatch
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+
+		rsp, err := srv.Missing(context.TODO(), &pb.MissingRequest{Chunks: chunks[i:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range rsp.Chunks {
+			data, ok := manifest[c]
+			if loc, isFile := locations[c]; !ok && isFile {
+				if data, err = loc.Read(); err != nil {
+					return nil, err
+				}
+			} else if !ok {
+				continue
+			}
+			if _, err := srv.Write(context.TODO(), &pb.WriteRequest{Chunk: c, Data: data}); err != nil {
+				return nil, err
+			}
+			stats.Uploaded++
+			stats.Bytes += int64(len(data))
+		}
+	}
+
+	if _, err := srv.Assemble(context.TODO(), &pb.AssembleRequest{Name: name, Manifest: manifestChunks}); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
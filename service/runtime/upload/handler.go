@@ -0,0 +1,206 @@
+package upload
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/server"
+	pb "github.com/micro/micro/v2/service/runtime/upload/proto"
+)
+
+// chunkDir is the directory in the upload directory the chunks are kept in
+const chunkDir = "chunks"
+
+// RegisterHandler registers the upload handler with the server, the archives are written to the
+// directory the runtime extracts uploaded sources from
+func RegisterHandler(s server.Server, dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, chunkDir), 0700); err != nil {
+		return err
+	}
+	return pb.RegisterUploadHandler(s, &Handler{Dir: dir})
+}
+
+// Handler receives the chunks of sources and reassembles them into archives
+type Handler struct {
+	// Dir the archives are written to
+	Dir string
+}
+
+// Missing returns the chunks which haven't been uploaded. The chunks which have been are touched so
+// they're not removed as unused before they're assembled.
+func (h *Handler) Missing(ctx context.Context, req *pb.MissingRequest, rsp *pb.MissingResponse) error {
+	now := time.Now()
+	for _, c := range req.Chunks {
+		if !validChunk.MatchString(c) {
+			return errors.BadRequest("go.micro.server", "invalid chunk %v", c)
+		}
+		if err := os.Chtimes(h.chunkPath(c), now, now); os.IsNotExist(err) {
+			rsp.Chunks = append(rsp.Chunks, c)
+		} else if err != nil {
+			return errors.InternalServerError("go.micro.server", err.Error())
+		}
+	}
+	return nil
+}
+
+// Write a chunk, the data must match the hash it's addressed by
+func (h *Handler) Write(ctx context.Context, req *pb.WriteRequest, rsp *pb.WriteResponse) error {
+	if !validChunk.MatchString(req.Chunk) {
+		return errors.BadRequest("go.micro.server", "invalid chunk %v", req.Chunk)
+	}
+	if Hash(req.Data) != req.Chunk {
+		return errors.BadRequest("go.micro.server", "chunk %v doesn't match its data", req.Chunk)
+	}
+
+	// write to a temp file first so a partially written chunk is never used
+	tmp, err := ioutil.TempFile(filepath.Join(h.Dir, chunkDir), "tmp-")
+	if err != nil {
+		return errors.InternalServerError("go.micro.server", err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(req.Data); err != nil {
+		tmp.Close()
+		return errors.InternalServerError("go.micro.server", err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.InternalServerError("go.micro.server", err.Error())
+	}
+	if err := os.Rename(tmp.Name(), h.chunkPath(req.Chunk)); err != nil {
+		return errors.InternalServerError("go.micro.server", err.Error())
+	}
+	return nil
+}
+
+// Assemble the archive from the chunks listed in the manifest
+func (h *Handler) Assemble(ctx context.Context, req *pb.AssembleRequest, rsp *pb.AssembleResponse) error {
+	if len(req.Name) == 0 || req.Name != filepath.Base(req.Name) || strings.HasPrefix(req.Name, ".") {
+		return errors.BadRequest("go.micro.server", "invalid name %v", req.Name)
+	}
+
+	// the manifest is uploaded as chunks like the files it lists
+	var buf bytes.Buffer
+	for _, c := range req.Manifest {
+		if err := h.readChunk(c, &buf); err != nil {
+			return errors.BadRequest("go.micro.server", err.Error())
+		}
+	}
+	var m *Manifest
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		return errors.BadRequest("go.micro.server", "invalid manifest: %v", err)
+	}
+
+	n, err := h.assemble(req.Name, m)
+	if err != nil {
+		return errors.InternalServerError("go.micro.server", err.Error())
+	}
+	rsp.Files = int64(n)
+	return nil
+}
+
+// assemble writes the files in the manifest to a .tar.gz archive, returning the number of files
+func (h *Handler) assemble(name string, m *Manifest) (int, error) {
+	tmp, err := ioutil.TempFile(h.Dir, "tmp-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(zw)
+	now := time.Now()
+
+	var n int
+	for _, f := range m.Files {
+		p := path.Clean(f.Path)
+		if path.IsAbs(p) || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+			return 0, fmt.Errorf("Invalid path %v", f.Path)
+		}
+
+		header := &tar.Header{
+			Name:    p,
+			Mode:    int64(f.Mode.Perm()),
+			ModTime: now,
+		}
+		if f.Mode.IsDir() {
+			header.Typeflag = tar.TypeDir
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = f.Size
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return 0, err
+		}
+
+		var written int64
+		for _, c := range f.Chunks {
+			cw := &countWriter{w: tw}
+			if err := h.readChunk(c, cw); err != nil {
+				return 0, err
+			}
+			written += cw.n
+		}
+		if written != header.Size {
+			return 0, fmt.Errorf("Size of %v doesn't match its chunks", f.Path)
+		}
+		n++
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	return n, os.Rename(tmp.Name(), filepath.Join(h.Dir, name))
+}
+
+// readChunk copies the chunk to the writer
+func (h *Handler) readChunk(c string, w io.Writer) error {
+	if !validChunk.MatchString(c) {
+		return fmt.Errorf("Invalid chunk %v", c)
+	}
+	f, err := os.Open(h.chunkPath(c))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("Chunk %v has not been uploaded", c)
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func (h *Handler) chunkPath(c string) string {
+	return filepath.Join(h.Dir, chunkDir, c)
+}
+
+// countWriter counts the bytes written to the underlying writer
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,150 @@
+package upload
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile is the name of the file in the root of the source listing the paths which shouldn't
+// be uploaded, using the same syntax as .gitignore
+const IgnoreFile = ".microignore"
+
+// defaultIgnore are the patterns which are never uploaded
+var defaultIgnore = []string{".git/"}
+
+// Ignore matches the paths listed in a .microignore file
+type Ignore struct {
+	rules []*rule
+}
+
+type rule struct {
+	// segments of the pattern split by /
+	segments []string
+	// negate re-includes paths matched by previous rules, e.g. !keep.txt
+	negate bool
+	// dirOnly only matches directories, e.g. build/
+	dirOnly bool
+	// anchored patterns contain a slash so only match from the root, others match at any depth
+	anchored bool
+}
+
+// LoadIgnore reads the .microignore file in the directory, if it exists
+func LoadIgnore(dir string) (*Ignore, error) {
+	f, err := os.Open(filepath.Join(dir, IgnoreFile))
+	if os.IsNotExist(err) {
+		return ParseIgnore(strings.NewReader(""))
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseIgnore(f)
+}
+
+// ParseIgnore parses the patterns in a .microignore file, one per line. Blank lines and lines
+// starting with # are skipped.
+func ParseIgnore(r io.Reader) (*Ignore, error) {
+	lines := append([]string{}, defaultIgnore...)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	i := &Ignore{}
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if len(l) == 0 || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		r := &rule{}
+		if strings.HasPrefix(l, "!") {
+			r.negate = true
+			l = l[1:]
+		}
+		if strings.HasSuffix(l, "/") {
+			r.dirOnly = true
+			l = strings.TrimSuffix(l, "/")
+		}
+		if strings.Contains(l, "/") {
+			r.anchored = true
+			l = strings.TrimPrefix(l, "/")
+		}
+		if len(l) == 0 {
+			continue
+		}
+
+		r.segments = strings.Split(l, "/")
+		i.rules = append(i.rules, r)
+	}
+
+	return i, nil
+}
+
+// Match returns true if the path, relative to the root of the source and separated by /, should
+// be ignored. The last matching pattern wins so later negated patterns re-include paths, except
+// those in an ignored directory.
+func (i *Ignore) Match(p string, dir bool) bool {
+	segments := strings.Split(p, "/")
+	for n := 1; n < len(segments); n++ {
+		if i.match(segments[:n], true) {
+			return true
+		}
+	}
+	return i.match(segments, dir)
+}
+
+func (i *Ignore) match(segments []string, dir bool) bool {
+	var ignored bool
+	for _, r := range i.rules {
+		if r.dirOnly && !dir {
+			continue
+		}
+		if r.match(segments) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func (r *rule) match(segments []string) bool {
+	if r.anchored {
+		return matchSegments(r.segments, segments)
+	}
+	for i := range segments {
+		if matchSegments(r.segments, segments[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches the segments of a path against those of a pattern, ** matches any
+// number of segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
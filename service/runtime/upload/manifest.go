@@ -0,0 +1,142 @@
+// Package upload sends the source of local services to the server in content addressed chunks, so
+// only the chunks the server doesn't already have are sent, and reassembles the tree on the server
+package upload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// ChunkSize is the max size of a chunk of a file
+var ChunkSize = 512 * 1024
+
+// validChunk matches the hex encoded sha256 hash chunks are addressed by
+var validChunk = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// Manifest lists the files in the source and the chunks they're made of
+type Manifest struct {
+	Files []*File `json:"files"`
+}
+
+// File in the source
+type File struct {
+	// Path relative to the root of the source, separated by /
+	Path string `json:"path"`
+	// Mode of the file, including whether it's a directory
+	Mode os.FileMode `json:"mode"`
+	// Size of the file in bytes
+	Size int64 `json:"size"`
+	// Chunks are the hashes of the chunks of the file, in order
+	Chunks []string `json:"chunks,omitempty"`
+}
+
+// Hash returns the address of the chunk
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewManifest walks the source, skipping the paths which are ignored, and splits each file into
+// chunks. The location of each chunk is returned so it can be read again if it needs uploading.
+func NewManifest(root string, ignore *Ignore) (*Manifest, map[string]*Location, error) {
+	m := &Manifest{}
+	locations := make(map[string]*Location)
+
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if ignore != nil && ignore.Match(rel, fi.IsDir()) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// symlinks and other special files aren't uploaded
+		if fi.IsDir() {
+			m.Files = append(m.Files, &File{Path: rel, Mode: fi.Mode()})
+			return nil
+		} else if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		f := &File{Path: rel, Mode: fi.Mode()}
+		if err := chunkFile(p, f, locations); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, locations, nil
+}
+
+// Location of a chunk in a local file
+type Location struct {
+	Path   string
+	Offset int64
+	Size   int
+}
+
+// Read the chunk from the file
+func (l *Location) Read() ([]byte, error) {
+	f, err := os.Open(l.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data := make([]byte, l.Size)
+	if _, err := f.ReadAt(data, l.Offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// chunkFile splits the file into chunks, adding their hashes to the file and their locations to
+// the map
+func chunkFile(p string, f *File, locations map[string]*Location) error {
+	r, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	buf := make([]byte, ChunkSize)
+	var offset int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			hash := Hash(buf[:n])
+			f.Chunks = append(f.Chunks, hash)
+			if _, ok := locations[hash]; !ok {
+				locations[hash] = &Location{Path: p, Offset: offset, Size: n}
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			f.Size = offset
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package upload
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultDir is the directory the server writes uploaded sources to
+var DefaultDir = filepath.Join(os.TempDir(), "micro", "uploads")
+
+// Unused returns the paths of the archives in the directory which aren't in use and the chunks
+// which haven't been uploaded or checked for within the age. Newer archives are kept since they
+// may have just been uploaded for a service which is about to be created.
+func Unused(dir string, inUse map[string]bool, age time.Duration) ([]string, error) {
+	cutoff := time.Now().Add(-age)
+
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".tar.gz") {
+			continue
+		}
+		if inUse[f.Name()] || f.ModTime().After(cutoff) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, f.Name()))
+	}
+
+	chunks, err := ioutil.ReadDir(filepath.Join(dir, chunkDir))
+	if os.IsNotExist(err) {
+		return paths, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.ModTime().After(cutoff) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, chunkDir, c.Name()))
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,157 @@
+package upload
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/micro/micro/v2/service/runtime/upload/proto"
+)
+
+func TestIgnore(t *testing.T) {
+	ignore, err := ParseIgnore(strings.NewReader(`
+# dependencies
+vendor/
+node_modules
+/bin
+*.log
+!keep.log
+docs/**/*.png
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing ignore file: %v", err)
+	}
+
+	tests := []struct {
+		Path    string
+		Dir     bool
+		Ignored bool
+	}{
+		{".git", true, true},
+		{"vendor", true, true},
+		{"vendor", false, false},
+		{"a/node_modules/b.js", false, true},
+		{"bin", true, true},
+		{"a/bin", true, false},
+		{"a/debug.log", false, true},
+		{"a/keep.log", false, false},
+		{"docs/a/b/c.png", false, true},
+		{"docs/c.go", false, false},
+		{"main.go", false, false},
+	}
+
+	for _, tc := range tests {
+		if ignored := ignore.Match(tc.Path, tc.Dir); ignored != tc.Ignored {
+			t.Errorf("Expected %v (dir %v) ignored to be %v, got %v", tc.Path, tc.Dir, tc.Ignored, ignored)
+		}
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	src, err := ioutil.TempDir("", "upload-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "upload-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	// a file larger than a chunk is split into multiple chunks
+	defer func(size int) { ChunkSize = size }(ChunkSize)
+	ChunkSize = 4
+
+	os.MkdirAll(filepath.Join(src, "a", "vendor"), 0755)
+	ioutil.WriteFile(filepath.Join(src, "main.go"), []byte("package main"), 0644)
+	ioutil.WriteFile(filepath.Join(src, "a", "a.go"), []byte("package a"), 0644)
+	ioutil.WriteFile(filepath.Join(src, "a", "vendor", "dep.go"), []byte("package dep"), 0644)
+	ioutil.WriteFile(filepath.Join(src, IgnoreFile), []byte("vendor/"), 0644)
+
+	ignore, err := LoadIgnore(src)
+	if err != nil {
+		t.Fatalf("Unexpected error loading ignore file: %v", err)
+	}
+	m, locations, err := NewManifest(src, ignore)
+	if err != nil {
+		t.Fatalf("Unexpected error creating manifest: %v", err)
+	}
+	if len(m.Files) != 4 {
+		t.Fatalf("Expected 4 files in the manifest, got %v", len(m.Files))
+	}
+
+	h := &Handler{Dir: dst}
+	os.MkdirAll(filepath.Join(dst, chunkDir), 0700)
+
+	var missing pb.MissingResponse
+	var chunks []string
+	for c := range locations {
+		chunks = append(chunks, c)
+	}
+	if err := h.Missing(context.TODO(), &pb.MissingRequest{Chunks: chunks}, &missing); err != nil {
+		t.Fatalf("Unexpected error checking chunks: %v", err)
+	}
+	if len(missing.Chunks) != len(chunks) {
+		t.Fatalf("Expected %v missing chunks, got %v", len(chunks), len(missing.Chunks))
+	}
+	for _, c := range missing.Chunks {
+		data, err := locations[c].Read()
+		if err != nil {
+			t.Fatalf("Unexpected error reading chunk: %v", err)
+		}
+		if err := h.Write(context.TODO(), &pb.WriteRequest{Chunk: c, Data: data}, &pb.WriteResponse{}); err != nil {
+			t.Fatalf("Unexpected error writing chunk: %v", err)
+		}
+	}
+	if err := h.Write(context.TODO(), &pb.WriteRequest{Chunk: chunks[0], Data: []byte("foo")}, &pb.WriteResponse{}); err == nil {
+		t.Errorf("Expected an error writing a chunk which doesn't match its hash")
+	}
+
+	// once uploaded no chunks are missing
+	missing = pb.MissingResponse{}
+	h.Missing(context.TODO(), &pb.MissingRequest{Chunks: chunks}, &missing)
+	if len(missing.Chunks) != 0 {
+		t.Errorf("Expected no missing chunks, got %v", len(missing.Chunks))
+	}
+
+	n, err := h.assemble("src.tar.gz", m)
+	if err != nil || n != 4 {
+		t.Fatalf("Expected 4 files to be assembled, got %v: %v", n, err)
+	}
+
+	f, err := os.Open(filepath.Join(dst, "src.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		data, _ := ioutil.ReadAll(tr)
+		files[header.Name] = string(data)
+	}
+	if files["a/a.go"] != "package a" || files["main.go"] != "package main" {
+		t.Errorf("Expected the files to be reassembled, got %v", files)
+	}
+	if _, ok := files["a/vendor/dep.go"]; ok {
+		t.Errorf("Expected the vendor directory to be ignored")
+	}
+
+	if _, err := h.assemble("bad.tar.gz", &Manifest{Files: []*File{{Path: "../etc/passwd"}}}); err == nil {
+		t.Errorf("Expected an error assembling a path outside the archive")
+	}
+}
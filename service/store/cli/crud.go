@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"text/tabwriter"
@@ -12,7 +14,12 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/micro/cli/v2"
 	"github.com/micro/go-micro/v2/config/cmd"
+	microerrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/metadata"
 	"github.com/micro/go-micro/v2/store"
+	storeproto "github.com/micro/go-micro/v2/store/service/proto"
+	"github.com/micro/micro/v2/service/store/conditional"
+	"github.com/micro/micro/v2/service/store/handler"
 	"github.com/pkg/errors"
 )
 
@@ -47,7 +54,7 @@ func Read(ctx *cli.Context) error {
 	default:
 		if ctx.Bool("verbose") {
 			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
-			fmt.Fprintf(w, "%v \t %v \t %v\n", "KEY", "VALUE", "EXPIRY")
+			fmt.Fprintf(w, "%v \t %v \t %v \t %v\n", "KEY", "VALUE", "EXPIRY", "VERSION")
 			for _, r := range records {
 				var key, value, expiry string
 				key = r.Key
@@ -65,7 +72,11 @@ func Read(ctx *cli.Context) error {
 				} else {
 					expiry = humanize.Time(time.Now().Add(r.Expiry))
 				}
-				fmt.Fprintf(w, "%v \t %v \t %v\n", key, value, expiry)
+				version := conditional.Version(r)
+				if len(version) == 0 {
+					version = "None"
+				}
+				fmt.Fprintf(w, "%v \t %v \t %v \t %v\n", key, value, expiry, version)
 			}
 			w.Flush()
 			return nil
@@ -97,6 +108,9 @@ func Write(ctx *cli.Context) error {
 		record.Expiry = d
 	}
 
+	if len(ctx.String("if-version")) > 0 || ctx.Bool("if-absent") {
+		return writeIf(ctx, record)
+	}
 	store := *cmd.DefaultOptions().Store
 	if err := store.Write(record); err != nil {
 		return errors.Wrap(err, "couldn't write")
@@ -104,6 +118,52 @@ func Write(ctx *cli.Context) error {
 	return nil
 }
 
+// writeIf writes the record through the store service if the version it has matches, or there
+// isn't one, which the store interface can't express
+func writeIf(ctx *cli.Context, r *store.Record) error {
+	client := storeproto.NewStoreService("go.micro.store", *cmd.DefaultOptions().Client)
+	_, err := client.Write(conditionContext(ctx), &storeproto.WriteRequest{
+		Record: &storeproto.Record{
+			Key:    r.Key,
+			Value:  r.Value,
+			Expiry: int64(r.Expiry.Seconds()),
+		},
+		Options: &storeproto.WriteOptions{
+			Database: ctx.String("database"),
+			Table:    ctx.String("table"),
+		},
+	})
+	return conditionError(ctx, err, "couldn't write")
+}
+
+// conditionContext returns a context with the condition of the write or delete
+func conditionContext(ctx *cli.Context) context.Context {
+	md := metadata.Metadata{}
+	if v := ctx.String("if-version"); len(v) > 0 {
+		md[handler.IfVersionHeader] = v
+	}
+	if ctx.Bool("if-absent") {
+		md[handler.IfAbsentHeader] = "true"
+	}
+	return metadata.NewContext(context.Background(), md)
+}
+
+// conditionError returns an error saying which condition wasn't met for conflicts
+func conditionError(ctx *cli.Context, err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	switch merr := microerrors.Parse(err.Error()); {
+	case merr.Code == http.StatusConflict && ctx.Bool("if-absent"):
+		return errors.New("the record already exists")
+	case merr.Code == http.StatusConflict:
+		return errors.New("the record doesn't have the version, it's been changed or deleted")
+	case merr.Code == http.StatusNotImplemented:
+		return errors.New("the store service can't guarantee conditional writes with its backend, only the memory, file and cockroach stores support them")
+	}
+	return errors.Wrap(err, msg)
+}
+
 // List retrieves keys
 func List(ctx *cli.Context) error {
 	if err := initStore(ctx); err != nil {
@@ -147,6 +207,17 @@ func Delete(ctx *cli.Context) error {
 	if len(ctx.Args().Slice()) == 0 {
 		return errors.New("key is required")
 	}
+	if len(ctx.String("if-version")) > 0 {
+		client := storeproto.NewStoreService("go.micro.store", *cmd.DefaultOptions().Client)
+		_, err := client.Delete(conditionContext(ctx), &storeproto.DeleteRequest{
+			Key: ctx.Args().First(),
+			Options: &storeproto.DeleteOptions{
+				Database: ctx.String("database"),
+				Table:    ctx.String("table"),
+			},
+		})
+		return conditionError(ctx, err, "couldn't delete key "+ctx.Args().First())
+	}
 	store := *cmd.DefaultOptions().Store
 	if err := store.Delete(ctx.Args().First()); err != nil {
 		return errors.Wrapf(err, "couldn't delete key %s", ctx.Args().First())
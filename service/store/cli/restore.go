@@ -1,36 +1,43 @@
 package cli
 
 import (
-	"net/url"
+	"time"
 
 	"github.com/micro/cli/v2"
 	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/store"
 	"github.com/micro/micro/v2/service/store/snapshot"
 	"github.com/pkg/errors"
 )
 
-// Restore is the entrypoint for micro store restore
+// Restore is the entrypoint for micro store restore. Restoring the state at a time deletes the keys
+// of the table which didn't exist then.
 func Restore(ctx *cli.Context) error {
 	s, err := makeStore(ctx)
 	if err != nil {
 		return errors.Wrap(err, "couldn't construct a store")
 	}
 	log := logger.DefaultLogger
-	var rs snapshot.Restore
 	source := ctx.String("source")
 
 	if len(source) == 0 {
 		return errors.New("source flag must be set")
 	}
-	u, err := url.Parse(source)
-	if err != nil {
-		return errors.Wrap(err, "source is invalid")
+	opts := []snapshot.RestoreOption{
+		snapshot.Source(source),
+		snapshot.RestoreTable(s.Options().Database, s.Options().Table),
+	}
+	at := ctx.String("at")
+	if len(at) > 0 {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return errors.Wrap(err, "at must be a time in RFC3339 format")
+		}
+		opts = append(opts, snapshot.RestoreAt(t))
 	}
-	switch u.Scheme {
-	case "file":
-		rs = snapshot.NewFileRestore(snapshot.Source(source))
-	default:
-		return errors.Errorf("unsupported source scheme: %s", u.Scheme)
+	rs, err := snapshot.NewRestore(opts...)
+	if err != nil {
+		return err
 	}
 
 	err = rs.Init()
@@ -38,12 +45,28 @@ func Restore(ctx *cli.Context) error {
 		return errors.Wrap(err, "failed to initialise the restorer")
 	}
 
+	restored, deleted, err := restore(s, rs, len(at) > 0)
+	if err != nil {
+		return err
+	}
+	log.Logf(logger.DebugLevel, "Restored %d records and deleted %d", restored, deleted)
+	return nil
+}
+
+// restore writes the records of the snapshot to the store. With prune the keys of the store which
+// aren't in the snapshot are deleted once every record has been read, so the store has the state of
+// the snapshot.
+func restore(s store.Store, rs snapshot.Restore, prune bool) (uint64, uint64, error) {
+	log := logger.DefaultLogger
+
 	recordChan, err := rs.Start()
 	if err != nil {
-		return errors.Wrap(err, "couldn't start the restorer")
+		return 0, 0, errors.Wrap(err, "couldn't start the restorer")
 	}
 	counter := uint64(0)
+	keys := make(map[string]bool)
 	for r := range recordChan {
+		keys[r.Key] = true
 		err := s.Write(r)
 		if err != nil {
 			log.Logf(logger.ErrorLevel, "couldn't write key %s to store %s", r.Key, s.String())
@@ -51,6 +74,27 @@ func Restore(ctx *cli.Context) error {
 			counter++
 		}
 	}
-	log.Logf(logger.DebugLevel, "Restored %d records", counter)
-	return nil
+	if err := rs.Wait(); err != nil {
+		return counter, 0, errors.Wrapf(err, "restore failed after %d records", counter)
+	}
+	if !prune {
+		return counter, 0, nil
+	}
+
+	existing, err := s.List()
+	if err != nil {
+		return counter, 0, errors.Wrap(err, "couldn't list the keys to delete")
+	}
+	deleted := uint64(0)
+	for _, k := range existing {
+		if keys[k] {
+			continue
+		}
+		if err := s.Delete(k); err != nil {
+			log.Logf(logger.ErrorLevel, "couldn't delete key %s from store %s", k, s.String())
+		} else {
+			deleted++
+		}
+	}
+	return counter, deleted, nil
 }
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/service/store/snapshot"
+)
+
+// testRestore emits the records it's created with
+type testRestore struct {
+	records []*store.Record
+}
+
+func (r *testRestore) Init(...snapshot.RestoreOption) error { return nil }
+
+func (r *testRestore) Start() (<-chan *store.Record, error) {
+	c := make(chan *store.Record, len(r.records))
+	for _, rec := range r.records {
+		c <- rec
+	}
+	close(c)
+	return c, nil
+}
+
+func (r *testRestore) Wait() error { return nil }
+
+func TestRestore(t *testing.T) {
+	for _, prune := range []bool{false, true} {
+		s := memory.NewStore()
+		s.Write(&store.Record{Key: "foo", Value: []byte("old")})
+		s.Write(&store.Record{Key: "extra", Value: []byte("extra")})
+
+		rs := &testRestore{records: []*store.Record{
+			{Key: "foo", Value: []byte("foo")},
+			{Key: "bar", Value: []byte("bar")},
+		}}
+		restored, deleted, err := restore(s, rs, prune)
+		if err != nil {
+			t.Fatalf("Unexpected error restoring: %v", err)
+		}
+		if restored != 2 {
+			t.Errorf("Expected 2 records to be restored, got %v", restored)
+		}
+
+		keys, _ := s.List()
+		if prune && (deleted != 1 || len(keys) != 2) {
+			t.Errorf("Expected the extra key to be deleted, got keys %v", keys)
+		} else if !prune && (deleted != 0 || len(keys) != 3) {
+			t.Errorf("Expected the extra key to be kept, got keys %v", keys)
+		}
+		if r, err := s.Read("foo"); err != nil || string(r[0].Value) != "foo" {
+			t.Errorf("Expected foo to be restored")
+		}
+	}
+}
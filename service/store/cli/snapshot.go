@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"net/url"
-
 	"github.com/micro/cli/v2"
 	"github.com/micro/go-micro/v2/logger"
 	"github.com/micro/micro/v2/service/store/snapshot"
@@ -17,20 +15,20 @@ func Snapshot(ctx *cli.Context) error {
 	}
 	log := logger.DefaultLogger
 	dest := ctx.String("destination")
-	var sn snapshot.Snapshot
 
 	if len(dest) == 0 {
 		return errors.New("destination flag must be set")
 	}
-	u, err := url.Parse(dest)
-	if err != nil {
-		return errors.Wrap(err, "destination is invalid")
+	opts := []snapshot.SnapshotOption{
+		snapshot.Destination(dest),
+		snapshot.SnapshotTable(s.Options().Database, s.Options().Table),
 	}
-	switch u.Scheme {
-	case "file":
-		sn = snapshot.NewFileSnapshot(snapshot.Destination(dest))
-	default:
-		return errors.Errorf("unsupported destination scheme: %s", u.Scheme)
+	if ctx.Bool("incremental") {
+		opts = append(opts, snapshot.Incremental())
+	}
+	sn, err := snapshot.NewSnapshot(opts...)
+	if err != nil {
+		return err
 	}
 	err = sn.Init()
 	if err != nil {
@@ -38,27 +36,32 @@ func Snapshot(ctx *cli.Context) error {
 	}
 
 	log.Logf(logger.InfoLevel, "Snapshotting store %s", s.String())
-	recordChan, err := sn.Start()
-	if err != nil {
-		return errors.Wrap(err, "couldn't start the snapshotter")
-	}
 	keys, err := s.List()
 	if err != nil {
 		return errors.Wrap(err, "couldn't List() from store "+s.String())
 	}
 	log.Logf(logger.DebugLevel, "Snapshotting %d keys", len(keys))
 
+	recordChan, err := sn.Start()
+	if err != nil {
+		return errors.Wrap(err, "couldn't start the snapshotter")
+	}
+
 	for _, key := range keys {
 		r, err := s.Read(key)
+		if err == nil && len(r) != 1 {
+			err = errors.Errorf("reading %s from %s returned %d records", key, s.String(), len(r))
+		}
 		if err != nil {
+			close(recordChan)
+			sn.Wait()
 			return errors.Wrapf(err, "couldn't read key %s", key)
 		}
-		if len(r) != 1 {
-			return errors.Errorf("reading %s from %s returned 0 records", key, s.String())
-		}
 		recordChan <- r[0]
 	}
 	close(recordChan)
-	sn.Wait()
+	if err := sn.Wait(); err != nil {
+		return errors.Wrap(err, "snapshot failed")
+	}
 	return nil
 }
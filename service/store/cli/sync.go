@@ -1,35 +1,394 @@
 package cli
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/store"
+	signalutil "github.com/micro/go-micro/v2/util/signal"
+	"github.com/micro/micro/v2/service/store/handler"
 	"github.com/pkg/errors"
 )
 
-// Sync is the entrypoint for micro store sync
+// Sync is the entrypoint for micro store sync. It copies the records of one store into another,
+// all of its databases and tables unless one is set. With --continuous it keeps the stores in
+// sync, deleting the records deleted from the store it syncs from, until it's stopped.
+//
+// Sync is one-way, nothing is written to the store synced from. A record written to the other
+// store since it was last synced is a conflict, it's left as it is and reported rather than
+// copied back.
 func Sync(ctx *cli.Context) error {
 	from, to, err := makeStores(ctx)
 	if err != nil {
 		return errors.Wrap(err, "Sync")
 	}
+	s := &syncer{
+		from:   from,
+		to:     to,
+		dryRun: ctx.Bool("dry-run"),
+		synced: make(map[syncTable]map[string]bool),
+		state:  ctx.String("state"),
+	}
+	if len(s.state) == 0 {
+		if s.state, err = defaultSyncState(ctx); err != nil {
+			return errors.Wrap(err, "Sync")
+		}
+	}
+	if err := s.load(); err != nil {
+		return errors.Wrap(err, "Sync")
+	}
+	if s.all = len(ctx.String("from-database")) == 0 && len(ctx.String("from-table")) == 0; s.all {
+		if len(ctx.String("to-database")) > 0 || len(ctx.String("to-table")) > 0 {
+			return errors.New("from-database or from-table must be set to sync to a database or table")
+		}
+	}
+
+	if !ctx.Bool("continuous") {
+		return s.sync()
+	}
+	if s.dryRun {
+		return errors.New("dry-run can't be used with continuous")
+	}
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, signalutil.Shutdown()...)
+	ticker := time.NewTicker(ctx.Duration("interval"))
+	defer ticker.Stop()
+
+	for {
+		// a failed sync is retried on the next tick, the stores are expected to recover
+		if err := s.sync(); err != nil {
+			logger.Errorf("Error syncing %s to %s: %v", from.String(), to.String(), err)
+		}
+		select {
+		case <-shutdown:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncTable is a table being synced and the table it's synced to
+type syncTable struct {
+	fromDatabase, fromTable string
+	toDatabase, toTable     string
+}
+
+func (t syncTable) String() string {
+	if len(t.fromDatabase) == 0 && len(t.fromTable) == 0 {
+		return "default table"
+	}
+	return t.fromDatabase + "/" + t.fromTable
+}
+
+// syncer syncs the tables of the stores. It tracks the keys it synced to tell a record deleted
+// from the store it syncs from apart from one which was only written to the other. They're saved
+// to the state file so records deleted whilst sync isn't running are deleted when it's run again.
+type syncer struct {
+	from, to store.Store
+	all      bool
+	dryRun   bool
+	state    string
+
+	// synced are the keys of each table synced by the last sync, which started at last
+	synced map[syncTable]map[string]bool
+	last   time.Time
+}
+
+// syncState is the keys synced by the last sync, saved in the state file
+type syncState struct {
+	Last   time.Time        `json:"last"`
+	Tables []syncStateTable `json:"tables"`
+}
+
+type syncStateTable struct {
+	FromDatabase string   `json:"from_database"`
+	FromTable    string   `json:"from_table"`
+	ToDatabase   string   `json:"to_database"`
+	ToTable      string   `json:"to_table"`
+	Keys         []string `json:"keys"`
+}
+
+// defaultSyncState returns the state file for the stores synced, in the micro directory of the
+// user config dir named after the flags the stores are set with
+func defaultSyncState(ctx *cli.Context) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find the config dir for the state, set it with --state")
+	}
+	h := sha256.New()
+	for _, f := range []string{"from-backend", "from-nodes", "from-database", "from-table", "to-backend", "to-nodes", "to-database", "to-table"} {
+		fmt.Fprintf(h, "%s=%s\n", f, ctx.String(f))
+	}
+	return filepath.Join(dir, "micro", "sync", hex.EncodeToString(h.Sum(nil)[:8])+".json"), nil
+}
+
+// load the keys synced by the last sync from the state file, if there's been one
+func (s *syncer) load() error {
+	b, err := ioutil.ReadFile(s.state)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "couldn't read the sync state")
+	}
+	var st syncState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return errors.Wrapf(err, "couldn't decode the sync state %s", s.state)
+	}
+	s.last = st.Last
+	for _, t := range st.Tables {
+		keys := make(map[string]bool, len(t.Keys))
+		for _, k := range t.Keys {
+			keys[k] = true
+		}
+		s.synced[syncTable{t.FromDatabase, t.FromTable, t.ToDatabase, t.ToTable}] = keys
+	}
+	return nil
+}
+
+// save the keys synced to the state file
+func (s *syncer) save() error {
+	st := syncState{Last: s.last}
+	for t, synced := range s.synced {
+		keys := make([]string, 0, len(synced))
+		for k := range synced {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		st.Tables = append(st.Tables, syncStateTable{t.fromDatabase, t.fromTable, t.toDatabase, t.toTable, keys})
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.state), 0700); err != nil {
+		return errors.Wrap(err, "couldn't save the sync state")
+	}
+	// the state is replaced atomically so it's not lost if sync is stopped whilst it's saved
+	tmp := s.state + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "couldn't save the sync state")
+	}
+	return errors.Wrap(os.Rename(tmp, s.state), "couldn't save the sync state")
+}
 
-	keys, err := from.List()
+// sync syncs each table once, printing the progress and the changes if it's a dry run
+func (s *syncer) sync() error {
+	start := time.Now()
+	tables, err := s.tables()
 	if err != nil {
-		return errors.Wrapf(err, "couldn't list from store %s", from.String())
+		return err
 	}
+
+	var total syncStats
+	for i, t := range tables {
+		stats, err := s.syncTable(t)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't sync %s", t)
+		}
+		fmt.Printf("[%d/%d] %s: %s\n", i+1, len(tables), t, stats)
+		total.add(stats)
+	}
+	fmt.Printf("Synced %d tables in %v: %s\n", len(tables), time.Since(start).Round(time.Millisecond), total)
+	if total.conflicts > 0 && !s.dryRun {
+		fmt.Printf("The conflicts (!) were written to %s since they were last synced, sync is one-way so they were left as they are\n", s.to.String())
+	}
+	if s.dryRun {
+		return nil
+	}
+	s.last = start
+	return s.save()
+}
+
+// tables returns the tables to sync, the default table and those in the internal table of the
+// store being synced from when none is set
+func (s *syncer) tables() ([]syncTable, error) {
+	o, p := s.from.Options(), s.to.Options()
+	tables := []syncTable{{o.Database, o.Table, p.Database, p.Table}}
+	if !s.all {
+		return tables, nil
+	}
+
+	recs, err := s.from.Read("tables/", store.ReadPrefix(), store.ReadFrom("micro", "internal"))
+	if err != nil && err != store.ErrNotFound {
+		return nil, errors.Wrapf(err, "couldn't list the tables of %s", s.from.String())
+	}
+	// the internal table is synced too, so the other store lists the same databases and tables
+	tables = append(tables, syncTable{"micro", "internal", "micro", "internal"})
+	for _, r := range recs {
+		parts := strings.SplitN(strings.TrimPrefix(r.Key, "tables/"), "/", 2)
+		if len(parts) != 2 || parts[0] == o.Database && parts[1] == o.Table {
+			continue
+		}
+		tables = append(tables, syncTable{parts[0], parts[1], parts[0], parts[1]})
+	}
+	return tables, nil
+}
+
+// syncStats are the changes made by a sync
+type syncStats struct {
+	added, updated, deleted, conflicts int
+}
+
+func (s *syncStats) add(o syncStats) {
+	s.added += o.added
+	s.updated += o.updated
+	s.deleted += o.deleted
+	s.conflicts += o.conflicts
+}
+
+func (s syncStats) String() string {
+	return fmt.Sprintf("%d added, %d updated, %d deleted, %d conflicts", s.added, s.updated, s.deleted, s.conflicts)
+}
+
+// syncTable makes the table of the store being synced to match the table being synced from
+func (s *syncer) syncTable(t syncTable) (syncStats, error) {
+	var stats syncStats
+
+	from, err := readTable(s.from, t.fromDatabase, t.fromTable)
+	if err != nil {
+		return stats, err
+	}
+	to, err := readTable(s.to, t.toDatabase, t.toTable)
+	if err != nil {
+		return stats, err
+	}
+
+	changes := diff(from, to, s.synced[t], s.last)
+	synced := make(map[string]bool, len(from))
+	for k := range from {
+		synced[k] = true
+	}
+
+	for _, c := range changes {
+		switch c.op {
+		case opAdd:
+			stats.added++
+		case opUpdate:
+			stats.updated++
+		case opDelete:
+			stats.deleted++
+		case opConflict:
+			stats.conflicts++
+		}
+		if s.dryRun || c.op == opConflict {
+			fmt.Printf("%c %s %s\n", c.op, t, c.key)
+			continue
+		}
+
+		switch c.op {
+		case opAdd, opUpdate:
+			err = s.to.Write(from[c.key], store.WriteTo(t.toDatabase, t.toTable))
+		case opDelete:
+			err = s.to.Delete(c.key, store.DeleteFrom(t.toDatabase, t.toTable))
+			if err == store.ErrNotFound {
+				err = nil
+			}
+		}
+		if err != nil {
+			return stats, errors.Wrapf(err, "couldn't sync %s to store %s", c.key, s.to.String())
+		}
+	}
+
+	if !s.dryRun {
+		s.synced[t] = synced
+	}
+	return stats, nil
+}
+
+// readTable reads all the records of the table
+func readTable(s store.Store, database, table string) (map[string]*store.Record, error) {
+	keys, err := s.List(store.ListFrom(database, table))
+	if err != nil && err != store.ErrNotFound {
+		return nil, errors.Wrapf(err, "couldn't list from store %s", s.String())
+	}
+	records := make(map[string]*store.Record, len(keys))
 	for _, k := range keys {
-		r, err := from.Read(k)
+		r, err := s.Read(k, store.ReadFrom(database, table))
+		if err == store.ErrNotFound {
+			// deleted or expired since it was listed
+			continue
+		}
 		if err != nil {
-			return errors.Wrapf(err, "couldn't read %s from store %s", k, from.String())
+			return nil, errors.Wrapf(err, "couldn't read %s from store %s", k, s.String())
 		}
 		if len(r) != 1 {
-			return errors.Errorf("received multiple records reading %s from %s", k, from.String())
+			return nil, errors.Errorf("received multiple records reading %s from %s", k, s.String())
 		}
-		err = to.Write(r[0])
-		if err != nil {
-			return errors.Wrapf(err, "couldn't write %s to store %s", k, to.String())
+		records[k] = r[0]
+	}
+	return records, nil
+}
+
+const (
+	opAdd      = '+'
+	opUpdate   = '~'
+	opDelete   = '-'
+	opConflict = '!'
+)
+
+// change is a change needed to sync a key, or a conflict resolved by leaving it as it is
+type change struct {
+	op  byte
+	key string
+}
+
+// diff returns the changes to make the records synced to match the records synced from, sorted
+// by key. Records which differ are resolved by last writer wins on the time they were updated,
+// the records being synced from win if it's the same. Records are only deleted if they were synced
+// by the last sync and haven't been updated since it started.
+func diff(from, to map[string]*store.Record, synced map[string]bool, last time.Time) []change {
+	var changes []change
+	for k, r := range from {
+		old, ok := to[k]
+		switch {
+		case !ok:
+			changes = append(changes, change{opAdd, k})
+		case bytes.Equal(r.Value, old.Value):
+		case updated(old).After(updated(r)):
+			changes = append(changes, change{opConflict, k})
+		default:
+			changes = append(changes, change{opUpdate, k})
 		}
 	}
-	return nil
+	for k, r := range to {
+		if _, ok := from[k]; ok || !synced[k] {
+			continue
+		}
+		if updated(r).After(last) {
+			changes = append(changes, change{opConflict, k})
+		} else {
+			changes = append(changes, change{opDelete, k})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].key < changes[j].key
+	})
+	return changes
+}
+
+// updated returns the time the record was last written, zero if it doesn't have one
+func updated(r *store.Record) time.Time {
+	switch v := r.Metadata[handler.UpdatedMetadata].(type) {
+	case time.Time:
+		return v
+	case string:
+		t, _ := time.Parse(time.RFC3339Nano, v)
+		return t
+	}
+	return time.Time{}
 }
 
 // SyncFlags are the flags for micro store sync
@@ -46,7 +405,7 @@ var SyncFlags = []cli.Flag{
 	},
 	&cli.StringFlag{
 		Name:    "from-database",
-		Usage:   "Database to sync from",
+		Usage:   "Database to sync from, all databases and tables are synced if neither it or the table are set",
 		EnvVars: []string{"MICRO_STORE_FROM_DATABASE"},
 	},
 	&cli.StringFlag{
@@ -74,4 +433,21 @@ var SyncFlags = []cli.Flag{
 		Usage:   "Table to sync to",
 		EnvVars: []string{"MICRO_STORE_TO_TABLE"},
 	},
+	&cli.StringFlag{
+		Name:  "state",
+		Usage: "File the keys synced are saved to, so records deleted whilst sync isn't running are deleted when it's run again. Defaults to a file in the micro/sync dir of the user config dir.",
+	},
+	&cli.BoolFlag{
+		Name:  "continuous",
+		Usage: "Keep syncing the stores until stopped, deleting the records deleted from the store synced from",
+	},
+	&cli.DurationFlag{
+		Name:  "interval",
+		Usage: "Interval between syncs when continuous",
+		Value: 10 * time.Second,
+	},
+	&cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Print the records which would be added (+), updated (~) or deleted (-) and the conflicts (!) without syncing them",
+	},
 }
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/memory"
+	"github.com/micro/micro/v2/service/store/handler"
+)
+
+func TestDiff(t *testing.T) {
+	last := time.Now().Add(-time.Minute)
+	record := func(value string, updated time.Time) *store.Record {
+		r := &store.Record{Value: []byte(value)}
+		if !updated.IsZero() {
+			r.Metadata = map[string]interface{}{handler.UpdatedMetadata: updated.Format(time.RFC3339Nano)}
+		}
+		return r
+	}
+	before, after := last.Add(-time.Second), last.Add(time.Second)
+
+	from := map[string]*store.Record{
+		"added":      record("added", after),
+		"same":       record("same", before),
+		"updated":    record("new", after),
+		"untimed":    record("new", time.Time{}),
+		"overwrote":  record("old", before),
+		"equal time": record("new", before),
+	}
+	to := map[string]*store.Record{
+		"same":       record("same", before),
+		"updated":    record("old", before),
+		"untimed":    record("old", time.Time{}),
+		"overwrote":  record("new", after),
+		"equal time": record("old", before),
+		"deleted":    record("deleted", before),
+		"rewritten":  record("rewritten", after),
+		"unsynced":   record("unsynced", before),
+	}
+	synced := map[string]bool{"same": true, "updated": true, "deleted": true, "rewritten": true}
+
+	expected := []change{
+		{opAdd, "added"},
+		{opDelete, "deleted"},
+		{opUpdate, "equal time"},
+		{opConflict, "overwrote"},
+		{opConflict, "rewritten"},
+		{opUpdate, "untimed"},
+		{opUpdate, "updated"},
+	}
+	if changes := diff(from, to, synced, last); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %v, got %v", expected, changes)
+	}
+
+	// nothing is deleted without knowing what was synced
+	for _, c := range diff(from, to, nil, time.Time{}) {
+		if c.op == opDelete {
+			t.Errorf("Unexpected delete of %v", c.key)
+		}
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	from, to := memory.NewStore(), memory.NewStore()
+	dir, err := ioutil.TempDir("", "sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+	newSyncer := func() *syncer {
+		s := &syncer{from: from, to: to, state: state, synced: make(map[syncTable]map[string]bool)}
+		if err := s.load(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	for _, k := range []string{"foo", "bar"} {
+		if err := from.Write(&store.Record{Key: k, Value: []byte(k)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := newSyncer().sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a record deleted whilst sync wasn't running is deleted by the next sync
+	if err := from.Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := newSyncer().sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := to.Read("foo"); err != store.ErrNotFound {
+		t.Errorf("Expected the deleted record to be deleted, got %v", err)
+	}
+	if _, err := to.Read("bar"); err != nil {
+		t.Errorf("Unexpected error reading the record synced: %v", err)
+	}
+}
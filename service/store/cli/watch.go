@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/micro/cli/v2"
+	"github.com/micro/go-micro/v2/config/cmd"
+	watchpb "github.com/micro/micro/v2/service/store/proto"
+	"github.com/pkg/errors"
+)
+
+// Watch is the entrypoint for micro store watch, it prints the changes to the keys with the prefix
+// until it's stopped
+func Watch(ctx *cli.Context) error {
+	client := *cmd.DefaultOptions().Client
+	stream, err := watchpb.NewStoreService(ctx.String("store"), client).Watch(context.TODO(), &watchpb.WatchRequest{
+		Key:      ctx.Args().First(),
+		Prefix:   true,
+		Database: ctx.String("database"),
+		Table:    ctx.String("table"),
+		Sequence: ctx.Uint64("sequence"),
+		Epoch:    ctx.String("epoch"),
+	})
+	if err != nil {
+		return errors.Wrap(err, "couldn't watch the store")
+	}
+	defer stream.Close()
+
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "watch failed")
+		}
+
+		switch ctx.String("output") {
+		case "json":
+			b, err := json.Marshal(ev)
+			if err != nil {
+				return errors.Wrap(err, "failed marshalling JSON")
+			}
+			fmt.Println(string(b))
+		default:
+			value := string(ev.Value)
+			if !isPrintable(ev.Value) {
+				value = fmt.Sprintf("%#x", ev.Value)
+			}
+			fmt.Printf("%s\t%d\t%s\t%s\t%s\n", ev.Epoch, ev.Sequence, ev.Type, ev.Key, value)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package conditional
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	// the postgres driver cockroach is connected to with
+	_ "github.com/lib/pq"
+	"github.com/micro/go-micro/v2/store"
+)
+
+var (
+	// cockroachDefault is the database and table the cockroach store uses if none are set
+	cockroachDefault = "micro"
+	// cockroachNode is the node the cockroach store connects to if none are set
+	cockroachNode = "postgresql://root@localhost:26257?sslmode=disable"
+	// cockroachName replaces the characters the cockroach store doesn't allow in names
+	cockroachName = regexp.MustCompile("[^a-zA-Z0-9]+")
+
+	// the statements which write and delete records on a condition, an expired record is absent
+	cockroachStatements = map[string]string{
+		"writeAbsent":   "INSERT INTO %s.%s AS r (key, value, metadata, expiry) VALUES ($1, $2::bytea, $3::JSONB, $4) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, metadata = EXCLUDED.metadata, expiry = EXCLUDED.expiry WHERE r.expiry IS NOT NULL AND r.expiry < now();",
+		"writeVersion":  "UPDATE %s.%s SET value = $2::bytea, metadata = $3::JSONB, expiry = $4 WHERE key = $1 AND metadata->>'version' = $5 AND (expiry IS NULL OR expiry > now());",
+		"deleteVersion": "DELETE FROM %s.%s WHERE key = $1 AND metadata->>'version' = $2 AND (expiry IS NULL OR expiry > now());",
+		"exists":        "SELECT count(*) FROM %s.%s WHERE key = $1 AND (expiry IS NULL OR expiry > now());",
+	}
+)
+
+// cockroachStore checks the conditions of writes and deletes in the statements which make them,
+// so they hold across every process using the database. The store it wraps doesn't expose its
+// connection, so it connects to the same nodes and uses the tables the store creates.
+type cockroachStore struct {
+	store.Store
+	db *sql.DB
+
+	sync.Mutex
+	// tables the store has been used with, so they exist
+	tables map[string]bool
+}
+
+// newCockroachStore connects to the nodes of the cockroach store
+func newCockroachStore(s store.Store) (*cockroachStore, error) {
+	source := cockroachNode
+	if nodes := s.Options().Nodes; len(nodes) > 0 {
+		source = nodes[0]
+	}
+	// connection strings which aren't urls are key value pairs, e.g. host=localhost port=26257
+	if _, err := url.Parse(source); err != nil && !strings.Contains(source, " ") {
+		source = fmt.Sprintf("host=%s", source)
+	}
+
+	db, err := sql.Open("postgres", source)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &cockroachStore{Store: s, db: db, tables: make(map[string]bool)}, nil
+}
+
+// table returns the database and table of the records, named as they are by the cockroach store.
+// The table is read from through the store the first time it's used, which creates it if it
+// doesn't exist.
+func (s *cockroachStore) table(database, table string) (string, string, error) {
+	if len(database) == 0 {
+		database = s.Options().Database
+	}
+	if len(database) == 0 {
+		database = cockroachDefault
+	}
+	if len(table) == 0 {
+		table = s.Options().Table
+	}
+	if len(table) == 0 {
+		table = cockroachDefault
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if !s.tables[database+"."+table] {
+		if _, err := s.Store.Read("", store.ReadFrom(database, table)); err != nil && err != store.ErrNotFound {
+			return "", "", err
+		}
+		s.tables[database+"."+table] = true
+	}
+	return cockroachName.ReplaceAllString(database, "_"), cockroachName.ReplaceAllString(table, "_"), nil
+}
+
+// exec runs the statement on the table, returning the number of rows it changed
+func (s *cockroachStore) exec(statement, database, table string, args ...interface{}) (int64, error) {
+	res, err := s.db.Exec(fmt.Sprintf(cockroachStatements[statement], database, table), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Write writes the record with a new version
+func (s *cockroachStore) Write(r *store.Record, opts ...store.WriteOption) error {
+	return s.WriteIf(r, Condition{}, opts...)
+}
+
+func (s *cockroachStore) WriteIf(r *store.Record, c Condition, opts ...store.WriteOption) error {
+	version(r)
+	if !c.Absent && len(c.Version) == 0 {
+		return s.Store.Write(r, opts...)
+	}
+
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	database, table, err := s.table(options.Database, options.Table)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(r.Metadata)
+	if err != nil {
+		return err
+	}
+	var expiry interface{}
+	if r.Expiry != 0 {
+		expiry = time.Now().Add(r.Expiry)
+	}
+
+	var n int64
+	if c.Absent {
+		n, err = s.exec("writeAbsent", database, table, r.Key, r.Value, string(metadata), expiry)
+	} else {
+		n, err = s.exec("writeVersion", database, table, r.Key, r.Value, string(metadata), expiry, c.Version)
+	}
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (s *cockroachStore) DeleteIf(key string, c Condition, opts ...store.DeleteOption) error {
+	if !c.Absent && len(c.Version) == 0 {
+		return s.Store.Delete(key, opts...)
+	}
+
+	var options store.DeleteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	database, table, err := s.table(options.Database, options.Table)
+	if err != nil {
+		return err
+	}
+
+	// deleting a record which must be absent only checks there isn't one, as nothing is deleted
+	if c.Absent {
+		var n int
+		if err := s.db.QueryRow(fmt.Sprintf(cockroachStatements["exists"], database, table), key).Scan(&n); err != nil {
+			return err
+		} else if n > 0 {
+			return ErrConflict
+		}
+		return nil
+	}
+
+	n, err := s.exec("deleteVersion", database, table, key, c.Version)
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (s *cockroachStore) Close() error {
+	s.db.Close()
+	return s.Store.Close()
+}
@@ -0,0 +1,161 @@
+// Package conditional adds versions to the records of a store, so they can be written or deleted
+// only if they haven't changed since they were read
+package conditional
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/store"
+)
+
+// VersionMetadata is the record metadata with the version of the record, which changes each time
+// the record is written
+const VersionMetadata = "version"
+
+var (
+	// ErrConflict is returned when the condition of a write or delete isn't met
+	ErrConflict = errors.New("version conflict")
+	// ErrUnsupported is returned for conditional writes and deletes to a store which is shared
+	// between processes and whose conditions can't be checked
+	ErrUnsupported = errors.New("conditional writes aren't supported by the store")
+)
+
+// Condition of a write or delete, the empty condition is always met
+type Condition struct {
+	// Version the record must have
+	Version string
+	// Absent requires there isn't a record
+	Absent bool
+}
+
+// Store is a store which versions its records and writes and deletes them on a condition.
+// Stores which can check the condition themselves should implement it, others are wrapped.
+type Store interface {
+	store.Store
+	// WriteIf writes the record if the condition is met, setting its new version in its metadata
+	WriteIf(r *store.Record, c Condition, opts ...store.WriteOption) error
+	// DeleteIf deletes the record if the condition is met
+	DeleteIf(key string, c Condition, opts ...store.DeleteOption) error
+}
+
+// localStores are the stores whose records are only written by the process which opened them
+var localStores = map[string]bool{"memory": true, "file": true}
+
+// Wrap returns the store if it implements Store. The conditions of the cockroach store are checked
+// by the database and those of the store service by the service, other stores are wrapped in a
+// Store which checks the conditions under a lock.
+// The lock is only held in this process, so the condition can only be guaranteed for the local
+// stores, conditional writes and deletes to any other return ErrUnsupported.
+func Wrap(s store.Store) Store {
+	if c, ok := s.(Store); ok {
+		return c
+	}
+	if s.String() == "cockroach" {
+		c, err := newCockroachStore(s)
+		if err == nil {
+			return c
+		}
+		logger.Warnf("Error connecting to cockroach, conditional writes are unsupported: %v", err)
+	}
+	if s.String() == "service" {
+		return newServiceStore(s)
+	}
+	return &lockingStore{Store: s, local: localStores[s.String()]}
+}
+
+// locks is the number of locks keys are spread across
+const locks = 64
+
+type lockingStore struct {
+	store.Store
+	locks [locks]sync.Mutex
+	// local is true if the records are only written by this process
+	local bool
+}
+
+// lock locks the key in the table
+func (s *lockingStore) lock(database, table, key string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(database + "/" + table + "/" + key))
+	l := &s.locks[h.Sum32()%locks]
+	l.Lock()
+	return l.Unlock
+}
+
+// Write writes the record with a new version
+func (s *lockingStore) Write(r *store.Record, opts ...store.WriteOption) error {
+	return s.WriteIf(r, Condition{}, opts...)
+}
+
+func (s *lockingStore) WriteIf(r *store.Record, c Condition, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	defer s.lock(options.Database, options.Table, r.Key)()
+
+	if err := s.check(r.Key, c, store.ReadFrom(options.Database, options.Table)); err != nil {
+		return err
+	}
+	version(r)
+	return s.Store.Write(r, opts...)
+}
+
+// Delete deletes the record, waiting for conditional writes of it to complete
+func (s *lockingStore) Delete(key string, opts ...store.DeleteOption) error {
+	return s.DeleteIf(key, Condition{}, opts...)
+}
+
+func (s *lockingStore) DeleteIf(key string, c Condition, opts ...store.DeleteOption) error {
+	var options store.DeleteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	defer s.lock(options.Database, options.Table, key)()
+
+	if err := s.check(key, c, store.ReadFrom(options.Database, options.Table)); err != nil {
+		return err
+	}
+	return s.Store.Delete(key, opts...)
+}
+
+// check returns ErrConflict if the record doesn't meet the condition
+func (s *lockingStore) check(key string, c Condition, opts ...store.ReadOption) error {
+	if !c.Absent && len(c.Version) == 0 {
+		return nil
+	}
+	if !s.local {
+		return ErrUnsupported
+	}
+	recs, err := s.Store.Read(key, opts...)
+	if err == store.ErrNotFound || err == nil && len(recs) == 0 {
+		if c.Absent {
+			return nil
+		}
+		return ErrConflict
+	} else if err != nil {
+		return err
+	}
+	if c.Absent {
+		return ErrConflict
+	}
+	if v, _ := recs[0].Metadata[VersionMetadata].(string); v != c.Version {
+		return ErrConflict
+	}
+	return nil
+}
+
+// version sets a new version in the metadata of the record, copying it so the caller's metadata
+// isn't changed
+func version(r *store.Record) {
+	metadata := make(map[string]interface{}, len(r.Metadata)+1)
+	for k, v := range r.Metadata {
+		metadata[k] = v
+	}
+	metadata[VersionMetadata] = uuid.New().String()
+	r.Metadata = metadata
+}
@@ -0,0 +1,186 @@
+package conditional
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/micro/go-micro/v2/client"
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/metadata"
+	"github.com/micro/go-micro/v2/store"
+	"github.com/micro/go-micro/v2/store/cockroach"
+	"github.com/micro/go-micro/v2/store/memory"
+	pb "github.com/micro/go-micro/v2/store/service/proto"
+)
+
+func TestConditional(t *testing.T) {
+	s := Wrap(memory.NewStore())
+	if Wrap(s) != s {
+		t.Fatalf("Expected a conditional store not to be wrapped again")
+	}
+	testConditional(t, s)
+}
+
+func TestCockroach(t *testing.T) {
+	addr := os.Getenv("MICRO_STORE_ADDRESS")
+	if len(addr) == 0 {
+		t.Skip("No cockroach address in MICRO_STORE_ADDRESS")
+	}
+	s := Wrap(cockroach.NewStore(store.Nodes(addr)))
+	if _, ok := s.(*cockroachStore); !ok {
+		t.Fatalf("Expected the conditions to be checked by cockroach")
+	}
+	defer s.Close()
+
+	// records left by previous runs would conflict
+	for _, key := range []string{"foo", "counter"} {
+		s.Delete(key, store.DeleteFrom("micro", "counters"))
+	}
+	testConditional(t, s)
+}
+
+func testConditional(t *testing.T, s Store) {
+	opts := []store.WriteOption{store.WriteTo("micro", "counters")}
+
+	if err := s.WriteIf(&store.Record{Key: "foo", Value: []byte("1")}, Condition{Version: "1"}, opts...); err != ErrConflict {
+		t.Errorf("Expected a conflict writing a version which doesn't exist, got %v", err)
+	}
+	r := &store.Record{Key: "foo", Value: []byte("1")}
+	if err := s.WriteIf(r, Condition{Absent: true}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	v1, _ := r.Metadata[VersionMetadata].(string)
+	if len(v1) == 0 {
+		t.Fatalf("Expected the record to be given a version")
+	}
+	if err := s.WriteIf(&store.Record{Key: "foo", Value: []byte("2")}, Condition{Absent: true}, opts...); err != ErrConflict {
+		t.Errorf("Expected a conflict writing a record which exists, got %v", err)
+	}
+
+	recs, err := s.Read("foo", store.ReadFrom("micro", "counters"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := recs[0].Metadata[VersionMetadata]; v != v1 {
+		t.Errorf("Expected the version %v to be read, got %v", v1, v)
+	}
+
+	r = &store.Record{Key: "foo", Value: []byte("2")}
+	if err := s.WriteIf(r, Condition{Version: v1}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if r.Metadata[VersionMetadata] == v1 {
+		t.Errorf("Expected the version to change when written")
+	}
+	if err := s.WriteIf(&store.Record{Key: "foo", Value: []byte("3")}, Condition{Version: v1}, opts...); err != ErrConflict {
+		t.Errorf("Expected a conflict writing an old version, got %v", err)
+	}
+	if err := s.DeleteIf("foo", Condition{Version: v1}, store.DeleteFrom("micro", "counters")); err != ErrConflict {
+		t.Errorf("Expected a conflict deleting an old version, got %v", err)
+	}
+	if err := s.DeleteIf("foo", Condition{Version: r.Metadata[VersionMetadata].(string)}, store.DeleteFrom("micro", "counters")); err != nil {
+		t.Errorf("Unexpected error deleting the current version: %v", err)
+	}
+
+	// concurrent increments don't lose updates when they're retried on conflicts
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				cond, value := Condition{Absent: true}, byte(0)
+				recs, err := s.Read("counter", store.ReadFrom("micro", "counters"))
+				if err == nil {
+					cond = Condition{Version: recs[0].Metadata[VersionMetadata].(string)}
+					value = recs[0].Value[0]
+				}
+				err = s.WriteIf(&store.Record{Key: "counter", Value: []byte{value + 1}}, cond, opts...)
+				if err == nil {
+					return
+				} else if err != ErrConflict {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	recs, err = s.Read("counter", store.ReadFrom("micro", "counters"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs[0].Value[0] != 10 {
+		t.Errorf("Expected the counter to be 10, got %v", recs[0].Value[0])
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	s := Wrap(&sharedStore{memory.NewStore()})
+	if err := s.WriteIf(&store.Record{Key: "foo"}, Condition{Absent: true}); err != ErrUnsupported {
+		t.Errorf("Expected conditional writes to a shared store to be unsupported, got %v", err)
+	}
+	if err := s.DeleteIf("foo", Condition{Version: "1"}); err != ErrUnsupported {
+		t.Errorf("Expected conditional deletes from a shared store to be unsupported, got %v", err)
+	}
+	if err := s.Write(&store.Record{Key: "foo"}); err != nil {
+		t.Errorf("Unexpected error writing to a shared store: %v", err)
+	}
+}
+
+// sharedStore is a store which could be written by other processes
+type sharedStore struct {
+	store.Store
+}
+
+func (s *sharedStore) String() string {
+	return "redis"
+}
+
+func TestService(t *testing.T) {
+	c := &storeService{}
+	s := &serviceStore{Store: memory.NewStore(), client: c}
+
+	if err := s.WriteIf(&store.Record{Key: "foo"}, Condition{Absent: true}, store.WriteTo("micro", "counters")); err != nil {
+		t.Fatal(err)
+	}
+	if c.md[IfAbsentHeader] != "true" || c.md["Micro-Database"] != "micro" || c.md["Micro-Table"] != "counters" {
+		t.Errorf("Expected the condition and table to be sent to the store service, got %v", c.md)
+	}
+
+	c.err = errors.Conflict("go.micro.store", "version conflict")
+	if err := s.DeleteIf("foo", Condition{Version: "1"}); err != ErrConflict {
+		t.Errorf("Expected a conflict returned by the store service, got %v", err)
+	}
+	if c.md[IfVersionHeader] != "1" {
+		t.Errorf("Expected the version to be sent to the store service, got %v", c.md)
+	}
+	c.err = errors.New("go.micro.store", "unsupported", http.StatusNotImplemented)
+	if err := s.WriteIf(&store.Record{Key: "foo"}, Condition{Version: "1"}); err != ErrUnsupported {
+		t.Errorf("Expected conditional writes unsupported by the store service, got %v", err)
+	}
+
+	if v := Version(&store.Record{Metadata: map[string]interface{}{VersionMetadata: &pb.Field{Value: "2"}}}); v != "2" {
+		t.Errorf("Expected the version of a record read from the store service, got %v", v)
+	}
+}
+
+// storeService records the metadata of the requests to it
+type storeService struct {
+	pb.StoreService
+	md  metadata.Metadata
+	err error
+}
+
+func (s *storeService) Write(ctx context.Context, req *pb.WriteRequest, opts ...client.CallOption) (*pb.WriteResponse, error) {
+	s.md, _ = metadata.FromContext(ctx)
+	return &pb.WriteResponse{}, s.err
+}
+
+func (s *storeService) Delete(ctx context.Context, req *pb.DeleteRequest, opts ...client.CallOption) (*pb.DeleteResponse, error) {
+	s.md, _ = metadata.FromContext(ctx)
+	return &pb.DeleteResponse{}, s.err
+}
@@ -0,0 +1,128 @@
+package conditional
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/micro/go-micro/v2/client"
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/metadata"
+	"github.com/micro/go-micro/v2/store"
+	pb "github.com/micro/go-micro/v2/store/service/proto"
+)
+
+const (
+	// IfVersionHeader is the request metadata with the version a record must have to be written
+	// or deleted by the store service
+	IfVersionHeader = "Micro-If-Version"
+	// IfAbsentHeader is the request metadata which when true only writes a record to the store
+	// service if there isn't one
+	IfAbsentHeader = "Micro-If-Absent"
+)
+
+// serviceStore sends the conditions of writes and deletes to the store service, which checks
+// them with the store it's run with. The version of a record is set by the store service, so it
+// isn't returned in the metadata of the record written and must be read.
+type serviceStore struct {
+	store.Store
+	client pb.StoreService
+}
+
+// newServiceStore returns a store which sends conditions to the service the store is a client of
+func newServiceStore(s store.Store) *serviceStore {
+	c := s.Options().Client
+	if c == nil {
+		c = client.DefaultClient
+	}
+	return &serviceStore{Store: s, client: pb.NewStoreService("go.micro.store", c)}
+}
+
+// context returns the context of a request to the store service with the condition
+func (s *serviceStore) context(database, table string, c Condition) context.Context {
+	md := metadata.Metadata{}
+	if len(database) > 0 {
+		md["Micro-Database"] = database
+	}
+	if len(table) > 0 {
+		md["Micro-Table"] = table
+	}
+	if len(c.Version) > 0 {
+		md[IfVersionHeader] = c.Version
+	}
+	if c.Absent {
+		md[IfAbsentHeader] = "true"
+	}
+	return metadata.NewContext(context.Background(), md)
+}
+
+func (s *serviceStore) WriteIf(r *store.Record, c Condition, opts ...store.WriteOption) error {
+	if !c.Absent && len(c.Version) == 0 {
+		return s.Store.Write(r, opts...)
+	}
+
+	options := store.WriteOptions{Database: s.Options().Database, Table: s.Options().Table}
+	for _, o := range opts {
+		o(&options)
+	}
+	md := make(map[string]*pb.Field, len(r.Metadata))
+	for k, v := range r.Metadata {
+		md[k] = &pb.Field{Type: reflect.TypeOf(v).String(), Value: fmt.Sprintf("%v", v)}
+	}
+
+	_, err := s.client.Write(s.context(options.Database, options.Table, c), &pb.WriteRequest{
+		Record: &pb.Record{
+			Key:      r.Key,
+			Value:    r.Value,
+			Expiry:   int64(r.Expiry.Seconds()),
+			Metadata: md,
+		},
+		Options: &pb.WriteOptions{Database: options.Database, Table: options.Table},
+	}, client.WithAddress(s.Options().Nodes...))
+	return serviceError(err)
+}
+
+func (s *serviceStore) DeleteIf(key string, c Condition, opts ...store.DeleteOption) error {
+	if !c.Absent && len(c.Version) == 0 {
+		return s.Store.Delete(key, opts...)
+	}
+
+	options := store.DeleteOptions{Database: s.Options().Database, Table: s.Options().Table}
+	for _, o := range opts {
+		o(&options)
+	}
+	_, err := s.client.Delete(s.context(options.Database, options.Table, c), &pb.DeleteRequest{
+		Key:     key,
+		Options: &pb.DeleteOptions{Database: options.Database, Table: options.Table},
+	}, client.WithAddress(s.Options().Nodes...))
+	return serviceError(err)
+}
+
+// serviceError returns the error of the store for an error returned by the store service
+func serviceError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch errors.Parse(err.Error()).Code {
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusNotImplemented:
+		return ErrUnsupported
+	case http.StatusNotFound:
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// Version returns the version of a record read from the store, the store service returns the
+// metadata of records as fields
+func Version(r *store.Record) string {
+	switch v := r.Metadata[VersionMetadata].(type) {
+	case string:
+		return v
+	case *pb.Field:
+		return v.Value
+	}
+	return ""
+}
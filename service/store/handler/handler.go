@@ -4,16 +4,33 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/micro/go-micro/v2/broker"
 	"github.com/micro/go-micro/v2/errors"
 	"github.com/micro/go-micro/v2/metadata"
 	"github.com/micro/go-micro/v2/store"
 	pb "github.com/micro/go-micro/v2/store/service/proto"
 	"github.com/micro/micro/v2/internal/namespace"
+	"github.com/micro/micro/v2/service/store/conditional"
+	watchpb "github.com/micro/micro/v2/service/store/proto"
+)
+
+// UpdatedMetadata is the record metadata with the time the record was last written, in RFC3339
+// format, which micro store sync resolves conflicts with
+const UpdatedMetadata = "updated"
+
+const (
+	// IfVersionHeader is the request metadata with the version a record must have to be written
+	// or deleted, as returned in its metadata by Read
+	IfVersionHeader = conditional.IfVersionHeader
+	// IfAbsentHeader is the request metadata which when true only writes a record if there isn't one
+	IfAbsentHeader = conditional.IfAbsentHeader
 )
 
 type Store struct {
@@ -27,6 +44,17 @@ type Store struct {
 	sync.RWMutex
 
 	Stores map[string]bool
+
+	// Broker the changes made through the handler are published over, so they can be watched
+	// through any instance of the store service
+	Broker broker.Broker
+
+	// watchers of the changes made through the handler
+	watchers watchers
+
+	// the default store with conditional writes
+	conditionalOnce sync.Once
+	conditional     conditional.Store
 }
 
 // TODO: remove this horrible bs
@@ -88,6 +116,35 @@ func (s *Store) get(ctx context.Context, database, table string) (string, string
 	return database, table
 }
 
+// getConditional returns the default store with conditional writes and deletes
+func (s *Store) getConditional() conditional.Store {
+	s.conditionalOnce.Do(func() {
+		s.conditional = conditional.Wrap(s.Default)
+	})
+	return s.conditional
+}
+
+// condition returns the condition of a write or delete from the request metadata
+func condition(ctx context.Context) (conditional.Condition, error) {
+	var c conditional.Condition
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return c, nil
+	}
+	c.Version, _ = md.Get(IfVersionHeader)
+	if v, ok := md.Get(IfAbsentHeader); ok && len(v) > 0 {
+		absent, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, errors.BadRequest("go.micro.store", "%s must be true or false", IfAbsentHeader)
+		}
+		c.Absent = absent
+	}
+	if c.Absent && len(c.Version) > 0 {
+		return c, errors.BadRequest("go.micro.store", "only one of %s and %s can be set", IfVersionHeader, IfAbsentHeader)
+	}
+	return c, nil
+}
+
 func (s *Store) Read(ctx context.Context, req *pb.ReadRequest, rsp *pb.ReadResponse) error {
 	var opts []store.ReadOption
 	var database, table string
@@ -151,11 +208,17 @@ func (s *Store) Write(ctx context.Context, req *pb.WriteRequest, rsp *pb.WriteRe
 	if req.Record == nil {
 		return errors.BadRequest("go.micro.store", "no record specified")
 	}
+	cond, err := condition(ctx)
+	if err != nil {
+		return err
+	}
 
 	metadata := make(map[string]interface{})
 	for k, v := range req.Record.Metadata {
 		metadata[k] = v.Value
 	}
+	// records read and written back have the time they were read, so it's always replaced
+	metadata[UpdatedMetadata] = time.Now().UTC().Format(time.RFC3339Nano)
 
 	record := &store.Record{
 		Key:      req.Record.Key,
@@ -167,16 +230,60 @@ func (s *Store) Write(ctx context.Context, req *pb.WriteRequest, rsp *pb.WriteRe
 	var opts []store.WriteOption
 	opts = append(opts, store.WriteTo(database, table))
 
-	err := s.Default.Write(record, opts...)
-	if err != nil && err == store.ErrNotFound {
+	// watchers are told whether the record is created or updated, which follows from the condition
+	// of a conditional write. Other writes are made conditional if there are watchers.
+	watched := s.watched()
+	typ := watchpb.EventType_UPDATE
+	if watched && !cond.Absent && len(cond.Version) == 0 {
+		typ, err = s.writeChange(record, database, table, opts...)
+	} else {
+		if cond.Absent {
+			typ = watchpb.EventType_CREATE
+		}
+		err = s.getConditional().WriteIf(record, cond, opts...)
+	}
+	if err == conditional.ErrConflict {
+		return errors.Conflict("go.micro.store", err.Error())
+	} else if err == conditional.ErrUnsupported {
+		return errors.New("go.micro.store", err.Error(), http.StatusNotImplemented)
+	} else if err != nil && err == store.ErrNotFound {
 		return errors.NotFound("go.micro.store", err.Error())
 	} else if err != nil {
 		return errors.InternalServerError("go.micro.store", err.Error())
 	}
 
+	if watched {
+		s.publishChange(database, table, typ, record.Key, record)
+	}
 	return nil
 }
 
+// writeChange writes the record on the condition it hasn't changed since it was read, returning
+// whether it was created or updated. Stores which can't check the condition are read and written,
+// so a record written by another client in between may be reported as created.
+func (s *Store) writeChange(record *store.Record, database, table string, opts ...store.WriteOption) (watchpb.EventType, error) {
+	for {
+		typ := watchpb.EventType_UPDATE
+		cond := conditional.Condition{}
+		recs, err := s.Default.Read(record.Key, store.ReadFrom(database, table))
+		if err == store.ErrNotFound || err == nil && len(recs) == 0 {
+			typ = watchpb.EventType_CREATE
+			cond.Absent = true
+		} else if err != nil {
+			return typ, err
+		} else {
+			cond.Version = conditional.Version(recs[0])
+		}
+
+		err = s.getConditional().WriteIf(record, cond, opts...)
+		if err == conditional.ErrUnsupported {
+			return typ, s.getConditional().Write(record, opts...)
+		} else if err != conditional.ErrConflict {
+			return typ, err
+		}
+	}
+}
+
 func (s *Store) Delete(ctx context.Context, req *pb.DeleteRequest, rsp *pb.DeleteResponse) error {
 	var database, table string
 
@@ -195,11 +302,22 @@ func (s *Store) Delete(ctx context.Context, req *pb.DeleteRequest, rsp *pb.Delet
 	var opts []store.DeleteOption
 	opts = append(opts, store.DeleteFrom(database, table))
 
-	if err := s.Default.Delete(req.Key, opts...); err == store.ErrNotFound {
+	cond, err := condition(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.getConditional().DeleteIf(req.Key, cond, opts...); err == conditional.ErrConflict {
+		return errors.Conflict("go.micro.store", err.Error())
+	} else if err == conditional.ErrUnsupported {
+		return errors.New("go.micro.store", err.Error(), http.StatusNotImplemented)
+	} else if err == store.ErrNotFound {
 		return errors.NotFound("go.micro.store", err.Error())
 	} else if err != nil {
 		return errors.InternalServerError("go.micro.store", err.Error())
 	}
+	if s.watched() {
+		s.publishChange(database, table, watchpb.EventType_DELETE, req.Key, nil)
+	}
 	return nil
 }
 
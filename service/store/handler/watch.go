@@ -0,0 +1,408 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/micro/go-micro/v2/broker"
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/micro/go-micro/v2/store"
+	pb "github.com/micro/go-micro/v2/store/service/proto"
+	watchpb "github.com/micro/micro/v2/service/store/proto"
+)
+
+// watchHistory is the least number of events kept to resume watches from, and the number buffered
+// for each watcher before it's stopped for falling behind
+const watchHistory = 1000
+
+const (
+	// watchTopic is the broker topic the changes made by every instance of the store service are
+	// published to, along with the messages announcing an instance has watchers
+	watchTopic = "go.micro.store.watch"
+	// watchChange and watchWatching are the types of message published to the topic
+	watchChange   = "change"
+	watchWatching = "watching"
+)
+
+var (
+	// watchAnnounceInterval is how often an instance with watchers announces it, changes are only
+	// published while an instance has watchers
+	watchAnnounceInterval = time.Second * 30
+	// watchAnnounceTTL is how long changes are published for after an announcement
+	watchAnnounceTTL = watchAnnounceInterval * 2
+)
+
+// Register registers the store handler with the server. Watch isn't in the go-micro store proto,
+// so the handler is registered like pb.RegisterStoreHandler would with Watch added.
+func Register(s server.Server, h *Store, opts ...server.HandlerOption) error {
+	type store interface {
+		Read(ctx context.Context, in *pb.ReadRequest, out *pb.ReadResponse) error
+		Write(ctx context.Context, in *pb.WriteRequest, out *pb.WriteResponse) error
+		Delete(ctx context.Context, in *pb.DeleteRequest, out *pb.DeleteResponse) error
+		List(ctx context.Context, stream server.Stream) error
+		Databases(ctx context.Context, in *pb.DatabasesRequest, out *pb.DatabasesResponse) error
+		Tables(ctx context.Context, in *pb.TablesRequest, out *pb.TablesResponse) error
+		Watch(ctx context.Context, stream server.Stream) error
+	}
+	type Store struct {
+		store
+	}
+	return s.Handle(s.NewHandler(&Store{&storeHandler{h}}, opts...))
+}
+
+// storeHandler adapts the streaming endpoints of the Store handler to the server
+type storeHandler struct {
+	*Store
+}
+
+func (h *storeHandler) List(ctx context.Context, stream server.Stream) error {
+	m := new(pb.ListRequest)
+	if err := stream.Recv(m); err != nil {
+		return err
+	}
+	return h.Store.List(ctx, m, &storeListStream{stream})
+}
+
+func (h *storeHandler) Watch(ctx context.Context, stream server.Stream) error {
+	m := new(watchpb.WatchRequest)
+	if err := stream.Recv(m); err != nil {
+		return err
+	}
+	return h.Store.Watch(ctx, m, &storeWatchStream{stream})
+}
+
+type storeListStream struct {
+	server.Stream
+}
+
+func (x *storeListStream) SendMsg(m interface{}) error {
+	return x.Stream.Send(m)
+}
+
+func (x *storeListStream) RecvMsg(m interface{}) error {
+	return x.Stream.Recv(m)
+}
+
+func (x *storeListStream) Send(m *pb.ListResponse) error {
+	return x.Stream.Send(m)
+}
+
+type storeWatchStream struct {
+	server.Stream
+}
+
+func (x *storeWatchStream) SendMsg(m interface{}) error {
+	return x.Stream.Send(m)
+}
+
+func (x *storeWatchStream) RecvMsg(m interface{}) error {
+	return x.Stream.Recv(m)
+}
+
+func (x *storeWatchStream) Send(m *watchpb.WatchEvent) error {
+	return x.Stream.Send(m)
+}
+
+// change made through an instance of the store service, published over the broker
+type change struct {
+	Database string            `json:"database"`
+	Table    string            `json:"table"`
+	Type     watchpb.EventType `json:"type"`
+	Key      string            `json:"key"`
+	Value    []byte            `json:"value,omitempty"`
+	Expiry   int64             `json:"expiry,omitempty"`
+}
+
+// Subscribe to the changes made through every instance of the store service, they're published
+// over the broker so they can be watched through any instance. Without a broker only the changes
+// made through this instance are watched.
+func (s *Store) Subscribe() error {
+	if s.Broker == nil {
+		return nil
+	}
+
+	// the server only connects the broker when it has subscribers, the store doesn't
+	if err := s.Broker.Connect(); err != nil {
+		return err
+	}
+	_, err := s.Broker.Subscribe(watchTopic, func(p broker.Event) error {
+		switch p.Message().Header["type"] {
+		case watchWatching:
+			s.watchers.announced(time.Now().Add(watchAnnounceTTL))
+		case watchChange:
+			var c *change
+			if err := json.Unmarshal(p.Message().Body, &c); err != nil {
+				logger.Warnf("Error unmarshaling change: %v", err)
+				return err
+			}
+			var r *store.Record
+			if c.Type != watchpb.EventType_DELETE {
+				r = &store.Record{Value: c.Value, Expiry: time.Duration(c.Expiry) * time.Second}
+			}
+			s.watchers.publish(c.Database, c.Table, c.Type, c.Key, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	go s.announce()
+	return nil
+}
+
+// announce periodically tells the other instances of the store service this instance has
+// watchers, so they publish their changes
+func (s *Store) announce() {
+	ticker := time.NewTicker(watchAnnounceInterval)
+
+	for {
+		if s.watchers.count() > 0 {
+			s.publishAnnouncement()
+		}
+		<-ticker.C
+	}
+}
+
+func (s *Store) publishAnnouncement() {
+	msg := &broker.Message{Header: map[string]string{"type": watchWatching}}
+	if err := s.Broker.Publish(watchTopic, msg); err != nil {
+		logger.Warnf("Error announcing the store has watchers: %v", err)
+	}
+}
+
+// watched returns true if a change could have watchers, only then is it read beforehand to tell
+// whether it's created or updated, and published
+func (s *Store) watched() bool {
+	return s.watchers.watched(time.Now())
+}
+
+// publishChange sends the change to the watchers of every instance of the store service, or of this
+// instance if there's no broker or it fails
+func (s *Store) publishChange(database, table string, typ watchpb.EventType, key string, r *store.Record) {
+	if s.Broker != nil {
+		c := &change{Database: database, Table: table, Type: typ, Key: key}
+		if r != nil {
+			c.Value = r.Value
+			c.Expiry = int64(r.Expiry.Seconds())
+		}
+		bytes, err := json.Marshal(c)
+		if err == nil {
+			msg := &broker.Message{Header: map[string]string{"type": watchChange}, Body: bytes}
+			if err = s.Broker.Publish(watchTopic, msg); err == nil {
+				return
+			}
+		}
+		logger.Warnf("Error publishing change to %v: %v", key, err)
+	}
+	s.watchers.publish(database, table, typ, key, r)
+}
+
+// Watch streams the changes made through the store service to the key, or the keys with the
+// prefix. Every instance receives the changes made through the others, but numbers them by its
+// own sequence which restarts with the instance, so the epoch of the instance is sent with each
+// change. Resuming from another epoch, i.e. on another instance, or from a sequence which is no
+// longer kept, is an error so the caller knows to read the keys again.
+func (s *Store) Watch(ctx context.Context, req *watchpb.WatchRequest, stream watchpb.Store_WatchStream) error {
+	database, table := s.get(ctx, req.Database, req.Table)
+	w := &watcher{
+		database: database,
+		table:    table,
+		key:      req.Key,
+		prefix:   req.Prefix,
+		events:   make(chan *event, watchHistory),
+	}
+	backlog, first, err := s.watchers.add(w, req.Epoch, req.Sequence)
+	if err != nil {
+		return errors.BadRequest("go.micro.store", err.Error())
+	}
+	defer s.watchers.remove(w)
+
+	// the other instances only publish their changes once they know there are watchers
+	if first && s.Broker != nil {
+		s.publishAnnouncement()
+	}
+
+	send := func(ev *event) error {
+		err := stream.Send(ev.WatchEvent)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.InternalServerError("go.micro.store", err.Error())
+		}
+		return nil
+	}
+	for _, ev := range backlog {
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.events:
+			if !ok {
+				return errors.Conflict("go.micro.store", "watch fell behind, resume it from the last sequence received")
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// event is a change to a record in a database and table
+type event struct {
+	database, table string
+	*watchpb.WatchEvent
+}
+
+// watcher receives the events for a key or prefix
+type watcher struct {
+	database, table string
+	key             string
+	prefix          bool
+	events          chan *event
+}
+
+func (w *watcher) matches(ev *event) bool {
+	if w.database != ev.database || w.table != ev.table {
+		return false
+	}
+	if w.prefix {
+		return strings.HasPrefix(ev.Key, w.key)
+	}
+	return w.key == ev.Key
+}
+
+// watchers sends the events published by the store to the watchers, keeping the last events so
+// watches can be resumed
+type watchers struct {
+	sync.Mutex
+	// epoch identifies the sequence, it's generated when the first watcher is added or event
+	// published
+	epoch    string
+	sequence uint64
+	history  []*event
+	watchers map[*watcher]bool
+	// until is when the last announcement that another instance has watchers expires
+	until time.Time
+}
+
+// getEpoch returns the epoch, generating it if needed. The lock must be held.
+func (ws *watchers) getEpoch() string {
+	if len(ws.epoch) == 0 {
+		ws.epoch = uuid.New().String()
+	}
+	return ws.epoch
+}
+
+// add adds the watcher, returning the events since the sequence it's resumed from if any, and
+// whether it's the only watcher
+func (ws *watchers) add(w *watcher, epoch string, sequence uint64) ([]*event, bool, error) {
+	ws.Lock()
+	defer ws.Unlock()
+
+	var backlog []*event
+	if sequence > 0 {
+		if epoch != ws.getEpoch() {
+			return nil, false, fmt.Errorf("epoch %v isn't the store's %v, it may have restarted or be another instance", epoch, ws.epoch)
+		}
+		if sequence > ws.sequence {
+			return nil, false, fmt.Errorf("sequence %d is ahead of the store's %d", sequence, ws.sequence)
+		}
+		if len(ws.history) > 0 && sequence+1 < ws.history[0].Sequence {
+			return nil, false, fmt.Errorf("events since sequence %d are no longer kept", sequence)
+		}
+		for _, ev := range ws.history {
+			if ev.Sequence > sequence && w.matches(ev) {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+
+	if ws.watchers == nil {
+		ws.watchers = make(map[*watcher]bool)
+	}
+	ws.watchers[w] = true
+	return backlog, len(ws.watchers) == 1, nil
+}
+
+func (ws *watchers) remove(w *watcher) {
+	ws.Lock()
+	defer ws.Unlock()
+	delete(ws.watchers, w)
+}
+
+// count returns the number of watchers
+func (ws *watchers) count() int {
+	ws.Lock()
+	defer ws.Unlock()
+	return len(ws.watchers)
+}
+
+// announced records that another instance has watchers until the time provided
+func (ws *watchers) announced(until time.Time) {
+	ws.Lock()
+	defer ws.Unlock()
+	if until.After(ws.until) {
+		ws.until = until
+	}
+}
+
+// watched returns true if there are watchers, or another instance announced it had them recently
+func (ws *watchers) watched(now time.Time) bool {
+	ws.Lock()
+	defer ws.Unlock()
+	return len(ws.watchers) > 0 || now.Before(ws.until)
+}
+
+// publish numbers the change and sends it to the watchers, those which have fallen behind are
+// stopped so they don't block the store
+func (ws *watchers) publish(database, table string, typ watchpb.EventType, key string, r *store.Record) {
+	ws.Lock()
+	defer ws.Unlock()
+
+	ws.sequence++
+	ev := &event{
+		database: database,
+		table:    table,
+		WatchEvent: &watchpb.WatchEvent{
+			Epoch:    ws.getEpoch(),
+			Sequence: ws.sequence,
+			Type:     typ,
+			Key:      key,
+		},
+	}
+	if r != nil {
+		ev.Value = r.Value
+		ev.Expiry = int64(r.Expiry.Seconds())
+	}
+
+	// the history is trimmed once it's twice as long as needed so it's not copied for every event
+	ws.history = append(ws.history, ev)
+	if len(ws.history) > 2*watchHistory {
+		ws.history = append(ws.history[:0:0], ws.history[len(ws.history)-watchHistory:]...)
+	}
+
+	for w := range ws.watchers {
+		if !w.matches(ev) {
+			continue
+		}
+		select {
+		case w.events <- ev:
+		default:
+			delete(ws.watchers, w)
+			close(w.events)
+		}
+	}
+}
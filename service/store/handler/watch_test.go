@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/broker"
+	"github.com/micro/go-micro/v2/broker/memory"
+	"github.com/micro/go-micro/v2/store"
+	memStore "github.com/micro/go-micro/v2/store/memory"
+	pb "github.com/micro/go-micro/v2/store/service/proto"
+	watchpb "github.com/micro/micro/v2/service/store/proto"
+)
+
+func TestWatchers(t *testing.T) {
+	var ws watchers
+	prefix := &watcher{database: "micro", table: "users", key: "user/", prefix: true, events: make(chan *event, 10)}
+	key := &watcher{database: "micro", table: "users", key: "user/1", events: make(chan *event, 10)}
+	for _, w := range []*watcher{prefix, key} {
+		if _, _, err := ws.add(w, "", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws.publish("micro", "users", watchpb.EventType_CREATE, "user/1", &store.Record{Value: []byte("1")})
+	ws.publish("micro", "users", watchpb.EventType_CREATE, "user/2", &store.Record{Value: []byte("2")})
+	ws.publish("micro", "other", watchpb.EventType_CREATE, "user/1", &store.Record{Value: []byte("1")})
+	ws.publish("micro", "users", watchpb.EventType_DELETE, "user/1", nil)
+
+	expect := func(w *watcher, sequences ...uint64) {
+		t.Helper()
+		for _, seq := range sequences {
+			select {
+			case ev := <-w.events:
+				if ev.Sequence != seq {
+					t.Errorf("Expected event %v, got %v", seq, ev.Sequence)
+				}
+			default:
+				t.Errorf("Expected event %v", seq)
+			}
+		}
+		if len(w.events) > 0 {
+			t.Errorf("Expected no more events, got %v", len(w.events))
+		}
+	}
+	expect(prefix, 1, 2, 4)
+	expect(key, 1, 4)
+
+	// a watch resumes with the events after the sequence
+	resumed := &watcher{database: "micro", table: "users", key: "user/", prefix: true, events: make(chan *event, 10)}
+	backlog, _, err := ws.add(resumed, ws.epoch, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backlog) != 2 || backlog[0].Sequence != 2 || backlog[1].Sequence != 4 || backlog[1].Type != watchpb.EventType_DELETE {
+		t.Errorf("Expected the events after sequence 1, got %v", backlog)
+	}
+	if _, _, err := ws.add(resumed, ws.epoch, 10); err == nil {
+		t.Errorf("Expected an error resuming from a sequence ahead of the store")
+	}
+	if _, _, err := ws.add(resumed, "other", 1); err == nil {
+		t.Errorf("Expected an error resuming from another epoch")
+	}
+
+	// watchers which fall behind are stopped
+	ws.remove(prefix)
+	ws.remove(resumed)
+	for i := 0; i < 10; i++ {
+		ws.publish("micro", "users", watchpb.EventType_UPDATE, "user/1", &store.Record{})
+	}
+	if _, ok := ws.watchers[key]; !ok {
+		t.Fatalf("Expected the watcher to be kept while its buffer isn't full")
+	}
+	ws.publish("micro", "users", watchpb.EventType_UPDATE, "user/1", &store.Record{})
+	if _, ok := ws.watchers[key]; ok {
+		t.Errorf("Expected the watcher to be removed once it fell behind")
+	}
+	for range key.events {
+	}
+
+	// only the recent history is kept to resume from
+	for i := 0; i < 2*watchHistory; i++ {
+		ws.publish("micro", "users", watchpb.EventType_UPDATE, "user/1", &store.Record{})
+	}
+	if _, _, err := ws.add(resumed, ws.epoch, 1); err == nil {
+		t.Errorf("Expected an error resuming from a sequence no longer kept")
+	}
+	if _, _, err := ws.add(resumed, ws.epoch, ws.sequence-watchHistory); err != nil {
+		t.Errorf("Unexpected error resuming from a recent sequence: %v", err)
+	}
+}
+
+func TestWatchBroker(t *testing.T) {
+	b := memory.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	newStore := func() *Store {
+		s := &Store{
+			Default: memStore.NewStore(),
+			Broker:  b,
+			Stores:  make(map[string]bool),
+			New:     func(string, string) (store.Store, error) { return nil, nil },
+		}
+		if err := s.Subscribe(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	a, w := newStore(), newStore()
+
+	write := func(key string) {
+		t.Helper()
+		req := &pb.WriteRequest{
+			Record:  &pb.Record{Key: key, Value: []byte(key)},
+			Options: &pb.WriteOptions{Database: "micro", Table: "users"},
+		}
+		if err := a.Write(context.TODO(), req, &pb.WriteResponse{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// nothing is published while no instance has watchers
+	var published int
+	if _, err := b.Subscribe(watchTopic, func(p broker.Event) error {
+		if p.Message().Header["type"] == watchChange {
+			published++
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	write("user/1")
+	if published != 0 {
+		t.Errorf("Expected no changes to be published without watchers, got %v", published)
+	}
+
+	// a change made through one instance is sent to the watchers of another
+	watcher := &watcher{database: "micro", table: "users", key: "user/", prefix: true, events: make(chan *event, 10)}
+	if _, first, err := w.watchers.add(watcher, "", 0); err != nil || !first {
+		t.Fatalf("Expected the first watcher to be added, got %v", err)
+	}
+	w.publishAnnouncement()
+	write("user/1")
+	write("user/2")
+
+	for _, typ := range []watchpb.EventType{watchpb.EventType_UPDATE, watchpb.EventType_CREATE} {
+		select {
+		case ev := <-watcher.events:
+			if ev.Type != typ || ev.Epoch != w.watchers.epoch {
+				t.Errorf("Expected a %v event in epoch %v, got %+v", typ, w.watchers.epoch, ev.WatchEvent)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %v event", typ)
+		}
+	}
+}
+
+func TestWatchWriteRace(t *testing.T) {
+	s := &Store{
+		Default: memStore.NewStore(),
+		Stores:  make(map[string]bool),
+		New:     func(string, string) (store.Store, error) { return nil, nil },
+	}
+	watcher := &watcher{database: "micro", table: "users", key: "user/1", events: make(chan *event, 100)}
+	if _, _, err := s.watchers.add(watcher, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// only one of the writes racing to create the record is sent as its creation
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := &pb.WriteRequest{
+				Record:  &pb.Record{Key: "user/1", Value: []byte("user/1")},
+				Options: &pb.WriteOptions{Database: "micro", Table: "users"},
+			}
+			if err := s.Write(context.TODO(), req, &pb.WriteResponse{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var created int
+	for i := 0; i < 20; i++ {
+		if ev := <-watcher.events; ev.Type == watchpb.EventType_CREATE {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected the record to be created once, got %v", created)
+	}
+}
@@ -0,0 +1,198 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// manifestName is the name of the object listing the snapshots of an incremental destination,
+// which are named by the time they were taken
+const manifestName = "manifest.json"
+
+// manifest chains the snapshots of an incremental destination. The first snapshot is a full one,
+// the base, each following snapshot only has the records which changed since the one before it
+// and a tombstone for each record which was deleted.
+type manifest struct {
+	Snapshots []manifestEntry `json:"snapshots"`
+}
+
+type manifestEntry struct {
+	// Name of the snapshot's object
+	Name string `json:"name"`
+	// Time the snapshot was started, it has the state of the store at this time
+	Time time.Time `json:"time"`
+	// Records written and deleted by the snapshot
+	Records int `json:"records"`
+	Deleted int `json:"deleted"`
+}
+
+// snapshotName returns the name of a snapshot taken at t
+func snapshotName(t time.Time) string {
+	return t.UTC().Format("20060102T150405.000000000Z") + ".snapshot"
+}
+
+// objectPath returns the path of a named object next to the path of the destination
+func objectPath(path, name string) string {
+	if len(name) == 0 {
+		return path
+	}
+	return path + "." + name
+}
+
+// notFound returns whether the error is from opening an object which doesn't exist
+func notFound(err error) bool {
+	return os.IsNotExist(errors.Cause(err))
+}
+
+// readManifest reads the manifest of the destination, a destination without one has no snapshots
+func readManifest(open func(name string) (io.ReadCloser, error)) (*manifest, error) {
+	rc, err := open(manifestName)
+	if notFound(err) {
+		return &manifest{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read the manifest")
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrap(err, "manifest is invalid")
+	}
+	return &m, nil
+}
+
+// writeManifest replaces the manifest of the destination, which commits the snapshots it lists
+func writeManifest(create func(name string) (io.WriteCloser, error), m *manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	wc, err := create(manifestName)
+	if err != nil {
+		return err
+	}
+	if _, err := wc.Write(b); err != nil {
+		wc.Close()
+		return errors.Wrap(err, "couldn't write the manifest")
+	}
+	if err := wc.Close(); err != nil {
+		return errors.Wrap(err, "couldn't write the manifest")
+	}
+	return nil
+}
+
+// until returns the snapshots of the chain needed to restore the state at the time, all of them
+// when the time is zero
+func (m *manifest) until(at time.Time) ([]manifestEntry, error) {
+	if len(m.Snapshots) == 0 {
+		return nil, errors.New("manifest has no snapshots")
+	}
+	if at.IsZero() {
+		return m.Snapshots, nil
+	}
+	n := sort.Search(len(m.Snapshots), func(i int) bool {
+		return m.Snapshots[i].Time.After(at)
+	})
+	if n == 0 {
+		return nil, errors.Errorf("no snapshot at or before %s, the first is at %s",
+			at.Format(time.RFC3339), m.Snapshots[0].Time.Format(time.RFC3339))
+	}
+	return m.Snapshots[:n], nil
+}
+
+// replay applies the snapshots in order, returning the records in the store once the last was taken
+func replay(open func(name string) (io.ReadCloser, error), f *format, snapshots []manifestEntry) (map[string]*record, error) {
+	state := make(map[string]*record)
+	for _, s := range snapshots {
+		src, err := open(s.Name)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := f.reader(src)
+		if err != nil {
+			src.Close()
+			return nil, errors.Wrapf(err, "couldn't read snapshot %s", s.Name)
+		}
+		err = decode(rc, func(r *record) error {
+			if r.Deleted {
+				delete(state, r.Key)
+			} else {
+				state[r.Key] = r
+			}
+			return nil
+		})
+		rc.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't read snapshot %s", s.Name)
+		}
+	}
+	return state, nil
+}
+
+// decode calls fn with each record read from the snapshot
+func decode(r io.Reader, fn func(*record) error) error {
+	dec := gob.NewDecoder(r)
+	for {
+		var ir record
+		err := dec.Decode(&ir)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(&ir); err != nil {
+			return err
+		}
+	}
+}
+
+// increment is the state of the store when the last snapshot of an incremental destination was
+// taken, which the records of the next snapshot are compared against
+type increment struct {
+	manifest *manifest
+	entry    manifestEntry
+	state    map[string]*record
+	seen     map[string]bool
+}
+
+// changed returns whether the record has been written since the last snapshot
+func (i *increment) changed(r *record) bool {
+	i.seen[r.Key] = true
+	old, ok := i.state[r.Key]
+	if !ok || !bytes.Equal(old.Value, r.Value) || old.ExpiresAt.IsZero() != r.ExpiresAt.IsZero() {
+		return true
+	}
+	// the expiry is read as a duration, so the time it's at moves a little between snapshots
+	d := old.ExpiresAt.Sub(r.ExpiresAt)
+	return d <= -time.Second || d >= time.Second
+}
+
+// deleted returns tombstones for the records which have been deleted since the last snapshot
+func (i *increment) deleted() []record {
+	var keys []string
+	for k := range i.state {
+		if !i.seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	tombstones := make([]record, len(keys))
+	for n, k := range keys {
+		tombstones[n] = record{Key: k, Deleted: true}
+	}
+	return tombstones
+}
@@ -0,0 +1,159 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/store"
+)
+
+func TestIncremental(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		dest     string
+		manifest string
+	}{
+		{"file", "file://" + dir + "/backup?compress=gzip", filepath.Join(dir, "backup.manifest.json")},
+		{"dir", "dir://" + dir + "/tables?compress=zstd&key_file=" + keyFile, filepath.Join(dir, "tables", "micro", "users.manifest.json")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the first snapshot is the base, the next only have the changes
+			deadline := time.Now().Add(time.Hour)
+			write(t, tt.dest, testData, Incremental())
+			// the store reads the time left until records expire
+			changed := func() []*store.Record {
+				return []*store.Record{
+					{Key: "foo", Value: []byte(`new foo`)},
+					{Key: "baz", Value: []byte(`baz`), Expiry: time.Until(deadline)},
+					{Key: "qux", Value: []byte(`qux`), Expiry: time.Until(deadline)},
+				}
+			}
+			write(t, tt.dest, changed(), Incremental())
+			write(t, tt.dest, changed(), Incremental())
+
+			b, err := ioutil.ReadFile(tt.manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m manifest
+			if err := json.Unmarshal(b, &m); err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Snapshots) != 3 {
+				t.Fatalf("Expected 3 snapshots in the manifest, got %v", len(m.Snapshots))
+			}
+			counts := [][2]int{{3, 0}, {2, 1}, {0, 0}}
+			for i, s := range m.Snapshots {
+				if s.Records != counts[i][0] || s.Deleted != counts[i][1] {
+					t.Errorf("Expected snapshot %v to write %v and delete %v records, got %v and %v",
+						i, counts[i][0], counts[i][1], s.Records, s.Deleted)
+				}
+			}
+
+			// the latest state is restored by default
+			records, err := read(t, tt.dest)
+			if err != nil {
+				t.Fatalf("Unexpected error restoring: %v", err)
+			}
+			expected := map[string]string{"baz": "baz", "foo": "new foo", "qux": "qux"}
+			if len(records) != len(expected) {
+				t.Fatalf("Expected %v records, got %v", len(expected), len(records))
+			}
+			for _, r := range records {
+				if expected[r.Key] != string(r.Value) {
+					t.Errorf("Expected %v to be %v, got %v", r.Key, expected[r.Key], string(r.Value))
+				}
+			}
+
+			// the state at the time of the base is the records it was taken with
+			records, err = read(t, tt.dest, RestoreAt(m.Snapshots[0].Time))
+			if err != nil {
+				t.Fatalf("Unexpected error restoring: %v", err)
+			}
+			checkRecords(t, records)
+
+			if _, err := read(t, tt.dest, RestoreAt(m.Snapshots[0].Time.Add(-time.Second))); err == nil {
+				t.Errorf("Expected an error restoring before the base snapshot")
+			}
+		})
+	}
+
+	// a restore at a time needs a manifest
+	write(t, "file://"+dir+"/full", testData)
+	if _, err := read(t, "file://"+dir+"/full", RestoreAt(time.Now())); err == nil {
+		t.Errorf("Expected an error restoring a full snapshot at a time")
+	}
+
+	s, err := NewSnapshot(Destination("stdout://"), Incremental())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Init(); err == nil {
+		t.Errorf("Expected an error for an incremental snapshot to stdout")
+	}
+}
+
+func TestRestoreAtExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dest := "file://" + dir + "/backup"
+	write(t, dest, []*store.Record{{Key: "foo", Value: []byte(`foo`), Expiry: time.Hour}}, Incremental())
+
+	// the snapshot is moved back in time, the record had an hour left when it was taken
+	path := filepath.Join(dir, "backup.manifest.json")
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	m.Snapshots[0].Time = m.Snapshots[0].Time.Add(-time.Minute * 30)
+	if b, err = json.Marshal(m); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := read(t, dest, RestoreAt(m.Snapshots[0].Time))
+	if err != nil {
+		t.Fatalf("Unexpected error restoring: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %v", len(records))
+	}
+	if d := records[0].Expiry; d < time.Minute*89 || d > time.Minute*91 {
+		t.Errorf("Expected the record to expire after the time it had left at the snapshot, got %v", d)
+	}
+
+	// the latest state expires when the records would have
+	records, err = read(t, dest)
+	if err != nil {
+		t.Fatalf("Unexpected error restoring: %v", err)
+	}
+	if d := records[0].Expiry; d < time.Minute*59 || d > time.Hour {
+		t.Errorf("Expected the record to expire when it would have, got %v", d)
+	}
+}
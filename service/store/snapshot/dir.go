@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// DirSnapshot backs up each table to its own file in a directory per database, e.g.
+// dir:///backups writes the table users of the database micro to /backups/micro/users.snapshot.
+// Incremental snapshots of the table are written next to it, e.g. /backups/micro/users.manifest.json
+type DirSnapshot struct {
+	Options SnapshotOptions
+
+	writer
+}
+
+// NewDirSnapshot returns a DirSnapshot
+func NewDirSnapshot(opts ...SnapshotOption) Snapshot {
+	d := &DirSnapshot{}
+	for _, o := range opts {
+		o(&d.Options)
+	}
+	return d
+}
+
+// Init validates the options
+func (d *DirSnapshot) Init(opts ...SnapshotOption) error {
+	for _, o := range opts {
+		o(&d.Options)
+	}
+	u, err := parseURL(d.Options.Destination, "dir")
+	if err != nil {
+		return errors.Wrap(err, "destination is invalid")
+	}
+	path, err := tablePath(u.Path, d.Options.Database, d.Options.Table)
+	if err != nil {
+		return err
+	}
+	if d.format, err = parseFormat(u.Query()); err != nil {
+		return err
+	}
+	d.incremental = d.Options.Incremental
+	d.create = func(name string) (io.WriteCloser, error) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, errors.Wrapf(err, "couldn't create directory %s", filepath.Dir(path))
+		}
+		return createFile(tableObject(path, name))
+	}
+	d.open = func(name string) (io.ReadCloser, error) {
+		return openFile(tableObject(path, name))
+	}
+	return nil
+}
+
+// DirRestore reads the records of a table from the directory written by a DirSnapshot
+type DirRestore struct {
+	Options RestoreOptions
+
+	reader
+}
+
+// NewDirRestore returns a DirRestore
+func NewDirRestore(opts ...RestoreOption) Restore {
+	d := &DirRestore{}
+	for _, o := range opts {
+		o(&d.Options)
+	}
+	return d
+}
+
+// Init validates the options
+func (d *DirRestore) Init(opts ...RestoreOption) error {
+	for _, o := range opts {
+		o(&d.Options)
+	}
+	u, err := parseURL(d.Options.Source, "dir")
+	if err != nil {
+		return errors.Wrap(err, "source is invalid")
+	}
+	path, err := tablePath(u.Path, d.Options.Database, d.Options.Table)
+	if err != nil {
+		return err
+	}
+	if d.format, err = parseFormat(u.Query()); err != nil {
+		return err
+	}
+	d.at = d.Options.At
+	d.open = func(name string) (io.ReadCloser, error) {
+		return openFile(tableObject(path, name))
+	}
+	return nil
+}
+
+// tablePath returns the path of the table's objects in the directory, without an extension
+func tablePath(dir, database, table string) (string, error) {
+	if len(dir) == 0 {
+		return "", errors.New("directory must be set")
+	}
+	if len(database) == 0 || len(table) == 0 {
+		return "", errors.New("database and table must be set to use a directory")
+	}
+	if database == "." || database == ".." {
+		return "", errors.Errorf("invalid database %s", database)
+	}
+	return filepath.Join(dir, url.PathEscape(database), url.PathEscape(table)), nil
+}
+
+// tableObject returns the path of the table's object with the name, its snapshot if it's empty
+func tableObject(path, name string) string {
+	if len(name) == 0 {
+		name = "snapshot"
+	}
+	return objectPath(path, name)
+}
@@ -0,0 +1,419 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Gzip compresses snapshots with gzip, set with compress=gzip
+	Gzip = "gzip"
+	// Zstd compresses snapshots with zstandard, set with compress=zstd
+	Zstd = "zstd"
+
+	// keySize is the size of the AES-256 key snapshots are encrypted with
+	keySize = 32
+	// chunkSize is the size of the chunks encrypted snapshots are sealed in, each chunk is
+	// authenticated so a snapshot is decrypted as it's read rather than all at once
+	chunkSize = 64 * 1024
+	// finalChunk is set in the length of the last chunk so a truncated snapshot is detected
+	finalChunk = 1 << 31
+)
+
+// magic starts the header of snapshots, those without it are plain gob written before snapshots
+// could be compressed or encrypted
+var magic = []byte("MSNP")
+
+// version of the header
+const version = 1
+
+// the compression and encryption of a snapshot as written in its header
+const (
+	compressNone byte = iota
+	compressGzip
+	compressZstd
+)
+
+const (
+	encryptNone byte = iota
+	encryptPassphrase
+	encryptKey
+)
+
+// format is how the records of a snapshot are compressed and encrypted, set by the parameters of
+// the destination or source URL: compress=gzip|zstd and passphrase=... or key_file=/path/to/key
+type format struct {
+	compression string
+	passphrase  string
+	key         []byte
+}
+
+// formatParams are the URL parameters read by parseFormat
+var formatParams = []string{"compress", "passphrase", "key_file"}
+
+// parseFormat reads the format from the URL parameters
+func parseFormat(q url.Values) (*format, error) {
+	f := &format{compression: q.Get("compress"), passphrase: q.Get("passphrase")}
+	switch f.compression {
+	case "", "none", Gzip, Zstd:
+	default:
+		return nil, errors.Errorf("unsupported compression %s (wanted %s or %s)", f.compression, Gzip, Zstd)
+	}
+
+	path := q.Get("key_file")
+	if len(path) > 0 && len(f.passphrase) > 0 {
+		return nil, errors.New("only one of passphrase and key_file can be set")
+	}
+	if len(path) > 0 {
+		key, err := readKey(path)
+		if err != nil {
+			return nil, err
+		}
+		f.key = key
+	}
+	return f, nil
+}
+
+// readKey reads a 32 byte key from the file, either raw or base64 encoded
+func readKey(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't read key file %s", path)
+	}
+	if key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b))); err == nil && len(key) == keySize {
+		return key, nil
+	}
+	if len(b) == keySize {
+		return b, nil
+	}
+	return nil, errors.Errorf("key file %s must contain a %d byte key, raw or base64 encoded", path, keySize)
+}
+
+// checkParams returns an error if the URL has a parameter which isn't allowed
+func checkParams(q url.Values, allowed ...string) error {
+	for k := range q {
+		ok := false
+		for _, a := range allowed {
+			ok = ok || k == a
+		}
+		if !ok {
+			return errors.Errorf("unknown parameter %s", k)
+		}
+	}
+	return nil
+}
+
+// writer wraps the destination with the compression and encryption of the format, writing the
+// header first. Closing the writer flushes it and closes the destination.
+func (f *format) writer(dst io.WriteCloser) (io.WriteCloser, error) {
+	header := append([]byte{}, magic...)
+	header = append(header, version, compressNone, encryptNone)
+	switch f.compression {
+	case Gzip:
+		header[len(magic)+1] = compressGzip
+	case Zstd:
+		header[len(magic)+1] = compressZstd
+	}
+
+	c := &chain{Writer: dst, closers: []io.Closer{dst}}
+
+	if len(f.passphrase) > 0 || len(f.key) > 0 {
+		key := f.key
+		header[len(magic)+2] = encryptKey
+		if len(f.passphrase) > 0 {
+			salt := make([]byte, 16)
+			if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+				return nil, err
+			}
+			var err error
+			if key, err = deriveKey(f.passphrase, salt); err != nil {
+				return nil, err
+			}
+			header[len(magic)+2] = encryptPassphrase
+			header = append(header, salt...)
+		}
+
+		prefix := make([]byte, 4)
+		if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+			return nil, err
+		}
+		header = append(header, prefix...)
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := dst.Write(header); err != nil {
+			return nil, err
+		}
+		s := &sealWriter{w: dst, aead: aead, prefix: prefix, header: header}
+		c.Writer = s
+		c.closers = append([]io.Closer{s}, c.closers...)
+	} else if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+
+	switch f.compression {
+	case Gzip:
+		gz := gzip.NewWriter(c.Writer)
+		c.Writer = gz
+		c.closers = append([]io.Closer{gz}, c.closers...)
+	case Zstd:
+		zw, err := zstd.NewWriter(c.Writer)
+		if err != nil {
+			return nil, err
+		}
+		c.Writer = zw
+		c.closers = append([]io.Closer{zw}, c.closers...)
+	}
+
+	return c, nil
+}
+
+// reader reads the header from the source and returns a reader of the records, decrypting and
+// decompressing them as the header says. Closing the reader closes the source.
+func (f *format) reader(src io.ReadCloser) (io.ReadCloser, error) {
+	buf := bufio.NewReader(src)
+	c := &readChain{Reader: buf, closers: []io.Closer{src}}
+
+	if b, err := buf.Peek(len(magic)); err != nil || !bytes.Equal(b, magic) {
+		// snapshots without a header are plain gob
+		return c, nil
+	}
+
+	header := make([]byte, len(magic)+3)
+	if _, err := io.ReadFull(buf, header); err != nil {
+		return nil, errors.Wrap(err, "couldn't read the snapshot header")
+	}
+	if v := header[len(magic)]; v != version {
+		return nil, errors.Errorf("unsupported snapshot version %d", v)
+	}
+	compression, encryption := header[len(magic)+1], header[len(magic)+2]
+
+	switch encryption {
+	case encryptNone:
+	case encryptPassphrase, encryptKey:
+		key := f.key
+		if encryption == encryptPassphrase {
+			if len(f.passphrase) == 0 {
+				return nil, errors.New("snapshot is encrypted with a passphrase, set passphrase in the source")
+			}
+			salt := make([]byte, 16)
+			if _, err := io.ReadFull(buf, salt); err != nil {
+				return nil, errors.Wrap(err, "couldn't read the snapshot header")
+			}
+			header = append(header, salt...)
+			var err error
+			if key, err = deriveKey(f.passphrase, salt); err != nil {
+				return nil, err
+			}
+		} else if len(key) == 0 {
+			return nil, errors.New("snapshot is encrypted with a key, set key_file in the source")
+		}
+
+		prefix := make([]byte, 4)
+		if _, err := io.ReadFull(buf, prefix); err != nil {
+			return nil, errors.Wrap(err, "couldn't read the snapshot header")
+		}
+		header = append(header, prefix...)
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		c.Reader = &openReader{r: buf, aead: aead, prefix: prefix, header: header}
+	default:
+		return nil, errors.Errorf("unsupported snapshot encryption %d", encryption)
+	}
+
+	switch compression {
+	case compressNone:
+	case compressGzip:
+		gz, err := gzip.NewReader(c.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't decompress the snapshot")
+		}
+		c.Reader = gz
+		c.closers = append([]io.Closer{gz}, c.closers...)
+	case compressZstd:
+		zr, err := zstd.NewReader(c.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't decompress the snapshot")
+		}
+		rc := zr.IOReadCloser()
+		c.Reader = rc
+		c.closers = append([]io.Closer{rc}, c.closers...)
+	default:
+		return nil, errors.Errorf("unsupported snapshot compression %d", compression)
+	}
+
+	return c, nil
+}
+
+// deriveKey derives the key a snapshot is encrypted with from the passphrase
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce of the chunk, the random prefix of the snapshot followed by the index of the chunk
+func nonce(prefix []byte, counter uint64) []byte {
+	n := make([]byte, 12)
+	copy(n, prefix)
+	binary.BigEndian.PutUint64(n[4:], counter)
+	return n
+}
+
+// additionalData authenticates the header with each chunk, along with whether it's the last
+func additionalData(header []byte, final bool) []byte {
+	ad := append([]byte{}, header...)
+	if final {
+		return append(ad, 1)
+	}
+	return append(ad, 0)
+}
+
+// sealWriter encrypts what's written to it in chunks, each prefixed with its length
+type sealWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	header  []byte
+	buf     []byte
+	counter uint64
+}
+
+func (s *sealWriter) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	for len(s.buf) >= chunkSize {
+		if err := s.seal(s.buf[:chunkSize], false); err != nil {
+			return 0, err
+		}
+		s.buf = append(s.buf[:0], s.buf[chunkSize:]...)
+	}
+	return len(p), nil
+}
+
+// Close writes the final chunk
+func (s *sealWriter) Close() error {
+	return s.seal(s.buf, true)
+}
+
+func (s *sealWriter) seal(p []byte, final bool) error {
+	ct := s.aead.Seal(nil, nonce(s.prefix, s.counter), p, additionalData(s.header, final))
+	s.counter++
+
+	length := uint32(len(ct))
+	if final {
+		length |= finalChunk
+	}
+	b := make([]byte, 4, 4+len(ct))
+	binary.BigEndian.PutUint32(b, length)
+	_, err := s.w.Write(append(b, ct...))
+	return err
+}
+
+// openReader decrypts the chunks written by a sealWriter
+type openReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	header  []byte
+	buf     []byte
+	counter uint64
+	final   bool
+}
+
+func (o *openReader) Read(p []byte) (int, error) {
+	for len(o.buf) == 0 {
+		if o.final {
+			return 0, io.EOF
+		}
+		if err := o.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, o.buf)
+	o.buf = o.buf[n:]
+	return n, nil
+}
+
+func (o *openReader) open() error {
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(o.r, b); err == io.EOF {
+		return errors.New("snapshot is truncated")
+	} else if err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint32(b)
+	final := length&finalChunk != 0
+	length &^= finalChunk
+	if length > chunkSize+uint32(o.aead.Overhead()) {
+		return errors.New("snapshot is corrupt")
+	}
+
+	ct := make([]byte, length)
+	if _, err := io.ReadFull(o.r, ct); err != nil {
+		return errors.New("snapshot is truncated")
+	}
+	pt, err := o.aead.Open(nil, nonce(o.prefix, o.counter), ct, additionalData(o.header, final))
+	if err != nil {
+		return errors.New("couldn't decrypt the snapshot, the passphrase or key is wrong or the snapshot is corrupt")
+	}
+	o.counter++
+	o.buf, o.final = pt, final
+	return nil
+}
+
+// chain is a writer which closes each of its closers in order
+type chain struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (c *chain) Close() error {
+	var err error
+	for _, cl := range c.closers {
+		if e := cl.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// readChain is a reader which closes each of its closers in order
+type readChain struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (c *readChain) Close() error {
+	var err error
+	for _, cl := range c.closers {
+		if e := cl.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
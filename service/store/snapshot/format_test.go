@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"testing"
+)
+
+type closeBuffer struct {
+	bytes.Buffer
+}
+
+func (closeBuffer) Close() error {
+	return nil
+}
+
+func TestFormatChunks(t *testing.T) {
+	// enough data for a few chunks, with the last one partly filled
+	data := make([]byte, chunkSize*3+100)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &format{compression: Zstd, key: []byte("0123456789abcdef0123456789abcdef")}
+	buf := &closeBuffer{}
+	w, err := f.writer(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// written in odd sizes so the chunks don't line up with the writes
+	for i := 0; i < len(data); i += 1000 {
+		end := i + 1000
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := w.Write(data[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), data[:64]) {
+		t.Fatalf("Expected the snapshot to be encrypted")
+	}
+
+	r, err := f.reader(ioutil.NopCloser(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Expected the data to be read back, got %v of %v bytes", len(got), len(data))
+	}
+
+	// the header is authenticated with each chunk
+	tampered := append([]byte{}, buf.Bytes()...)
+	tampered[len(magic)+1] = compressGzip
+	r, err = f.reader(ioutil.NopCloser(bytes.NewReader(tampered)))
+	if err == nil {
+		_, err = ioutil.ReadAll(r)
+	}
+	if err == nil {
+		t.Fatalf("Expected an error reading a tampered snapshot")
+	}
+}
@@ -1,10 +1,11 @@
 package snapshot
 
 import (
-	"encoding/gob"
 	"io"
 	"net/url"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/micro/go-micro/v2/store"
@@ -19,11 +20,19 @@ type Restore interface {
 	// Start opens a channel over which records from the snapshot are retrieved.
 	// The channel will be closed when the entire snapshot has been read.
 	Start() (<-chan *store.Record, error)
+	// Wait waits for the snapshot to be read, returning the error which stopped it early if any.
+	// It should be called once the channel is closed.
+	Wait() error
 }
 
 // RestoreOptions configure a Restore
 type RestoreOptions struct {
 	Source string
+	// Database and Table being restored, used by sources which read a file per table
+	Database string
+	Table    string
+	// At is the time to restore the state of an incremental snapshot at, the latest if zero
+	At time.Time
 }
 
 // RestoreOption is an individual option
@@ -36,10 +45,54 @@ func Source(source string) RestoreOption {
 	}
 }
 
+// RestoreTable sets the database and table being restored
+func RestoreTable(database, table string) RestoreOption {
+	return func(r *RestoreOptions) {
+		r.Database = database
+		r.Table = table
+	}
+}
+
+// RestoreAt restores the state of the store at the time from the snapshots chained by the
+// manifest of an incremental source. The records expire after the time they had left then.
+func RestoreAt(t time.Time) RestoreOption {
+	return func(r *RestoreOptions) {
+		r.At = t
+	}
+}
+
+// NewRestore returns the restore for the scheme of the source URL, the schemes of NewSnapshot are
+// supported except stdout which is read from stdin://. The parameters the snapshot was encrypted
+// with must be set, its compression is read from the snapshot. Sources with the manifest of
+// incremental snapshots are restored from the chain of snapshots.
+func NewRestore(opts ...RestoreOption) (Restore, error) {
+	var options RestoreOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	u, err := url.Parse(options.Source)
+	if err != nil {
+		return nil, errors.Wrap(err, "source is invalid")
+	}
+	switch u.Scheme {
+	case "file":
+		return NewFileRestore(opts...), nil
+	case "dir":
+		return NewDirRestore(opts...), nil
+	case "s3":
+		return NewS3Restore(opts...), nil
+	case "stdin":
+		return NewStdinRestore(opts...), nil
+	default:
+		return nil, errors.Errorf("unsupported source scheme: %s", u.Scheme)
+	}
+}
+
 // FileRestore reads records from a file
 type FileRestore struct {
 	Options RestoreOptions
 
+	reader
 	path string
 }
 
@@ -55,47 +108,148 @@ func (f *FileRestore) Init(opts ...RestoreOption) error {
 	for _, o := range opts {
 		o(&f.Options)
 	}
-	u, err := url.Parse(f.Options.Source)
+	u, err := parseURL(f.Options.Source, "file")
 	if err != nil {
 		return errors.Wrap(err, "source is invalid")
 	}
-	if u.Scheme != "file" {
-		return errors.Errorf("unsupported scheme %s (wanted file)", u.Scheme)
+	if f.format, err = parseFormat(u.Query()); err != nil {
+		return err
 	}
 	f.path = u.Path
+	f.at = f.Options.At
+	f.open = func(name string) (io.ReadCloser, error) {
+		return openFile(objectPath(f.path, name))
+	}
 	return nil
 }
 
-// Start starts reading records from a file. The returned channel is closed when complete
-func (f *FileRestore) Start() (<-chan *store.Record, error) {
-	fi, err := os.Open(f.path)
+func openFile(path string) (io.ReadCloser, error) {
+	fi, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Couldn't open file %s", path)
+	}
+	return fi, nil
+}
+
+// reader decodes the records from the reader opened by the source, in the format written by the
+// snapshot. It implements Start and Wait for each source. Sources open the object with the name
+// next to the source, the empty name being the source itself.
+type reader struct {
+	open   func(name string) (io.ReadCloser, error)
+	format *format
+	at     time.Time
+
+	err error
+	wg  sync.WaitGroup
+}
+
+// Start starts reading records from the source. The returned channel is closed when complete
+func (r *reader) Start() (<-chan *store.Record, error) {
+	if r.open == nil {
+		return nil, errors.New("Restore must be initialised before it's started")
+	}
+	m, err := readManifest(r.open)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Snapshots) > 0 {
+		return r.startChain(m)
+	}
+	if !r.at.IsZero() {
+		return nil, errors.New("source has no manifest, only incremental snapshots can be restored at a time")
+	}
+
+	src, err := r.open("")
+	if err != nil {
+		return nil, err
+	}
+	rc, err := r.format.reader(src)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Couldn't open file %s", f.path)
+		src.Close()
+		return nil, err
 	}
+
+	r.err = nil
 	recordChan := make(chan *store.Record)
-	go func(records chan<- *store.Record, reader io.ReadCloser) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
 		defer close(recordChan)
-		defer reader.Close()
-		dec := gob.NewDecoder(fi)
-		var r record
-		for {
-			err := dec.Decode(&r)
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				panic(err)
-			}
-			rec := &store.Record{
-				Key: r.Key,
+		defer rc.Close()
+
+		err := decode(rc, func(ir *record) error {
+			if rec := ir.storeRecord(time.Now()); rec != nil {
+				recordChan <- rec
 			}
-			rec.Value = make([]byte, len(r.Value))
-			copy(rec.Value, r.Value)
-			if !r.ExpiresAt.IsZero() {
-				rec.Expiry = time.Until(r.ExpiresAt)
+			return nil
+		})
+		if err != nil {
+			r.err = errors.Wrap(err, "couldn't read the snapshot")
+		}
+	}()
+	return recordChan, nil
+}
+
+// startChain restores the state at the time from the chain of incremental snapshots. The records
+// restored at a time expire after the time they had left when the snapshot was taken, rather than
+// when they would have expired had they been kept.
+func (r *reader) startChain(m *manifest) (<-chan *store.Record, error) {
+	snapshots, err := m.until(r.at)
+	if err != nil {
+		return nil, err
+	}
+	expiresFrom := time.Now()
+	if !r.at.IsZero() {
+		expiresFrom = snapshots[len(snapshots)-1].Time
+	}
+
+	r.err = nil
+	recordChan := make(chan *store.Record)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer close(recordChan)
+
+		state, err := replay(r.open, r.format, snapshots)
+		if err != nil {
+			r.err = err
+			return
+		}
+		keys := make([]string, 0, len(state))
+		for k := range state {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if rec := state[k].storeRecord(expiresFrom); rec != nil {
+				recordChan <- rec
 			}
-			recordChan <- rec
 		}
-	}(recordChan, fi)
+	}()
 	return recordChan, nil
 }
+
+// Wait waits for the records to be read
+func (r *reader) Wait() error {
+	r.wg.Wait()
+	return r.err
+}
+
+// storeRecord returns the store.Record to restore, nil for tombstones and records which had expired
+// by the time. The record expires after the time it had left then.
+func (ir *record) storeRecord(from time.Time) *store.Record {
+	if ir.Deleted {
+		return nil
+	}
+	rec := &store.Record{
+		Key:   ir.Key,
+		Value: ir.Value,
+	}
+	if !ir.ExpiresAt.IsZero() {
+		rec.Expiry = ir.ExpiresAt.Sub(from)
+		if rec.Expiry <= 0 {
+			return nil
+		}
+	}
+	return rec
+}
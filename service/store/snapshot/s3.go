@@ -0,0 +1,173 @@
+package snapshot
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+)
+
+// s3Params are the URL parameters of S3 destinations and sources. The endpoint is set for S3
+// compatible storage such as minio, the credentials are read from the environment.
+var s3Params = []string{"endpoint", "region"}
+
+// S3Snapshot uploads the snapshot to S3 compatible object storage, e.g.
+// s3://bucket/path/to/key?endpoint=http://localhost:9000. Incremental snapshots are written next
+// to the key, e.g. path/to/key.manifest.json
+type S3Snapshot struct {
+	Options SnapshotOptions
+
+	writer
+}
+
+// NewS3Snapshot returns an S3Snapshot
+func NewS3Snapshot(opts ...SnapshotOption) Snapshot {
+	s := &S3Snapshot{}
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	return s
+}
+
+// Init validates the options
+func (s *S3Snapshot) Init(opts ...SnapshotOption) error {
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	u, err := parseURL(s.Options.Destination, "s3", s3Params...)
+	if err != nil {
+		return errors.Wrap(err, "destination is invalid")
+	}
+	sess, bucket, key, err := s3Object(u)
+	if err != nil {
+		return errors.Wrap(err, "destination is invalid")
+	}
+	if s.format, err = parseFormat(u.Query()); err != nil {
+		return err
+	}
+	s.incremental = s.Options.Incremental
+	s.create = func(name string) (io.WriteCloser, error) {
+		object := objectPath(key, name)
+		pr, pw := io.Pipe()
+		w := &s3Writer{PipeWriter: pw, done: make(chan error, 1)}
+		go func() {
+			_, err := s3manager.NewUploader(sess).Upload(&s3manager.UploadInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(object),
+				Body:   pr,
+			})
+			// unblock the writer if the upload failed before reading everything
+			pr.CloseWithError(err)
+			if err != nil {
+				err = errors.Wrapf(err, "couldn't upload to s3://%s/%s", bucket, object)
+			}
+			w.done <- err
+		}()
+		return w, nil
+	}
+	s.open = func(name string) (io.ReadCloser, error) {
+		return getObject(sess, bucket, objectPath(key, name))
+	}
+	return nil
+}
+
+// s3Writer writes to the upload, closing it waits for the upload to complete
+type s3Writer struct {
+	*io.PipeWriter
+	done chan error
+}
+
+func (w *s3Writer) Close() error {
+	w.PipeWriter.Close()
+	return <-w.done
+}
+
+// S3Restore downloads a snapshot from S3 compatible object storage
+type S3Restore struct {
+	Options RestoreOptions
+
+	reader
+}
+
+// NewS3Restore returns an S3Restore
+func NewS3Restore(opts ...RestoreOption) Restore {
+	s := &S3Restore{}
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	return s
+}
+
+// Init validates the options
+func (s *S3Restore) Init(opts ...RestoreOption) error {
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	u, err := parseURL(s.Options.Source, "s3", s3Params...)
+	if err != nil {
+		return errors.Wrap(err, "source is invalid")
+	}
+	sess, bucket, key, err := s3Object(u)
+	if err != nil {
+		return errors.Wrap(err, "source is invalid")
+	}
+	if s.format, err = parseFormat(u.Query()); err != nil {
+		return err
+	}
+	s.at = s.Options.At
+	s.open = func(name string) (io.ReadCloser, error) {
+		return getObject(sess, bucket, objectPath(key, name))
+	}
+	return nil
+}
+
+// getObject downloads the object, returning an error satisfying os.IsNotExist if there isn't one
+func getObject(sess *session.Session, bucket, key string) (io.ReadCloser, error) {
+	out, err := s3.New(sess).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't download s3://%s/%s", bucket, key)
+	}
+	return out.Body, nil
+}
+
+// s3Object returns the session and the bucket and key of the object in the URL
+func s3Object(u *url.URL) (*session.Session, string, string, error) {
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if len(bucket) == 0 || len(key) == 0 {
+		return nil, "", "", errors.New("bucket and key must be set, e.g. s3://bucket/key")
+	}
+
+	q := u.Query()
+	region := q.Get("region")
+	if len(region) == 0 {
+		region = os.Getenv("AWS_REGION")
+	}
+	if len(region) == 0 {
+		region = "us-east-1"
+	}
+	cfg := &aws.Config{Region: aws.String(region)}
+	if endpoint := q.Get("endpoint"); len(endpoint) > 0 {
+		// S3 compatible storage generally doesn't support buckets as subdomains
+		cfg.Endpoint = aws.String(endpoint)
+		cfg.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return sess, bucket, key, nil
+}
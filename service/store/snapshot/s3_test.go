@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// objectStore is a stand-in for S3 which stores the objects put to it in memory
+type objectStore struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func (o *objectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.Lock()
+	defer o.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		o.objects[r.URL.Path] = b
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet:
+		b, ok := o.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+			return
+		}
+		w.Write(b)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Snapshot(t *testing.T) {
+	for _, k := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Setenv(k, "test")
+	}
+
+	objects := &objectStore{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(objects)
+	defer srv.Close()
+
+	dest := "s3://backups/micro/users?compress=gzip&passphrase=secret&endpoint=" + srv.URL
+	write(t, dest, testData)
+	if _, ok := objects.objects["/backups/micro/users"]; !ok {
+		t.Fatalf("Expected the snapshot to be uploaded to the bucket, got %v objects", len(objects.objects))
+	}
+
+	records, err := read(t, "s3://backups/micro/users?passphrase=secret&endpoint="+srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error restoring: %v", err)
+	}
+	checkRecords(t, records)
+
+	if _, err := read(t, "s3://backups/missing?endpoint="+srv.URL); err == nil {
+		t.Errorf("Expected an error restoring a missing snapshot")
+	}
+}
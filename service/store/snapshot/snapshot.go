@@ -2,6 +2,7 @@ package snapshot
 
 import (
 	"encoding/gob"
+	"io"
 	"net/url"
 	"os"
 	"sync"
@@ -19,13 +20,19 @@ type Snapshot interface {
 	// Start opens a channel that receives *store.Record, adding any incoming records to a backup
 	// close() the channel to commit the results.
 	Start() (chan<- *store.Record, error)
-	// Wait waits for any operations to be committed to underlying storage
-	Wait()
+	// Wait waits for any operations to be committed to underlying storage, returning the error
+	// which stopped the snapshot if any
+	Wait() error
 }
 
 // SnapshotOptions configure a snapshotter
 type SnapshotOptions struct {
 	Destination string
+	// Database and Table being snapshotted, used by destinations which write a file per table
+	Database string
+	Table    string
+	// Incremental snapshots only have the records which changed since the last snapshot
+	Incremental bool
 }
 
 // SnapshotOption is an individual option
@@ -38,20 +45,65 @@ func Destination(dest string) SnapshotOption {
 	}
 }
 
+// SnapshotTable sets the database and table being snapshotted
+func SnapshotTable(database, table string) SnapshotOption {
+	return func(s *SnapshotOptions) {
+		s.Database = database
+		s.Table = table
+	}
+}
+
+// Incremental only snapshots the records which changed since the last snapshot to the destination.
+// The snapshots are chained by a manifest next to the destination, the first is a full snapshot.
+func Incremental() SnapshotOption {
+	return func(s *SnapshotOptions) {
+		s.Incremental = true
+	}
+}
+
+// NewSnapshot returns the snapshot for the scheme of the destination URL:
+//
+//	file:///path/to/file
+//	dir:///path/to/dir, a file per database and table
+//	s3://bucket/path/to/key?endpoint=http://localhost:9000&region=us-east-1
+//	stdout://
+//
+// Every destination accepts compress=gzip|zstd and passphrase=... or key_file=/path/to/key to
+// encrypt the snapshot with AES-GCM.
+func NewSnapshot(opts ...SnapshotOption) (Snapshot, error) {
+	var options SnapshotOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	u, err := url.Parse(options.Destination)
+	if err != nil {
+		return nil, errors.Wrap(err, "destination is invalid")
+	}
+	switch u.Scheme {
+	case "file":
+		return NewFileSnapshot(opts...), nil
+	case "dir":
+		return NewDirSnapshot(opts...), nil
+	case "s3":
+		return NewS3Snapshot(opts...), nil
+	case "stdout":
+		return NewStdoutSnapshot(opts...), nil
+	default:
+		return nil, errors.Errorf("unsupported destination scheme: %s", u.Scheme)
+	}
+}
+
 // FileSnapshot backs up incoming records to a File
 type FileSnapshot struct {
 	Options SnapshotOptions
 
-	records chan *store.Record
-	path    string
-	encoder *gob.Encoder
-	file    *os.File
-	wg      *sync.WaitGroup
+	writer
+	path string
 }
 
 // NewFileSnapshot returns a FileSnapshot
 func NewFileSnapshot(opts ...SnapshotOption) Snapshot {
-	f := &FileSnapshot{wg: &sync.WaitGroup{}}
+	f := &FileSnapshot{}
 	for _, o := range opts {
 		o(&f.Options)
 	}
@@ -63,68 +115,175 @@ func (f *FileSnapshot) Init(opts ...SnapshotOption) error {
 	for _, o := range opts {
 		o(&f.Options)
 	}
-	u, err := url.Parse(f.Options.Destination)
+	u, err := parseURL(f.Options.Destination, "file")
 	if err != nil {
 		return errors.Wrap(err, "destination is invalid")
 	}
-	if u.Scheme != "file" {
-		return errors.Errorf("unsupported scheme %s (wanted file)", u.Scheme)
-	}
-	if f.wg == nil {
-		f.wg = &sync.WaitGroup{}
+	if f.format, err = parseFormat(u.Query()); err != nil {
+		return err
 	}
 	f.path = u.Path
+	f.incremental = f.Options.Incremental
+	f.create = func(name string) (io.WriteCloser, error) {
+		return createFile(objectPath(f.path, name))
+	}
+	f.open = func(name string) (io.ReadCloser, error) {
+		return openFile(objectPath(f.path, name))
+	}
 	return nil
 }
 
+func createFile(path string) (io.WriteCloser, error) {
+	fi, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't open file %s", path)
+	}
+	return fi, nil
+}
+
+// parseURL parses the URL, checking its scheme and that it only has the format's parameters and
+// those passed
+func parseURL(raw, scheme string, params ...string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != scheme {
+		return nil, errors.Errorf("unsupported scheme %s (wanted %s)", u.Scheme, scheme)
+	}
+	if err := checkParams(u.Query(), append(params, formatParams...)...); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// writer encodes the records sent to it in the format, to the writer opened by the destination.
+// It implements Start and Wait for each destination. Destinations create and open the object with
+// the name next to the destination, the empty name being the destination itself, incremental
+// snapshots need both.
+type writer struct {
+	create      func(name string) (io.WriteCloser, error)
+	open        func(name string) (io.ReadCloser, error)
+	format      *format
+	incremental bool
+
+	records chan *store.Record
+	err     error
+	wg      sync.WaitGroup
+}
+
 // Start opens a channel which recieves *store.Record and writes them to storage
-func (f *FileSnapshot) Start() (chan<- *store.Record, error) {
-	if f.records != nil || f.encoder != nil || f.file != nil {
+func (w *writer) Start() (chan<- *store.Record, error) {
+	if w.create == nil {
+		return nil, errors.New("Snapshot must be initialised before it's started")
+	}
+	if w.records != nil {
 		return nil, errors.New("Snapshot is already in use")
 	}
-	fi, err := os.OpenFile(f.path, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o600)
+
+	var inc *increment
+	name := ""
+	if w.incremental {
+		if w.open == nil {
+			return nil, errors.New("destination doesn't support incremental snapshots")
+		}
+		m, err := readManifest(w.open)
+		if err != nil {
+			return nil, err
+		}
+		state, err := replay(w.open, w.format, m.Snapshots)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't read the last snapshot")
+		}
+		now := time.Now()
+		name = snapshotName(now)
+		inc = &increment{
+			manifest: m,
+			entry:    manifestEntry{Name: name, Time: now},
+			state:    state,
+			seen:     make(map[string]bool),
+		}
+	}
+
+	dst, err := w.create(name)
 	if err != nil {
-		return nil, errors.Wrapf(err, "couldn't open file %s", f.path)
+		return nil, err
 	}
-	f.encoder = gob.NewEncoder(fi)
-	f.file = fi
-	f.records = make(chan *store.Record)
-	go f.receiveRecords(f.records)
-	return f.records, nil
+	wc, err := w.format.writer(dst)
+	if err != nil {
+		dst.Close()
+		return nil, errors.Wrap(err, "couldn't write the snapshot")
+	}
+
+	w.err = nil
+	w.records = make(chan *store.Record)
+	w.wg.Add(1)
+	go w.receiveRecords(w.records, dst, wc, inc)
+	return w.records, nil
 }
 
 // Wait waits for the snapshotter to commit the backups to persistent storage
-func (f *FileSnapshot) Wait() {
-	f.wg.Wait()
-}
-
-func (f *FileSnapshot) receiveRecords(rec <-chan *store.Record) {
-	f.wg.Add(1)
-	for {
-		r, more := <-rec
-		if !more {
-			println("Stopping FileSnapshot")
-			f.file.Close()
-			f.encoder = nil
-			f.file = nil
-			f.records = nil
-			break
+func (w *writer) Wait() error {
+	w.wg.Wait()
+	return w.err
+}
+
+func (w *writer) receiveRecords(rec <-chan *store.Record, dst, wc io.WriteCloser, inc *increment) {
+	defer w.wg.Done()
+
+	encoder := gob.NewEncoder(wc)
+	for r := range rec {
+		// the records are drained after an error so the sender isn't blocked
+		if w.err != nil {
+			continue
 		}
 		ir := record{
 			Key: r.Key,
 		}
-		if r.Expiry != 0 {
+		// the expiry of incremental snapshots is from the time they're taken at, so the time left
+		// when restoring the snapshot at that time is the expiry read
+		if r.Expiry != 0 && inc != nil {
+			ir.ExpiresAt = inc.entry.Time.Add(r.Expiry)
+		} else if r.Expiry != 0 {
 			ir.ExpiresAt = time.Now().Add(r.Expiry)
 		}
 		ir.Value = make([]byte, len(r.Value))
 		copy(ir.Value, r.Value)
-		if err := f.encoder.Encode(ir); err != nil {
-			// only thing to do here is panic
-			panic(errors.Wrap(err, "couldn't write to file"))
+		if inc != nil {
+			if !inc.changed(&ir) {
+				continue
+			}
+			inc.entry.Records++
+		}
+		if err := encoder.Encode(ir); err != nil {
+			w.err = errors.Wrap(err, "couldn't write the snapshot")
+		}
+	}
+	if inc != nil && w.err == nil {
+		for _, t := range inc.deleted() {
+			if err := encoder.Encode(t); err != nil {
+				w.err = errors.Wrap(err, "couldn't write the snapshot")
+				break
+			}
+			inc.entry.Deleted++
+		}
+	}
+
+	// destinations which can be aborted are, so a failed snapshot doesn't replace a good one
+	if a, ok := dst.(interface{ CloseWithError(error) error }); ok && w.err != nil {
+		a.CloseWithError(w.err)
+	}
+	if err := wc.Close(); err != nil && w.err == nil {
+		w.err = errors.Wrap(err, "couldn't commit the snapshot")
+	}
+	// the snapshot is only part of the chain once it's in the manifest
+	if inc != nil && w.err == nil {
+		inc.manifest.Snapshots = append(inc.manifest.Snapshots, inc.entry)
+		if err := writeManifest(w.create, inc.manifest); err != nil {
+			w.err = errors.Wrap(err, "couldn't commit the snapshot")
 		}
-		println("encoded", ir.Key)
 	}
-	f.wg.Done()
+	w.records = nil
 }
 
 // record is a store.Record when serialised to persistent storage.
@@ -132,4 +291,6 @@ type record struct {
 	Key       string
 	Value     []byte
 	ExpiresAt time.Time
+	// Deleted records are tombstones in incremental snapshots
+	Deleted bool
 }
@@ -1,6 +1,11 @@
 package snapshot
 
 import (
+	"encoding/base64"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -25,7 +30,9 @@ func TestFileSnapshot(t *testing.T) {
 		recordChan <- td
 	}
 	close(recordChan)
-	f.Wait()
+	if err := f.Wait(); err != nil {
+		t.Fatal(err)
+	}
 
 	r := NewFileRestore(Source("invalid"))
 	if err := r.Init(); err == nil {
@@ -41,26 +48,189 @@ func TestFileSnapshot(t *testing.T) {
 	}
 	var receivedData []*store.Record
 	for r := range returnChan {
-		println("decoded", r.Key)
 		receivedData = append(receivedData, r)
 	}
+	if err := r.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, receivedData)
+}
+
+func TestFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "key")
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	if err := ioutil.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dest   string
+		source string
+	}{
+		{"plain", "file://" + dir + "/plain", "file://" + dir + "/plain"},
+		{"gzip", "file://" + dir + "/gzip?compress=gzip", "file://" + dir + "/gzip"},
+		{"zstd", "file://" + dir + "/zstd?compress=zstd", "file://" + dir + "/zstd"},
+		{"passphrase", "file://" + dir + "/pass?passphrase=secret", "file://" + dir + "/pass?passphrase=secret"},
+		{"key file", "file://" + dir + "/encrypted?compress=zstd&key_file=" + keyFile, "file://" + dir + "/encrypted?key_file=" + keyFile},
+		{"dir", "dir://" + dir + "/tables?compress=gzip&passphrase=secret", "dir://" + dir + "/tables?passphrase=secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(t, tt.dest, testData)
+			records, err := read(t, tt.source)
+			if err != nil {
+				t.Fatalf("Unexpected error restoring: %v", err)
+			}
+			checkRecords(t, records)
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "tables", "micro", "users.snapshot")); err != nil {
+		t.Errorf("Expected the table to be written to its own file: %v", err)
+	}
+
+	// the snapshot can't be read without the right passphrase
+	if _, err := read(t, "file://"+dir+"/pass?passphrase=wrong"); err == nil {
+		t.Errorf("Expected an error restoring with the wrong passphrase")
+	}
+	r, _ := NewRestore(Source("file://" + dir + "/pass"))
+	if err := r.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Start(); err == nil {
+		t.Errorf("Expected an error restoring without the passphrase")
+	}
+
+	// a truncated snapshot is an error rather than a partial restore
+	b, err := ioutil.ReadFile(filepath.Join(dir, "pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "truncated"), b[:len(b)-10], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read(t, "file://"+dir+"/truncated?passphrase=secret"); err == nil {
+		t.Errorf("Expected an error restoring a truncated snapshot")
+	}
 
+	// snapshots written before the header was added are plain gob
+	fi, err := os.Create(filepath.Join(dir, "legacy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := gob.NewEncoder(fi)
+	for _, td := range testData {
+		enc.Encode(record{Key: td.Key, Value: td.Value, ExpiresAt: time.Now().Add(td.Expiry)})
+	}
+	fi.Close()
+	records, err := read(t, "file://"+dir+"/legacy")
+	if err != nil {
+		t.Fatalf("Unexpected error restoring a legacy snapshot: %v", err)
+	}
+	checkRecords(t, records)
+
+	invalid := []string{
+		"file:///tmp/foo?compress=lz4",
+		"file:///tmp/foo?passphrase=foo&key_file=" + keyFile,
+		"file:///tmp/foo?bucket=foo",
+		"dir:///tmp/foo?endpoint=localhost",
+		"s3:///foo",
+		"ftp://foo/bar",
+	}
+	for _, dest := range invalid {
+		s, err := NewSnapshot(Destination(dest), SnapshotTable("micro", "users"))
+		if err == nil {
+			err = s.Init()
+		}
+		if err == nil {
+			t.Errorf("Expected an error for destination %v", dest)
+		}
+	}
+}
+
+// write the records to the destination
+func write(t *testing.T, dest string, records []*store.Record, opts ...SnapshotOption) {
+	opts = append([]SnapshotOption{Destination(dest), SnapshotTable("micro", "users")}, opts...)
+	s, err := NewSnapshot(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	recordChan, err := s.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		recordChan <- r
+	}
+	close(recordChan)
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// read the records from the source
+func read(t *testing.T, source string, opts ...RestoreOption) ([]*store.Record, error) {
+	opts = append([]RestoreOption{Source(source), RestoreTable("micro", "users")}, opts...)
+	r, err := NewRestore(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Init(); err != nil {
+		t.Fatal(err)
+	}
+	recordChan, err := r.Start()
+	if err != nil {
+		return nil, err
+	}
+	var records []*store.Record
+	for r := range recordChan {
+		records = append(records, r)
+	}
+	return records, r.Wait()
+}
+
+func checkRecords(t *testing.T, records []*store.Record) {
+	if len(records) != len(testData) {
+		t.Fatalf("Expected %v records, got %v", len(testData), len(records))
+	}
+	values := make(map[string]string)
+	for _, td := range testData {
+		values[td.Key] = string(td.Value)
+	}
+	for _, r := range records {
+		if v, ok := values[r.Key]; !ok || v != string(r.Value) {
+			t.Errorf("Unexpected record %v: %v", r.Key, string(r.Value))
+		}
+		if r.Expiry <= 0 || r.Expiry > time.Hour {
+			t.Errorf("Unexpected expiry of %v: %v", r.Key, r.Expiry)
+		}
+	}
 }
 
 var testData = []*store.Record{
 	{
 		Key:    "foo",
 		Value:  []byte(`foo`),
-		Expiry: time.Until(time.Now().Add(5 * time.Second)),
+		Expiry: time.Hour,
 	},
 	{
 		Key:    "bar",
 		Value:  []byte(`bar`),
-		Expiry: time.Until(time.Now().Add(5 * time.Second)),
+		Expiry: time.Hour,
 	},
 	{
 		Key:    "baz",
 		Value:  []byte(`baz`),
-		Expiry: time.Until(time.Now().Add(5 * time.Second)),
+		Expiry: time.Hour,
 	},
 }
@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// StdoutSnapshot writes the snapshot to stdout so it can be piped, e.g. to ssh or another
+// micro store restore reading stdin://
+type StdoutSnapshot struct {
+	Options SnapshotOptions
+
+	writer
+}
+
+// NewStdoutSnapshot returns a StdoutSnapshot
+func NewStdoutSnapshot(opts ...SnapshotOption) Snapshot {
+	s := &StdoutSnapshot{}
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	return s
+}
+
+// Init validates the options
+func (s *StdoutSnapshot) Init(opts ...SnapshotOption) error {
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	u, err := parseURL(s.Options.Destination, "stdout")
+	if err != nil {
+		return errors.Wrap(err, "destination is invalid")
+	}
+	if s.format, err = parseFormat(u.Query()); err != nil {
+		return err
+	}
+	if s.Options.Incremental {
+		return errors.New("stdout doesn't support incremental snapshots")
+	}
+	s.create = func(name string) (io.WriteCloser, error) {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return nil
+}
+
+// StdinRestore reads a snapshot from stdin
+type StdinRestore struct {
+	Options RestoreOptions
+
+	reader
+}
+
+// NewStdinRestore returns a StdinRestore
+func NewStdinRestore(opts ...RestoreOption) Restore {
+	s := &StdinRestore{}
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	return s
+}
+
+// Init validates the options
+func (s *StdinRestore) Init(opts ...RestoreOption) error {
+	for _, o := range opts {
+		o(&s.Options)
+	}
+	u, err := parseURL(s.Options.Source, "stdin")
+	if err != nil {
+		return errors.Wrap(err, "source is invalid")
+	}
+	if s.format, err = parseFormat(u.Query()); err != nil {
+		return err
+	}
+	s.at = s.Options.At
+	s.open = func(name string) (io.ReadCloser, error) {
+		// there's only the snapshot on stdin, never a manifest
+		if len(name) > 0 {
+			return nil, os.ErrNotExist
+		}
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+	return nil
+}
+
+// nopWriteCloser doesn't close the writer, stdout is left open once the snapshot is written
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
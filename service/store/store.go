@@ -5,7 +5,6 @@ import (
 	"github.com/micro/go-micro/v2"
 	log "github.com/micro/go-micro/v2/logger"
 	"github.com/micro/go-micro/v2/store"
-	pb "github.com/micro/go-micro/v2/store/service/proto"
 	mcli "github.com/micro/micro/v2/client/cli"
 	"github.com/micro/micro/v2/internal/helper"
 	"github.com/micro/micro/v2/service/store/handler"
@@ -35,14 +34,21 @@ func Run(ctx *cli.Context, srvOpts ...micro.Option) {
 		Address = ctx.String("address")
 	}
 
+	// the store handler, it watches the changes made through every instance once the broker is
+	// connected
+	var storeHandler *handler.Store
+	subscribe := micro.AfterStart(func() error {
+		return storeHandler.Subscribe()
+	})
+
 	// Initialise service
 	service := micro.NewService(
-		micro.Name(Name),
+		append(srvOpts, micro.Name(Name), subscribe)...,
 	)
 
-	// the store handler
-	storeHandler := &handler.Store{
+	storeHandler = &handler.Store{
 		Default: service.Options().Store,
+		Broker:  service.Options().Broker,
 		Stores:  make(map[string]bool),
 	}
 
@@ -80,7 +86,7 @@ func Run(ctx *cli.Context, srvOpts ...micro.Option) {
 		return storeHandler.Default, nil
 	}
 
-	pb.RegisterStoreHandler(service.Server(), storeHandler)
+	handler.Register(service.Server(), storeHandler)
 
 	// start the service
 	if err := service.Run(); err != nil {